@@ -0,0 +1,214 @@
+package bome
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/omecodes/errors"
+)
+
+// Aggregate is an aggregate function computed over the values found at a JSON path of entry values.
+type Aggregate struct {
+	name     string
+	function string
+	path     string
+	distinct bool
+}
+
+// Sum returns the aggregate that sums the numeric values found at path.
+func Sum(path string) Aggregate {
+	return Aggregate{name: "sum(" + path + ")", function: "sum", path: path}
+}
+
+// Avg returns the aggregate that averages the numeric values found at path.
+func Avg(path string) Aggregate {
+	return Aggregate{name: "avg(" + path + ")", function: "avg", path: path}
+}
+
+// Min returns the aggregate that computes the lowest numeric value found at path.
+func Min(path string) Aggregate {
+	return Aggregate{name: "min(" + path + ")", function: "min", path: path}
+}
+
+// Max returns the aggregate that computes the highest numeric value found at path.
+func Max(path string) Aggregate {
+	return Aggregate{name: "max(" + path + ")", function: "max", path: path}
+}
+
+// CountDistinct returns the aggregate that counts the distinct values found at path.
+func CountDistinct(path string) Aggregate {
+	return Aggregate{name: "count(distinct " + path + ")", function: "count", path: path, distinct: true}
+}
+
+// CountAll returns the aggregate that counts entries.
+func CountAll() Aggregate {
+	return Aggregate{name: "count(*)", function: "count"}
+}
+
+// As returns a copy of the aggregate named name. Aggregates are named after their function and path by default.
+func (a Aggregate) As(name string) Aggregate {
+	a.name = name
+	return a
+}
+
+func (a Aggregate) sql(dialect Dialect, field string) string {
+	if a.path == "" {
+		return "count(*)"
+	}
+
+	value := dialect.JSONExtract(field, a.path)
+	if a.distinct {
+		return fmt.Sprintf("%s(distinct %s)", a.function, value)
+	}
+	return fmt.Sprintf("%s(%s)", a.function, dialect.SortKey(value, true))
+}
+
+// AggregateRow is a row of aggregation results.
+type AggregateRow struct {
+	// Group are the values found at the group by paths, in order. Entries with no value at a path are grouped under an empty string.
+	Group []string
+
+	// Values are the values of the aggregates, in order. Aggregates of no value are 0.
+	Values []float64
+
+	names []string
+}
+
+// Value returns the value of the aggregate named name, or 0 if there is no such aggregate.
+func (r *AggregateRow) Value(name string) float64 {
+	for i, n := range r.names {
+		if n == name {
+			return r.Values[i]
+		}
+	}
+	return 0
+}
+
+// Aggregation computes aggregates over the values of the entries of a JSON collection.
+type Aggregation struct {
+	holder     *JsonValueHolder
+	aggregates []Aggregate
+	filter     BoolExpr
+	groupBy    []string
+}
+
+// Aggregate returns an aggregation computing aggregates over all entries.
+func (s *JsonValueHolder) Aggregate(aggregates ...Aggregate) *Aggregation {
+	return &Aggregation{holder: s, aggregates: aggregates}
+}
+
+// Where restricts the aggregation to the entries matching condition.
+func (a *Aggregation) Where(condition BoolExpr) *Aggregation {
+	a.filter = condition
+	return a
+}
+
+// GroupBy computes the aggregates for each group of entries which have the same values at paths.
+// Rows are sorted by group values.
+func (a *Aggregation) GroupBy(paths ...string) *Aggregation {
+	a.groupBy = append(a.groupBy, paths...)
+	return a
+}
+
+// Rows computes the aggregation. Without group by paths, a single row is returned.
+func (a *Aggregation) Rows() ([]*AggregateRow, error) {
+	return a.RowsContext(context.Background())
+}
+
+func (a *Aggregation) RowsContext(ctx context.Context) ([]*AggregateRow, error) {
+	s := a.holder
+
+	filter := a.filter
+	if filter == nil {
+		filter = True()
+	}
+	clause, args, err := s.condition(filter)
+	if err != nil {
+		return nil, err
+	}
+	clause, args = s.visible(clause, args)
+
+	var columns, groups []string
+	for i, path := range a.groupBy {
+		group := s.dialect.JSONExtract(s.field, path)
+		groups = append(groups, group)
+		columns = append(columns, fmt.Sprintf("%s as g_%d", group, i))
+	}
+	for i, aggregate := range a.aggregates {
+		columns = append(columns, fmt.Sprintf("%s as a_%d", aggregate.sql(s.dialect, s.field), i))
+	}
+
+	rawQuery := fmt.Sprintf("select %s from $table$ where %s", strings.Join(columns, ", "), clause)
+	if len(groups) > 0 {
+		rawQuery += fmt.Sprintf(" group by %s order by %s", strings.Join(groups, ", "), strings.Join(groups, ", "))
+	}
+
+	c, err := s.client(ctx).QueryContext(ctx, rawQuery+";", aggregateRowScanner, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = c.Close()
+	}()
+
+	names := make([]string, len(a.aggregates))
+	for i, aggregate := range a.aggregates {
+		names[i] = aggregate.name
+	}
+
+	var rows []*AggregateRow
+	for c.HasNext() {
+		o, err := c.Entry()
+		if err != nil {
+			return nil, err
+		}
+		row := o.(*AggregateRow)
+		row.names = names
+		rows = append(rows, row)
+	}
+	return rows, cursorError(c)
+}
+
+// scanAggregateRow scans the group columns, named g_*, and the aggregate columns, named a_*, of aggregation rows.
+func scanAggregateRow(row Row) (interface{}, error) {
+	rows, ok := row.(interface{ Columns() ([]string, error) })
+	if !ok {
+		return nil, errors.NotSupported()
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]sql.NullString, 0, len(columns))
+	values := make([]sql.NullFloat64, 0, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i, column := range columns {
+		if strings.HasPrefix(column, "g_") {
+			groups = append(groups, sql.NullString{})
+			dest[i] = &groups[len(groups)-1]
+		} else {
+			values = append(values, sql.NullFloat64{})
+			dest[i] = &values[len(values)-1]
+		}
+	}
+
+	if err = row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	result := &AggregateRow{
+		Group:  make([]string, len(groups)),
+		Values: make([]float64, len(values)),
+	}
+	for i, group := range groups {
+		result.Group[i] = group.String
+	}
+	for i, value := range values {
+		result.Values[i] = value.Float64
+	}
+	return result, nil
+}
@@ -0,0 +1,267 @@
+package bome
+
+import (
+	"context"
+	"strings"
+)
+
+// BatchResult is returned by batch write operations. Rows are written by chunks sized
+// to the dialect placeholders limit, all in the same transaction.
+type BatchResult struct {
+	// Chunks are the results of the chunks that were executed, in order. The last one holds the error
+	// of the failed chunk, if any.
+	Chunks []Result
+
+	// FailedKey is the key of the first entry that could not be written. It is a string for Map and MList,
+	// a DoubleMapKey for DMap and an int64 index for List.
+	FailedKey interface{}
+
+	Error error
+}
+
+// Inserted returns the number of inserted entries.
+func (r *BatchResult) Inserted() int64 {
+	var n int64
+	for _, chunk := range r.Chunks {
+		n += chunk.Inserted
+	}
+	return n
+}
+
+// Updated returns the number of updated entries.
+func (r *BatchResult) Updated() int64 {
+	var n int64
+	for _, chunk := range r.Chunks {
+		n += chunk.Updated
+	}
+	return n
+}
+
+// AffectedRows returns the number of rows affected by all chunks.
+func (r *BatchResult) AffectedRows() int64 {
+	var n int64
+	for _, chunk := range r.Chunks {
+		n += chunk.AffectedRows
+	}
+	return n
+}
+
+// batchRow is a row written by a batch operation.
+type batchRow struct {
+	key  interface{}
+	args []interface{}
+}
+
+// batchTransaction is a collection bound to the transaction of a batch operation.
+type batchTransaction interface {
+	Commit() error
+	Rollback() error
+}
+
+// writeBatch runs stmt for rows by chunks. Each chunk runs in a savepoint: when a chunk fails, it is rolled back
+// and its rows are replayed one by one to find the first failing one, then rolled back again.
+// Chunks written before the failure are left to the transaction owner.
+func writeBatch(ctx context.Context, client Client, dialect Dialect, stmt *statement, rows []batchRow) BatchResult {
+	var result BatchResult
+
+	size := dialect.MaxPlaceholders() / stmt.width
+	if size < 1 {
+		size = 1
+	}
+
+	for start := 0; start < len(rows); start += size {
+		end := start + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+		chunk := rows[start:end]
+
+		if err := client.ExecContext(ctx, "savepoint bome_batch;").Error; err != nil {
+			result.Error = err
+			return result
+		}
+
+		var args []interface{}
+		for _, row := range chunk {
+			args = append(args, row.args...)
+		}
+
+		chunkResult := stmt.run(ctx, client, len(chunk), args)
+		result.Chunks = append(result.Chunks, chunkResult)
+		if chunkResult.Error != nil {
+			result.Error = chunkResult.Error
+			result.FailedKey = failedRowKey(ctx, client, stmt, chunk)
+			return result
+		}
+
+		if err := client.ExecContext(ctx, "release savepoint bome_batch;").Error; err != nil {
+			result.Error = err
+			return result
+		}
+	}
+	return result
+}
+
+// failedRowKey rolls back the failed chunk and returns the key of its first row that fails when rows
+// are written one by one. The rows written while searching are rolled back.
+func failedRowKey(ctx context.Context, client Client, stmt *statement, chunk []batchRow) interface{} {
+	defer func() {
+		_ = client.ExecContext(ctx, "rollback to savepoint bome_batch;")
+		_ = client.ExecContext(ctx, "release savepoint bome_batch;")
+	}()
+
+	if client.ExecContext(ctx, "rollback to savepoint bome_batch;").Error != nil {
+		return chunk[0].key
+	}
+
+	for _, row := range chunk {
+		if stmt.run(ctx, client, 1, row.args).Error != nil {
+			return row.key
+		}
+	}
+	return chunk[0].key
+}
+
+// endBatch commits or rolls back the transaction of a batch operation if the operation owns it.
+func endBatch(owned bool, tx batchTransaction, result BatchResult) BatchResult {
+	if !owned {
+		return result
+	}
+
+	if result.Error != nil {
+		_ = tx.Rollback()
+		return result
+	}
+
+	if err := tx.Commit(); err != nil {
+		result.Error = err
+	}
+	return result
+}
+
+// deleteStatement returns the statement that deletes the rows which keys are bound to its arguments.
+func deleteStatement(keys []string) *statement {
+	return &statement{
+		width: len(keys),
+		query: func(n int) string {
+			return "delete from $table$ where " + inKeys(keys, n) + ";"
+		},
+		exec: func(ctx context.Context, client Client, rawQuery string, _ int, args []interface{}) Result {
+			return client.ExecContext(ctx, rawQuery, args...)
+		},
+	}
+}
+
+// BatchRead is returned by batch read operations.
+type BatchRead[K comparable, V any] struct {
+	// Found maps the keys that were found to their entries.
+	Found map[K]V
+
+	// Missing are the keys that were not found, in the requested order.
+	Missing []K
+
+	keys []K
+}
+
+// Ordered returns the found entries in the requested keys order.
+func (r *BatchRead[K, V]) Ordered() []V {
+	values := make([]V, 0, len(r.Found))
+	for _, key := range r.keys {
+		if value, found := r.Found[key]; found {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// batchQuery is a read query of a variable number of keys.
+type batchQuery[K comparable, V any] struct {
+	// query returns the query that reads n keys.
+	query func(n int) string
+
+	// args returns the arguments of a key.
+	args func(key K) []interface{}
+
+	// extra are the arguments bound after the keys arguments.
+	extra []interface{}
+
+	scanner string
+
+	// entry returns the key and the entry of a scanned row.
+	entry func(o interface{}) (K, V)
+}
+
+// readBatch reads the entries of keys with chunked queries sized to the dialect placeholders limit.
+func readBatch[K comparable, V any](ctx context.Context, client Client, dialect Dialect, q *batchQuery[K, V], keys []K) (*BatchRead[K, V], error) {
+	result := &BatchRead[K, V]{Found: map[K]V{}}
+
+	seen := map[K]bool{}
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			result.keys = append(result.keys, key)
+		}
+	}
+	if len(result.keys) == 0 {
+		return result, nil
+	}
+
+	width := len(q.args(result.keys[0]))
+	size := (dialect.MaxPlaceholders() - len(q.extra)) / width
+	if size < 1 {
+		size = 1
+	}
+
+	for start := 0; start < len(result.keys); start += size {
+		end := start + size
+		if end > len(result.keys) {
+			end = len(result.keys)
+		}
+
+		var args []interface{}
+		for _, key := range result.keys[start:end] {
+			args = append(args, q.args(key)...)
+		}
+		args = append(args, q.extra...)
+
+		if err := readChunk(ctx, client, q, end-start, args, result.Found); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range result.keys {
+		if _, found := result.Found[key]; !found {
+			result.Missing = append(result.Missing, key)
+		}
+	}
+	return result, nil
+}
+
+func readChunk[K comparable, V any](ctx context.Context, client Client, q *batchQuery[K, V], n int, args []interface{}, found map[K]V) error {
+	c, err := client.QueryContext(ctx, q.query(n), q.scanner, args...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = c.Close()
+	}()
+
+	for c.HasNext() {
+		o, err := c.Entry()
+		if err != nil {
+			return err
+		}
+		key, value := q.entry(o)
+		found[key] = value
+	}
+	return cursorError(c)
+}
+
+// inKeys returns the condition that matches the rows of n keys made of the columns keys.
+func inKeys(keys []string, n int) string {
+	if len(keys) == 1 {
+		return keys[0] + " in (" + placeholders(n) + ")"
+	}
+	row := "(" + placeholders(len(keys)) + ")"
+	return "(" + strings.Join(keys, ", ") + ") in (" + strings.TrimSuffix(strings.Repeat(row+",", n), ",") + ")"
+}
@@ -1,9 +1,10 @@
 package bome
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -11,9 +12,6 @@ import (
 )
 
 const (
-	mysqlIndexScanner  = "mysql_index_scanner"
-	sqliteIndexScanner = "sqlite_index_scanner"
-
 	// VarPrefix is used to set table name prefix dynamically.
 	VarPrefix = "$prefix$"
 
@@ -25,8 +23,14 @@ const (
 	// VarAutoIncrement is used set auto_increment to int field. DB replaces it with the dialect proper value.
 	VarAutoIncrement = "$auto_increment$"
 
+	// VarAutoIncrementType is the type of auto incremented integer fields. DB replaces it with the dialect proper value.
+	VarAutoIncrementType = "$auto_increment_type$"
+
 	// VarLocate is the equivalent of string replace.
 	VarLocate = "$locate$"
+
+	// VarJSON is used to define JSON columns. DB replaces it with the dialect JSON column type.
+	VarJSON = "$json$"
 )
 
 // Result is returned when executing a write operation.
@@ -34,91 +38,80 @@ type Result struct {
 	Error        error
 	LastInserted int64
 	AffectedRows int64
+
+	// Inserted and Updated are the numbers of entries inserted and updated by collection save operations.
+	Inserted int64
+	Updated  int64
 }
 
 // DB is an SQL database wrapper.
 type DB struct {
-	sqlDb            *sql.DB
-	mux              *sync.RWMutex
-	dialect          string
-	isSQLite         bool
-	vars             map[string]string
-	tableDefs        []string
-	migrationScripts []string
-	scanners         map[string]Scanner
-	initDone         bool
+	sqlDb      *sql.DB
+	mux        *sync.RWMutex
+	dialect    Dialect
+	isSQLite   bool
+	vars       map[string]string
+	tableDefs  []string
+	migrations []*Migration
+	scanners   map[string]Scanner
+	initDone   bool
 }
 
 // Open detects and creates an instance of DB DB according to the dialect.
+// The DSN scheme is the name of one of the registered dialects.
 func Open(dsn string) (*DB, error) {
 	u, err := url.Parse(dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	if u.Scheme == SQLite3 {
-		db, err := sql.Open(SQLite3, strings.TrimPrefix(dsn, "sqlite3://"))
-		if err != nil {
-			return nil, err
-		}
-
-		dbome := new(DB)
-		dbome.sqlDb = db
-		dbome.isSQLite = true
-		dbome.dialect = SQLite3
-		dbome.SetVariable(VarLocate, "instr")
-		dbome.SetVariable(VarAutoIncrement, "AUTOINCREMENT")
-		dbome.SetVariable(VarEngine, "")
-		if _, err := dbome.sqlDb.Exec("PRAGMA foreign_keys=ON"); err != nil {
-			return nil, err
-		}
-		dbome.mux = new(sync.RWMutex)
-		return dbome, nil
+	dialect, found := LookupDialect(u.Scheme)
+	if !found {
+		return nil, errors.Unimplemented()
+	}
 
-	} else if u.Scheme == MySQL {
-		db, err := sql.Open(MySQL, strings.TrimPrefix(dsn, "mysql://"))
-		if err != nil {
-			return nil, err
-		}
+	db, err := sql.Open(dialect.DriverName(), dialect.DataSourceName(dsn))
+	if err != nil {
+		return nil, err
+	}
+	return NewDB(db, dialect)
+}
 
-		dbome := new(DB)
-		dbome.sqlDb = db
-		dbome.dialect = MySQL
-		dbome.SetVariable(VarLocate, "locate")
-		dbome.SetVariable(VarAutoIncrement, "AUTO_INCREMENT")
-		dbome.SetVariable(VarEngine, "engine=InnoDB")
-		return dbome, nil
+// NewDB creates a wrapper of dbConn that renders SQL with dialect.
+func NewDB(dbConn *sql.DB, dialect Dialect) (*DB, error) {
+	db := new(DB)
+	db.sqlDb = dbConn
+	db.dialect = dialect
+	for name, scanner := range defaultScanners {
+		db.RegisterScanner(name, scanner)
+	}
+	for name, value := range dialect.Variables() {
+		db.SetVariable(name, value)
+	}
+	if err := dialect.Configure(dbConn); err != nil {
+		return nil, err
+	}
 
-	} else {
-		return nil, errors.Unimplemented()
+	if dialect.DriverName() == SQLite3 {
+		db.isSQLite = true
+		db.mux = new(sync.RWMutex)
 	}
+	return db, nil
 }
 
 // New creates a MySQL wrapper.
 func New(dbConn *sql.DB) (*DB, error) {
-	db := new(DB)
-	db.sqlDb = dbConn
-	db.dialect = MySQL
-	db.SetVariable(VarLocate, "locate")
-	db.SetVariable(VarAutoIncrement, "AUTO_INCREMENT")
-	db.SetVariable(VarEngine, "engine=InnoDB")
-	return db, nil
+	return NewDB(dbConn, MySQLDialect{})
 }
 
 // NewLite creates an SQLite wrapper.
 func NewLite(dbConn *sql.DB) (*DB, error) {
-	db := new(DB)
-	db.sqlDb = dbConn
-	db.isSQLite = true
-	db.dialect = SQLite3
-	db.SetVariable(VarLocate, "instr")
-	db.SetVariable(VarAutoIncrement, "AUTOINCREMENT")
-	db.SetVariable(VarEngine, "")
-	if _, err := db.sqlDb.Exec("PRAGMA foreign_keys=ON"); err != nil {
-		return nil, err
-	}
-	db.mux = new(sync.RWMutex)
-	return db, nil
+	return NewDB(dbConn, SQLiteDialect{})
+}
+
+// NewPostgres creates a PostgreSQL wrapper.
+func NewPostgres(dbConn *sql.DB) (*DB, error) {
+	return NewDB(dbConn, PostgresDialect{})
 }
 
 // Init must be call after custom variable and statements are set. And before any request is executed.
@@ -127,12 +120,6 @@ func (db *DB) Init() error {
 }
 
 func (db *DB) init() error {
-	for name, scanner := range defaultScanners {
-		db.RegisterScanner(name, scanner)
-	}
-	db.RegisterScanner(mysqlIndexScanner, NewScannerFunc(db.mysqlIndexScan))
-	db.RegisterScanner(sqliteIndexScanner, NewScannerFunc(db.sqliteIndexScan))
-
 	if db.tableDefs != nil && len(db.tableDefs) > 0 {
 		for _, schema := range db.tableDefs {
 			for name, value := range db.vars {
@@ -149,22 +136,9 @@ func (db *DB) init() error {
 	return nil
 }
 
-// Migrate executes registered migration scripts. And must be call before init.
-func (db *DB) Migrate() error {
-	if !db.initDone {
-		return errors.New()
-	}
-	for _, ms := range db.migrationScripts {
-		for name, value := range db.vars {
-			ms = strings.Replace(ms, name, value, -1)
-		}
-
-		_, err := db.sqlDb.Exec(ms)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+// Dialect returns the dialect used to render SQL.
+func (db *DB) Dialect() Dialect {
+	return db.dialect
 }
 
 // IsSQLite return true if wrapped database is SQLite.
@@ -199,10 +173,20 @@ func (db *DB) SetTableName(tableName string) *DB {
 	return db
 }
 
-// AddMigrationScript adds an migration script.
+// AddMigrationScript adds an migration script. It is registered as a migration which version follows
+// the greatest registered version.
 func (db *DB) AddMigrationScript(s string) *DB {
-	db.migrationScripts = append(db.migrationScripts, s)
-	return db
+	var version int64
+	for _, m := range db.migrations {
+		if m.Version > version {
+			version = m.Version
+		}
+	}
+	return db.AddMigration(&Migration{
+		Version: version + 1,
+		Name:    "script_" + strconv.FormatInt(version+1, 10),
+		Up:      s,
+	})
 }
 
 // AddTableDefinition adds a table definition. Query can contains predefined or custom defined variables.
@@ -213,7 +197,12 @@ func (db *DB) AddTableDefinition(schema string) *DB {
 
 // BeginTx begins a transaction.
 func (db *DB) BeginTx() (*TX, error) {
-	tx, err := db.sqlDb.Begin()
+	return db.BeginTxContext(context.Background(), nil)
+}
+
+// BeginTxContext begins a transaction bound to ctx. The transaction is rolled back if ctx is canceled before it is committed.
+func (db *DB) BeginTxContext(ctx context.Context, opts *sql.TxOptions) (*TX, error) {
+	tx, err := db.sqlDb.BeginTx(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -224,13 +213,16 @@ func (db *DB) BeginTx() (*TX, error) {
 	return tr, nil
 }
 
-// AddUniqueIndex adds a table index.
+// AddUniqueIndex adds a unique table index, whatever the value of index.NonUnique.
 func (db *DB) AddUniqueIndex(index Index, forceUpdate bool) error {
 	if !db.initDone {
 		return errors.New()
 	}
 
+	index.NonUnique = false
+
 	for varName, value := range db.vars {
+		index.Name = strings.Replace(index.Name, varName, value, -1)
 		index.Table = strings.Replace(index.Table, varName, value, -1)
 	}
 	hasIndex, err := db.TableHasIndex(index)
@@ -239,39 +231,73 @@ func (db *DB) AddUniqueIndex(index Index, forceUpdate bool) error {
 	}
 
 	if hasIndex && forceUpdate {
-		var dropIndexSQL string
-		if db.dialect == MySQL {
-			dropIndexSQL = fmt.Sprintf("drop index %s on %s", index.Name, index.Table)
-		} else {
-			dropIndexSQL = fmt.Sprintf("drop index if exists %s on %s", index.Name, index.Table)
+		result := db.Exec(db.dialect.DropIndexQuery(index))
+		if result.Error != nil {
+			return result.Error
 		}
+	}
 
-		result := db.Exec(dropIndexSQL)
+	if !hasIndex || forceUpdate {
+		result := db.Exec(db.dialect.CreateIndexQuery(index))
 		if result.Error != nil {
 			return result.Error
 		}
 	}
 
-	if !hasIndex || forceUpdate {
-		var createIndexSQL string
-		if db.dialect == MySQL {
-			createIndexSQL = fmt.Sprintf("create unique index %s on %s(%s)", index.Name, index.Table, strings.Join(index.Fields, ","))
-		} else {
-			createIndexSQL = fmt.Sprintf("create unique index if not exists %s on %s(%s)", index.Name, index.Table, strings.Join(index.Fields, ","))
+	return nil
+}
+
+// EnsureIndex creates index, or recreates it when the existing index with the same name has a different definition.
+// It fails with an IndexUniquenessError rather than replacing a unique index with a non-unique one.
+func (db *DB) EnsureIndex(index Index) error {
+	if !db.initDone {
+		return errors.New()
+	}
+
+	if index.Where != "" && !db.dialect.SupportsPartialIndexes() {
+		return errors.NotSupported()
+	}
+
+	for varName, value := range db.vars {
+		index.Name = strings.Replace(index.Name, varName, value, -1)
+		index.Table = strings.Replace(index.Table, varName, value, -1)
+		index.Where = strings.Replace(index.Where, varName, value, -1)
+	}
+	hasIndex, err := db.TableHasIndex(index)
+	if err != nil {
+		return err
+	}
+
+	if hasIndex {
+		indexes, err := db.Indexes(index.Table)
+		if err != nil {
+			return err
+		}
+
+		for _, existing := range indexes {
+			if !strings.EqualFold(existing.Name, index.Name) {
+				continue
+			}
+			if index.sameDefinition(existing, db.dialect.SupportsIndexPrefixLengths()) {
+				return nil
+			}
+			if index.NonUnique && !existing.NonUnique {
+				return &IndexUniquenessError{Name: index.Name}
+			}
 		}
 
-		result := db.Exec(createIndexSQL)
+		result := db.Exec(db.dialect.DropIndexQuery(index))
 		if result.Error != nil {
 			return result.Error
 		}
 	}
 
-	return nil
+	return db.Exec(db.dialect.CreateIndexQuery(index)).Error
 }
 
 // AddForeignKey creates a foreign key.
 func (db *DB) AddForeignKey(fk *ForeignKey) error {
-	if db.dialect == MySQL {
+	if db.dialect.SupportsAlterTableForeignKeys() {
 		o, err := db.QueryFirst("SELECT 1 FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS WHERE CONSTRAINT_NAME=?", BoolScanner, fk.Name)
 		if err != nil {
 			if !errors.IsNotFound(err) {
@@ -296,25 +322,23 @@ func (db *DB) RegisterScanner(name string, scanner Scanner) *DB {
 	return db
 }
 
+// RegisterStructScanner registers a scanner that reads rows into new values of prototype type. See NewStructScanner.
+func (db *DB) RegisterStructScanner(name string, prototype interface{}) error {
+	scanner, err := NewStructScanner(prototype)
+	if err != nil {
+		return err
+	}
+	db.RegisterScanner(name, scanner)
+	return nil
+}
+
 // TableHasIndex tells if the given index exists.
 func (db *DB) TableHasIndex(index Index) (bool, error) {
 	if !db.initDone {
 		return false, errors.New()
 	}
 
-	var (
-		scannerName string
-		rawQuery    string
-	)
-	if db.dialect == MySQL {
-		rawQuery = fmt.Sprintf("SHOW INDEX FROM %s", index.Table)
-		scannerName = mysqlIndexScanner
-	} else {
-		rawQuery = fmt.Sprintf("PRAGMA INDEX_LIST('%s')", index.Table)
-		scannerName = sqliteIndexScanner
-	}
-
-	c, err := db.Query(rawQuery, scannerName)
+	c, err := db.Query(db.dialect.IndexesQuery(index.Table), StringScanner)
 	if err != nil {
 		return false, err
 	}
@@ -323,13 +347,12 @@ func (db *DB) TableHasIndex(index Index) (bool, error) {
 	}()
 
 	for c.HasNext() {
-		ind, err := c.Entry()
+		name, err := c.Entry()
 		if err != nil {
 			return false, err
 		}
 
-		rowIndex := ind.(Index)
-		if rowIndex.Name == index.Name {
+		if name.(string) == index.Name {
 			return true, nil
 		}
 	}
@@ -339,10 +362,17 @@ func (db *DB) TableHasIndex(index Index) (bool, error) {
 // Query executes a raw query.
 // scannerName: is one of the registered scanner name.
 func (db *DB) Query(query string, scannerName string, params ...interface{}) (Cursor, error) {
+	return db.QueryContext(context.Background(), query, scannerName, params...)
+}
+
+// QueryContext executes a raw query with ctx.
+// scannerName: is one of the registered scanner name.
+func (db *DB) QueryContext(ctx context.Context, query string, scannerName string, params ...interface{}) (Cursor, error) {
 	for name, value := range db.vars {
 		query = strings.Replace(query, name, value, -1)
 	}
-	rows, err := db.sqlDb.Query(query, params...)
+	query = db.dialect.Rebind(query)
+	rows, err := db.sqlDb.QueryContext(ctx, query, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -356,10 +386,16 @@ func (db *DB) Query(query string, scannerName string, params ...interface{}) (Cu
 // QueryObjects executes a raw query.
 // scannerName: is one of the registered scanner name.
 func (db *DB) QueryObjects(query string, params ...interface{}) (Cursor, error) {
+	return db.QueryObjectsContext(context.Background(), query, params...)
+}
+
+// QueryObjectsContext executes a raw query with ctx.
+func (db *DB) QueryObjectsContext(ctx context.Context, query string, params ...interface{}) (Cursor, error) {
 	for name, value := range db.vars {
 		query = strings.Replace(query, name, value, -1)
 	}
-	rows, err := db.sqlDb.Query(query, params...)
+	query = db.dialect.Rebind(query)
+	rows, err := db.sqlDb.QueryContext(ctx, query, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -373,11 +409,18 @@ func (db *DB) QueryObjects(query string, params ...interface{}) (Cursor, error)
 // QueryFirst gets the first result of the query result.
 // scannerName: is one of the registered scanner name.
 func (db *DB) QueryFirst(query string, scannerName string, params ...interface{}) (interface{}, error) {
+	return db.QueryFirstContext(context.Background(), query, scannerName, params...)
+}
+
+// QueryFirstContext gets the first result of the query result with ctx.
+// scannerName: is one of the registered scanner name.
+func (db *DB) QueryFirstContext(ctx context.Context, query string, scannerName string, params ...interface{}) (interface{}, error) {
 	for name, value := range db.vars {
 		query = strings.Replace(query, name, value, -1)
 	}
+	query = db.dialect.Rebind(query)
 
-	rows, err := db.sqlDb.Query(query, params...)
+	rows, err := db.sqlDb.QueryContext(ctx, query, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -399,6 +442,11 @@ func (db *DB) QueryFirst(query string, scannerName string, params ...interface{}
 
 // Exec executes the given raw query.
 func (db *DB) Exec(rawQuery string, params ...interface{}) Result {
+	return db.ExecContext(context.Background(), rawQuery, params...)
+}
+
+// ExecContext executes the given raw query with ctx.
+func (db *DB) ExecContext(ctx context.Context, rawQuery string, params ...interface{}) Result {
 	db.wLock()
 	defer db.wUnlock()
 	var r sql.Result
@@ -406,50 +454,15 @@ func (db *DB) Exec(rawQuery string, params ...interface{}) Result {
 	for name, value := range db.vars {
 		rawQuery = strings.Replace(rawQuery, name, value, -1)
 	}
-	r, result.Error = db.sqlDb.Exec(rawQuery, params...)
-	if result.Error == nil && db.dialect != SQLite3 {
+	rawQuery = db.dialect.Rebind(rawQuery)
+	r, result.Error = db.sqlDb.ExecContext(ctx, rawQuery, params...)
+	if result.Error == nil {
 		result.LastInserted, _ = r.LastInsertId()
 		result.AffectedRows, _ = r.RowsAffected()
 	}
 	return result
 }
 
-func (db *DB) sqliteIndexScan(row Row) (interface{}, error) {
-	db.rLock()
-	defer db.rUnLock()
-
-	var index Index
-	m, err := db.rowToMap(row.(*sql.Rows))
-	if err != nil {
-		return nil, err
-	}
-
-	var ok bool
-	index.Name, ok = m["name"].(string)
-	if !ok {
-		return nil, errors.NotFound()
-	}
-	return index, nil
-}
-
-func (db *DB) mysqlIndexScan(row Row) (interface{}, error) {
-	var index Index
-	m, err := db.rowToMap(row.(*sql.Rows))
-	if err != nil {
-		return nil, err
-	}
-
-	index.Name = fmt.Sprintf("%s", m["Key_name"])
-	if index.Name == "" {
-		return nil, errors.NotFound()
-	}
-	index.Table = fmt.Sprintf("%s", m["Table"])
-	if index.Table == "" {
-		return nil, errors.NotFound()
-	}
-	return index, nil
-}
-
 func (db *DB) rowToMap(rows *sql.Rows) (map[string]interface{}, error) {
 	cols, _ := rows.Columns()
 	columns := make([]interface{}, len(cols))
@@ -56,13 +56,11 @@ func (b *Builder) AddIndexes(indexes ...*Index) *Builder {
 }
 
 func (b *Builder) Map(opts ...Option) (*Map, error) {
-	if b.dialect != SQLite3 && b.dialect != MySQL {
-		return nil, errors.NotSupported()
-	}
-
 	fields := []string{
 		"name varchar(255) not null primary key",
-		"value json not null",
+		"value $json$ not null",
+		"expires_at bigint",
+		"version bigint not null default 1",
 	}
 
 	db, err := b.initTable(fields, opts...)
@@ -70,27 +68,35 @@ func (b *Builder) Map(opts ...Option) (*Map, error) {
 		return nil, err
 	}
 
+	err = ensureColumns(db, "expires_at bigint", "version bigint not null default 1")
+	if err != nil {
+		return nil, err
+	}
+
 	return &Map{
 		JsonValueHolder: &JsonValueHolder{
-			DB:      db,
-			field:   "value",
-			dialect: b.dialect,
+			DB:        db,
+			field:     "value",
+			columns:   "name, value",
+			expiry:    true,
+			versioned: true,
+			keys:      []string{"name"},
+			dialect:   db.dialect,
 		},
 		tableName: b.tableName,
 		DB:        db,
-		dialect:   b.dialect,
+		dialect:   db.dialect,
+		sweeper:   expirySweeper(db, "name", opts...),
 	}, nil
 }
 
 func (b *Builder) DMap(opts ...Option) (*DMap, error) {
-	if b.dialect != SQLite3 && b.dialect != MySQL {
-		return nil, errors.NotSupported()
-	}
-
 	fields := []string{
 		"first_key varchar(255) not null",
 		"second_key varchar(255) not null",
-		"value json not null",
+		"value $json$ not null",
+		"expires_at bigint",
+		"version bigint not null default 1",
 	}
 
 	db, err := b.initTable(fields, opts...)
@@ -98,67 +104,72 @@ func (b *Builder) DMap(opts ...Option) (*DMap, error) {
 		return nil, err
 	}
 
-	err = db.AddUniqueIndex(Index{Name: "unique_keys", Table: "$table$", Fields: []string{"first_key", "second_key"}}, false)
+	// Index names are database-wide on SQLite and PostgreSQL, so each table gets its own.
+	err = db.AddUniqueIndex(Index{Name: "$table$_unique_keys", Table: "$table$", Fields: []string{"first_key", "second_key"}}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ensureColumns(db, "expires_at bigint", "version bigint not null default 1")
 	if err != nil {
 		return nil, err
 	}
 
 	return &DMap{
 		JsonValueHolder: &JsonValueHolder{
-			DB:      db,
-			field:   "value",
-			dialect: b.dialect,
+			DB:        db,
+			field:     "value",
+			columns:   "first_key, second_key, value",
+			expiry:    true,
+			versioned: true,
+			keys:      []string{"first_key", "second_key"},
+			dialect:   db.dialect,
 		},
 		tableName: b.tableName,
 		DB:        db,
-		dialect:   b.dialect,
+		dialect:   db.dialect,
+		sweeper:   expirySweeper(db, "first_key, second_key", opts...),
 	}, nil
 }
 
 func (b *Builder) List(opts ...Option) (*List, error) {
-	if b.dialect != SQLite3 && b.dialect != MySQL {
-		return nil, errors.NotSupported()
+	fields := []string{
+		"ind $auto_increment_type$ not null primary key $auto_increment$",
+		"value $json$ not null",
 	}
+	fields = append(fields, "version bigint not null default 1")
 
-	var fields []string
-	if b.dialect == SQLite3 {
-		fields = []string{
-			"ind integer not null primary key $auto_increment$",
-			"value json not null",
-		}
-	} else {
-		fields = []string{
-			"ind bigint not null primary key $auto_increment$",
-			"value json not null",
-		}
+	db, err := b.initTable(fields, opts...)
+	if err != nil {
+		return nil, err
 	}
 
-	db, err := b.initTable(fields, opts...)
+	err = ensureColumns(db, "version bigint not null default 1")
 	if err != nil {
 		return nil, err
 	}
 
 	return &List{
 		JsonValueHolder: &JsonValueHolder{
-			DB:      db,
-			field:   "value",
-			dialect: b.dialect,
+			DB:        db,
+			field:     "value",
+			columns:   "ind, value",
+			versioned: true,
+			keys:      []string{"ind"},
+			dialect:   db.dialect,
 		},
 		tableName: b.tableName,
 		DB:        db,
-		dialect:   b.dialect,
+		dialect:   db.dialect,
 	}, nil
 }
 
 func (b *Builder) MList(opts ...Option) (*MList, error) {
-	if b.dialect != SQLite3 && b.dialect != MySQL {
-		return nil, errors.NotSupported()
-	}
-
 	fields := []string{
 		"ind bigint not null",
 		"name varchar(255) not null primary key",
-		"value json not null",
+		"value $json$ not null",
+		"version bigint not null default 1",
 	}
 
 	db, err := b.initTable(fields, opts...)
@@ -166,21 +177,73 @@ func (b *Builder) MList(opts ...Option) (*MList, error) {
 		return nil, err
 	}
 
+	err = ensureColumns(db, "version bigint not null default 1")
+	if err != nil {
+		return nil, err
+	}
+
 	return &MList{
 		JsonValueHolder: &JsonValueHolder{
-			DB:      db,
-			field:   "value",
-			dialect: b.dialect,
+			DB:        db,
+			field:     "value",
+			columns:   "ind, name, value",
+			versioned: true,
+			keys:      []string{"ind", "name"},
+			dialect:   db.dialect,
 		},
 		tableName: b.tableName,
 		DB:        db,
-		dialect:   b.dialect,
+		dialect:   db.dialect,
 	}, nil
 }
 
-func (b *Builder) initTable(fields []string, opts ...Option) (*DB, error) {
-	var postInitExec []string
+func (b *Builder) Set(opts ...Option) (*Set, error) {
+	fields := []string{
+		"name varchar(255) not null primary key",
+	}
+
+	db, err := b.initTable(fields, opts...)
+	if err != nil {
+		return nil, err
+	}
 
+	return &Set{
+		tableName: b.tableName,
+		DB:        db,
+		dialect:   db.dialect,
+	}, nil
+}
+
+func (b *Builder) Queue(opts ...Option) (*Queue, error) {
+	fields := []string{
+		"id $auto_increment_type$ not null primary key $auto_increment$",
+		"value $json$ not null",
+		"priority bigint not null",
+		"visible_at bigint not null",
+		"attempts bigint not null",
+		"lease varchar(64)",
+		"dead smallint not null",
+	}
+
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	db, err := b.initTable(fields, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Queue{
+		tableName:   b.tableName,
+		DB:          db,
+		dialect:     db.dialect,
+		maxAttempts: options.maxAttempts,
+	}, nil
+}
+
+func (b *Builder) initTable(fields []string, opts ...Option) (*DB, error) {
 	var (
 		err     error
 		db      *DB
@@ -199,41 +262,18 @@ func (b *Builder) initTable(fields []string, opts ...Option) (*DB, error) {
 		b.AddIndexes(ind)
 	}
 
-	if b.dialect == SQLite3 {
-		db, err = NewLite(b.conn)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(b.keys) > 0 {
-			for _, fk := range b.keys {
-				fields = append(fields, fk.InTableDefQuery())
-			}
-		}
-
-		if len(b.indexes) > 0 {
-			for _, ind := range b.indexes {
-				postInitExec = append(postInitExec, ind.SQLiteAddQuery())
-			}
-		}
-
-	} else {
-		db, err = New(b.conn)
-		if err != nil {
-			return nil, err
-		}
+	dialect, found := LookupDialect(b.dialect)
+	if !found {
+		return nil, errors.NotSupported()
+	}
 
-		if len(b.keys) > 0 {
-			for _, fk := range b.keys {
-				fields = append(fields, fk.InTableDefQuery())
-			}
-		}
+	db, err = NewDB(b.conn, dialect)
+	if err != nil {
+		return nil, err
+	}
 
-		if len(b.indexes) > 0 {
-			for _, ind := range b.indexes {
-				postInitExec = append(postInitExec, ind.MySQLAddQuery())
-			}
-		}
+	for _, fk := range b.keys {
+		fields = append(fields, fk.InTableDefQuery())
 	}
 
 	header := "create table if not exists $table$"
@@ -248,16 +288,41 @@ func (b *Builder) initTable(fields []string, opts ...Option) (*DB, error) {
 		return nil, err
 	}
 
-	for _, query := range postInitExec {
-		err = db.Exec(query).Error
+	for _, ind := range b.indexes {
+		err = db.EnsureIndex(*ind)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(options.jsonIndexes) > 0 {
+		err = createJsonIndexes(db, b.tableName, options.jsonIndexes)
 		if err != nil {
 			return nil, err
 		}
+		db.dialect = newJsonIndexDialect(dialect, options.jsonIndexes)
 	}
 
 	return db, nil
 }
 
+// ensureColumns adds the columns described by definitions to tables that were created without them.
+// Each definition starts with the column name.
+func ensureColumns(db *DB, definitions ...string) error {
+	for _, definition := range definitions {
+		column := strings.Fields(definition)[0]
+		if _, err := db.QueryFirst("select count("+column+") from $table$;", IntScanner); err == nil {
+			continue
+		}
+
+		err := db.Exec("alter table $table$ add column " + definition + ";").Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (b *Builder) GetTableName() string {
 	return b.tableName
 }
@@ -1,7 +1,12 @@
 package bome
 
+import "context"
+
 type Client interface {
 	Exec(query string, args ...interface{}) Result
 	Query(query string, scannerName string, args ...interface{}) (Cursor, error)
 	QueryFirst(query string, scannerName string, args ...interface{}) (interface{}, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) Result
+	QueryContext(ctx context.Context, query string, scannerName string, args ...interface{}) (Cursor, error)
+	QueryFirstContext(ctx context.Context, query string, scannerName string, args ...interface{}) (interface{}, error)
 }
@@ -0,0 +1,165 @@
+package bome
+
+// Column is a key column of a collection. Its conditions can be used with the methods of the collections
+// that have this column, which fail with an UnknownColumnError otherwise.
+type Column struct {
+	name string
+}
+
+// Col returns the column named name.
+func Col(name string) Column {
+	return Column{name: name}
+}
+
+// Key returns the key column of Map and MList entries.
+func Key() Column {
+	return Col("name")
+}
+
+// FirstKey returns the first key column of DMap entries.
+func FirstKey() Column {
+	return Col("first_key")
+}
+
+// SecondKey returns the second key column of DMap entries.
+func SecondKey() Column {
+	return Col("second_key")
+}
+
+// IndexColumn returns the index column of List and MList entries.
+func IndexColumn() Column {
+	return Col("ind")
+}
+
+func (c Column) Eq(e Expression) BoolExpr {
+	return &columnCompare{column: c.name, op: "=", e: e}
+}
+
+func (c Column) Ne(e Expression) BoolExpr {
+	return &columnCompare{column: c.name, op: "!=", e: e}
+}
+
+func (c Column) Gt(e Expression) BoolExpr {
+	return &columnCompare{column: c.name, op: ">", e: e}
+}
+
+func (c Column) Gte(e Expression) BoolExpr {
+	return &columnCompare{column: c.name, op: ">=", e: e}
+}
+
+func (c Column) Lt(e Expression) BoolExpr {
+	return &columnCompare{column: c.name, op: "<", e: e}
+}
+
+func (c Column) Lte(e Expression) BoolExpr {
+	return &columnCompare{column: c.name, op: "<=", e: e}
+}
+
+// In returns the condition that tells if the column value is one of values.
+func (c Column) In(values ...Expression) BoolExpr {
+	return &columnIn{column: c.name, values: values}
+}
+
+// Between returns the condition that tells if the column value is between low and high, inclusive.
+func (c Column) Between(low, high Expression) BoolExpr {
+	return &columnBetween{column: c.name, low: low, high: high}
+}
+
+func (c Column) StartsWith(e Expression) BoolExpr {
+	return &columnLike{column: c.name, e: e, after: "%"}
+}
+
+func (c Column) EndsWith(e Expression) BoolExpr {
+	return &columnLike{column: c.name, e: e, before: "%"}
+}
+
+func (c Column) Contains(e Expression) BoolExpr {
+	return &columnLike{column: c.name, e: e, before: "%", after: "%"}
+}
+
+// columnsExpr is implemented by the conditions that refer to columns.
+type columnsExpr interface {
+	columns() []string
+}
+
+// checkColumns checks that the columns condition refers to are among keys.
+func checkColumns(condition BoolExpr, keys []string) error {
+	ce, ok := condition.(columnsExpr)
+	if !ok {
+		return nil
+	}
+
+	for _, column := range ce.columns() {
+		known := false
+		for _, key := range keys {
+			if key == column {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return &UnknownColumnError{Column: column}
+		}
+	}
+	return nil
+}
+
+type columnCompare struct {
+	column string
+	op     string
+	e      Expression
+	dialectValue
+}
+
+func (c *columnCompare) columns() []string {
+	return []string{c.column}
+}
+
+func (c *columnCompare) sql() (string, []interface{}) {
+	return compareSQL(c.getDialect(), c.column, c.op, c.e)
+}
+
+type columnIn struct {
+	column string
+	values []Expression
+	dialectValue
+}
+
+func (c *columnIn) columns() []string {
+	return []string{c.column}
+}
+
+func (c *columnIn) sql() (string, []interface{}) {
+	return inSQL(c.getDialect(), c.column, c.values)
+}
+
+type columnBetween struct {
+	column string
+	low    Expression
+	high   Expression
+	dialectValue
+}
+
+func (c *columnBetween) columns() []string {
+	return []string{c.column}
+}
+
+func (c *columnBetween) sql() (string, []interface{}) {
+	return betweenSQL(c.getDialect(), c.column, c.low, c.high)
+}
+
+type columnLike struct {
+	column string
+	e      Expression
+	before string
+	after  string
+	dialectValue
+}
+
+func (c *columnLike) columns() []string {
+	return []string{c.column}
+}
+
+func (c *columnLike) sql() (string, []interface{}) {
+	return likeSQL(c.getDialect(), c.column, c.e, c.before, c.after)
+}
@@ -0,0 +1,188 @@
+package bome
+
+import "database/sql"
+
+// BaseDialect implements the Dialect methods that render SQL, so that dialects registered with RegisterDialect
+// only have to implement Name, DriverName and DataSourceName, and override the methods their engine renders
+// differently. The methods added to Dialect are given a default in BaseDialect, which keeps the dialects that
+// embed it compiling.
+//
+// BaseDialect renders SQL like SQLiteDialect, whose JSON functions are shared by engines such as MySQL,
+// and does not configure connections.
+type BaseDialect struct{}
+
+func (BaseDialect) Configure(*sql.DB) error {
+	return nil
+}
+
+func (BaseDialect) Variables() map[string]string {
+	return SQLiteDialect{}.Variables()
+}
+
+func (BaseDialect) Rebind(query string) string {
+	return SQLiteDialect{}.Rebind(query)
+}
+
+func (BaseDialect) Placeholder(t ValueType) string {
+	return SQLiteDialect{}.Placeholder(t)
+}
+
+func (BaseDialect) MaxPlaceholders() int {
+	return SQLiteDialect{}.MaxPlaceholders()
+}
+
+func (BaseDialect) QuoteString(value string) string {
+	return SQLiteDialect{}.QuoteString(value)
+}
+
+func (BaseDialect) QuoteIdentifier(name string) string {
+	return SQLiteDialect{}.QuoteIdentifier(name)
+}
+
+func (BaseDialect) Bool(value bool) string {
+	return SQLiteDialect{}.Bool(value)
+}
+
+func (BaseDialect) CastNumeric(expr string) string {
+	return SQLiteDialect{}.CastNumeric(expr)
+}
+
+func (BaseDialect) SortKey(expr string, numeric bool) string {
+	return SQLiteDialect{}.SortKey(expr, numeric)
+}
+
+func (BaseDialect) Length(field string) string {
+	return SQLiteDialect{}.Length(field)
+}
+
+func (BaseDialect) Concat(values ...string) string {
+	return SQLiteDialect{}.Concat(values...)
+}
+
+func (BaseDialect) JSONScalar(field string) string {
+	return SQLiteDialect{}.JSONScalar(field)
+}
+
+func (BaseDialect) JSONExtract(field string, path string) string {
+	return SQLiteDialect{}.JSONExtract(field, path)
+}
+
+func (BaseDialect) JSONQuery(field string, path string) string {
+	return SQLiteDialect{}.JSONQuery(field, path)
+}
+
+func (BaseDialect) JSONSet(field string, path string, value string) string {
+	return SQLiteDialect{}.JSONSet(field, path, value)
+}
+
+func (BaseDialect) JSONContainsPath(field string, path string) string {
+	return SQLiteDialect{}.JSONContainsPath(field, path)
+}
+
+func (BaseDialect) JSONIsNull(field string, path string) string {
+	return SQLiteDialect{}.JSONIsNull(field, path)
+}
+
+func (BaseDialect) JSONType(field string, path string) string {
+	return SQLiteDialect{}.JSONType(field, path)
+}
+
+func (BaseDialect) JSONRemove(field string, path string) string {
+	return SQLiteDialect{}.JSONRemove(field, path)
+}
+
+func (BaseDialect) JSONArrayAppend(field string, path string, value string) string {
+	return SQLiteDialect{}.JSONArrayAppend(field, path, value)
+}
+
+func (BaseDialect) JSONArrayInsert(field string, path string, index int, value string) string {
+	return SQLiteDialect{}.JSONArrayInsert(field, path, index, value)
+}
+
+func (BaseDialect) JSONNot(field string, path string) string {
+	return SQLiteDialect{}.JSONNot(field, path)
+}
+
+func (BaseDialect) JSONArrayContains(field string, path string, value string) string {
+	return SQLiteDialect{}.JSONArrayContains(field, path, value)
+}
+
+func (BaseDialect) JSONArrayLength(field string, path string) string {
+	return SQLiteDialect{}.JSONArrayLength(field, path)
+}
+
+func (BaseDialect) JSONLiteral(text string) string {
+	return SQLiteDialect{}.JSONLiteral(text)
+}
+
+func (BaseDialect) JSONArray(values ...string) string {
+	return SQLiteDialect{}.JSONArray(values...)
+}
+
+func (BaseDialect) JSONObject(values ...string) string {
+	return SQLiteDialect{}.JSONObject(values...)
+}
+
+func (BaseDialect) JSONValue(value string) string {
+	return SQLiteDialect{}.JSONValue(value)
+}
+
+func (BaseDialect) JSONColumn(name string, field string, path string, t ValueType) string {
+	return SQLiteDialect{}.JSONColumn(name, field, path, t)
+}
+
+func (BaseDialect) Upsert(table string, columns []string, keys []string, updates []string) string {
+	return SQLiteDialect{}.Upsert(table, columns, keys, updates)
+}
+
+func (BaseDialect) CreateIndexQuery(index Index) string {
+	return SQLiteDialect{}.CreateIndexQuery(index)
+}
+
+func (BaseDialect) DropIndexQuery(index Index) string {
+	return SQLiteDialect{}.DropIndexQuery(index)
+}
+
+func (BaseDialect) IndexesQuery(table string) string {
+	return SQLiteDialect{}.IndexesQuery(table)
+}
+
+func (BaseDialect) IndexColumnsQuery(table string) string {
+	return SQLiteDialect{}.IndexColumnsQuery(table)
+}
+
+func (BaseDialect) TablesQuery() string {
+	return SQLiteDialect{}.TablesQuery()
+}
+
+func (BaseDialect) ColumnsQuery(table string) string {
+	return SQLiteDialect{}.ColumnsQuery(table)
+}
+
+func (BaseDialect) ForeignKeysQuery(table string) string {
+	return SQLiteDialect{}.ForeignKeysQuery(table)
+}
+
+func (BaseDialect) IsDuplicateKeyError(err error) bool {
+	return SQLiteDialect{}.IsDuplicateKeyError(err)
+}
+
+func (BaseDialect) SupportsReturning() bool {
+	return SQLiteDialect{}.SupportsReturning()
+}
+
+func (BaseDialect) SkipLocked() string {
+	return SQLiteDialect{}.SkipLocked()
+}
+
+func (BaseDialect) SupportsPartialIndexes() bool {
+	return SQLiteDialect{}.SupportsPartialIndexes()
+}
+
+func (BaseDialect) SupportsIndexPrefixLengths() bool {
+	return SQLiteDialect{}.SupportsIndexPrefixLengths()
+}
+
+func (BaseDialect) SupportsAlterTableForeignKeys() bool {
+	return SQLiteDialect{}.SupportsAlterTableForeignKeys()
+}
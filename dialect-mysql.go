@@ -0,0 +1,245 @@
+package bome
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// MySQLDialect is the MySQL dialect. It can be embedded by dialects of MySQL compatible engines.
+type MySQLDialect struct{}
+
+func (MySQLDialect) Name() string {
+	return MySQL
+}
+
+func (MySQLDialect) DriverName() string {
+	return MySQL
+}
+
+func (MySQLDialect) DataSourceName(dsn string) string {
+	return strings.TrimPrefix(dsn, "mysql://")
+}
+
+func (MySQLDialect) Configure(_ *sql.DB) error {
+	return nil
+}
+
+func (MySQLDialect) Variables() map[string]string {
+	return map[string]string{
+		VarLocate:            "locate",
+		VarAutoIncrement:     "AUTO_INCREMENT",
+		VarAutoIncrementType: "bigint",
+		VarEngine:            "engine=InnoDB",
+		VarJSON:              "json",
+	}
+}
+
+func (MySQLDialect) Rebind(query string) string {
+	return query
+}
+
+func (MySQLDialect) Placeholder(t ValueType) string {
+	if t == JsonValue {
+		return "cast(? as json)"
+	}
+	return "?"
+}
+
+func (MySQLDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+func (MySQLDialect) QuoteString(value string) string {
+	return fmt.Sprintf("'%s'", escaped(value))
+}
+
+func (MySQLDialect) QuoteIdentifier(name string) string {
+	return fmt.Sprintf("`%s`", name)
+}
+
+func (MySQLDialect) Bool(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+func (MySQLDialect) CastNumeric(expr string) string {
+	return expr
+}
+
+func (MySQLDialect) SortKey(expr string, numeric bool) string {
+	if numeric {
+		return fmt.Sprintf("(%s+0)", expr)
+	}
+	return expr
+}
+
+func (MySQLDialect) Length(field string) string {
+	return fmt.Sprintf("length(%s)", field)
+}
+
+func (MySQLDialect) Concat(values ...string) string {
+	return fmt.Sprintf("concat(%s)", strings.Join(values, ","))
+}
+
+func (MySQLDialect) JSONScalar(field string) string {
+	return field
+}
+
+func (d MySQLDialect) JSONExtract(field string, path string) string {
+	return fmt.Sprintf("json_unquote(json_extract(%s, %s))", field, d.QuoteString(path))
+}
+
+func (d MySQLDialect) JSONQuery(field string, path string) string {
+	return fmt.Sprintf("json_extract(%s, %s)", field, d.QuoteString(path))
+}
+
+func (d MySQLDialect) JSONSet(field string, path string, value string) string {
+	return fmt.Sprintf("json_set(%s, %s, %s)", field, d.QuoteString(normalizedJsonPath(path)), value)
+}
+
+func (d MySQLDialect) JSONContainsPath(field string, path string) string {
+	return fmt.Sprintf("(json_contains_path(%s, 'one', %s))", field, d.QuoteString(path))
+}
+
+func (d MySQLDialect) JSONIsNull(field string, path string) string {
+	extract := fmt.Sprintf("json_extract(%s, %s)", field, d.QuoteString(path))
+	return fmt.Sprintf("(%s is null or json_type(%s) = 'NULL')", extract, extract)
+}
+
+func (d MySQLDialect) JSONType(field string, path string) string {
+	return fmt.Sprintf("lower(json_type(json_extract(%s, %s)))", field, d.QuoteString(path))
+}
+
+func (d MySQLDialect) JSONRemove(field string, path string) string {
+	return fmt.Sprintf("json_remove(%s, %s)", field, d.QuoteString(normalizedJsonPath(path)))
+}
+
+func (d MySQLDialect) JSONArrayAppend(field string, path string, value string) string {
+	return fmt.Sprintf("json_array_append(%s, %s, %s)", field, d.QuoteString(normalizedJsonPath(path)), value)
+}
+
+func (d MySQLDialect) JSONArrayInsert(field string, path string, index int, value string) string {
+	return fmt.Sprintf("json_array_insert(%s, %s, %s)", field, d.QuoteString(fmt.Sprintf("%s[%d]", normalizedJsonPath(path), index)), value)
+}
+
+func (d MySQLDialect) JSONNot(field string, path string) string {
+	return fmt.Sprintf("cast(case when json_extract(%s, %s) = cast('true' as json) then 'false' else 'true' end as json)", field, d.QuoteString(path))
+}
+
+func (d MySQLDialect) JSONArrayContains(field string, path string, value string) string {
+	return fmt.Sprintf("(json_contains(%s, json_array(%s), %s))", field, value, d.QuoteString(path))
+}
+
+func (d MySQLDialect) JSONArrayLength(field string, path string) string {
+	return fmt.Sprintf("json_length(%s, %s)", field, d.QuoteString(path))
+}
+
+func (d MySQLDialect) JSONLiteral(text string) string {
+	return fmt.Sprintf("cast(%s as json)", d.QuoteString(text))
+}
+
+func (MySQLDialect) JSONArray(values ...string) string {
+	return fmt.Sprintf("json_array(%s)", strings.Join(values, ","))
+}
+
+func (MySQLDialect) JSONObject(values ...string) string {
+	return fmt.Sprintf("json_object(%s)", strings.Join(values, ","))
+}
+
+func (MySQLDialect) JSONValue(value string) string {
+	return value
+}
+
+// JSONColumn reads numbers as JSON values since json_unquote converts JSON nulls to the 'null' string.
+func (d MySQLDialect) JSONColumn(name string, field string, path string, t ValueType) string {
+	switch t {
+	case IntValue:
+		return fmt.Sprintf("%s bigint generated always as (%s) virtual", name, d.JSONQuery(field, path))
+	case FloatValue:
+		return fmt.Sprintf("%s double generated always as (%s) virtual", name, d.JSONQuery(field, path))
+	default:
+		return fmt.Sprintf("%s varchar(255) generated always as (%s) virtual", name, d.JSONExtract(field, path))
+	}
+}
+
+func (MySQLDialect) Upsert(table string, columns []string, keys []string, updates []string) string {
+	var assignments []string
+	for _, column := range updates {
+		if strings.Contains(column, "=") {
+			assignments = append(assignments, column)
+		} else {
+			assignments = append(assignments, fmt.Sprintf("%s=values(%s)", column, column))
+		}
+	}
+	if len(assignments) == 0 {
+		// Assigning a key to itself leaves existing rows unchanged and reports no affected row.
+		assignments = append(assignments, fmt.Sprintf("%s=%s", keys[0], keys[0]))
+	}
+	return fmt.Sprintf("insert into %s (%s) values (%s) on duplicate key update %s",
+		table,
+		strings.Join(columns, ","),
+		placeholders(len(columns)),
+		strings.Join(assignments, ","),
+	)
+}
+
+func (MySQLDialect) CreateIndexQuery(index Index) string {
+	return index.MySQLAddQuery()
+}
+
+func (MySQLDialect) DropIndexQuery(index Index) string {
+	return index.MySQLDropQuery()
+}
+
+func (d MySQLDialect) IndexesQuery(table string) string {
+	return fmt.Sprintf("select index_name from information_schema.statistics where table_schema=database() and table_name=%s", d.QuoteString(table))
+}
+
+func (d MySQLDialect) IndexColumnsQuery(table string) string {
+	return fmt.Sprintf("select index_name, column_name, non_unique = 0, coalesce(collation = 'D', 0), coalesce(sub_part, 0), '' "+
+		"from information_schema.statistics where table_schema=database() and table_name=%s order by index_name, seq_in_index", d.QuoteString(table))
+}
+
+func (MySQLDialect) TablesQuery() string {
+	return "select table_name from information_schema.tables where table_schema=database() and table_type = 'BASE TABLE' order by table_name"
+}
+
+func (d MySQLDialect) ColumnsQuery(table string) string {
+	return fmt.Sprintf("select column_name, column_type, is_nullable = 'YES', coalesce(column_default, ''), column_key = 'PRI' "+
+		"from information_schema.columns where table_schema=database() and table_name=%s order by ordinal_position", d.QuoteString(table))
+}
+
+func (d MySQLDialect) ForeignKeysQuery(table string) string {
+	return fmt.Sprintf("select k.constraint_name, k.column_name, k.referenced_table_name, k.referenced_column_name, r.delete_rule = 'CASCADE' "+
+		"from information_schema.key_column_usage k join information_schema.referential_constraints r "+
+		"on r.constraint_schema = k.constraint_schema and r.constraint_name = k.constraint_name "+
+		"where k.table_schema=database() and k.table_name=%s and k.referenced_table_name is not null "+
+		"order by k.constraint_name, k.ordinal_position", d.QuoteString(table))
+}
+
+func (MySQLDialect) IsDuplicateKeyError(err error) bool {
+	return isPrimaryKeyConstraintError(err)
+}
+
+func (MySQLDialect) SupportsReturning() bool {
+	return false
+}
+
+func (MySQLDialect) SkipLocked() string {
+	return " for update skip locked"
+}
+
+func (MySQLDialect) SupportsPartialIndexes() bool {
+	return false
+}
+
+func (MySQLDialect) SupportsIndexPrefixLengths() bool {
+	return true
+}
+
+func (MySQLDialect) SupportsAlterTableForeignKeys() bool {
+	return true
+}
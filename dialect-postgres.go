@@ -0,0 +1,281 @@
+package bome
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PostgresDialect is the PostgreSQL dialect. JSON values are stored in jsonb columns.
+// The "postgres" database/sql driver (e.g. github.com/lib/pq) must be imported by the application.
+type PostgresDialect struct{}
+
+func (PostgresDialect) Name() string {
+	return Postgres
+}
+
+func (PostgresDialect) DriverName() string {
+	return Postgres
+}
+
+func (PostgresDialect) DataSourceName(dsn string) string {
+	return dsn
+}
+
+func (PostgresDialect) Configure(_ *sql.DB) error {
+	return nil
+}
+
+func (PostgresDialect) Variables() map[string]string {
+	return map[string]string{
+		VarLocate:            "strpos",
+		VarAutoIncrement:     "generated by default as identity",
+		VarAutoIncrementType: "bigint",
+		VarEngine:            "",
+		VarJSON:              "jsonb",
+	}
+}
+
+func (PostgresDialect) Rebind(query string) string {
+	return rebind(query)
+}
+
+func (PostgresDialect) Placeholder(t ValueType) string {
+	switch t {
+	case IntValue:
+		return "cast(? as bigint)"
+	case FloatValue:
+		return "cast(? as double precision)"
+	case JsonValue:
+		return "cast(? as jsonb)"
+	default:
+		return "cast(? as text)"
+	}
+}
+
+func (PostgresDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+func (PostgresDialect) QuoteString(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
+
+func (PostgresDialect) QuoteIdentifier(name string) string {
+	return fmt.Sprintf("\"%s\"", name)
+}
+
+func (PostgresDialect) Bool(value bool) string {
+	if value {
+		return "true"
+	}
+	return "false"
+}
+
+func (PostgresDialect) CastNumeric(expr string) string {
+	return fmt.Sprintf("cast(%s as numeric)", expr)
+}
+
+func (d PostgresDialect) SortKey(expr string, numeric bool) string {
+	if numeric {
+		return d.CastNumeric(expr)
+	}
+	return expr
+}
+
+func (PostgresDialect) Length(field string) string {
+	return fmt.Sprintf("length(%s::text)", field)
+}
+
+func (PostgresDialect) Concat(values ...string) string {
+	return fmt.Sprintf("concat(%s)", strings.Join(values, ","))
+}
+
+func (d PostgresDialect) JSONScalar(field string) string {
+	return d.accessor(field, "$", true)
+}
+
+func (d PostgresDialect) JSONExtract(field string, path string) string {
+	return d.accessor(field, path, true)
+}
+
+func (d PostgresDialect) JSONQuery(field string, path string) string {
+	return d.accessor(field, path, false)
+}
+
+func (d PostgresDialect) JSONSet(field string, path string, value string) string {
+	return fmt.Sprintf("jsonb_set(%s, %s, %s, true)", field, d.textArrayPath(path), value)
+}
+
+func (d PostgresDialect) JSONContainsPath(field string, path string) string {
+	return fmt.Sprintf("(jsonb_path_exists(%s, %s))", field, d.QuoteString(normalizedJsonPath(path)))
+}
+
+func (d PostgresDialect) JSONIsNull(field string, path string) string {
+	accessor := d.accessor(field, path, false)
+	return fmt.Sprintf("(%s is null or jsonb_typeof(%s) = 'null')", accessor, accessor)
+}
+
+func (d PostgresDialect) JSONType(field string, path string) string {
+	return fmt.Sprintf("jsonb_typeof(%s)", d.accessor(field, path, false))
+}
+
+func (d PostgresDialect) JSONRemove(field string, path string) string {
+	return fmt.Sprintf("(%s #- %s)", field, d.textArrayPath(path))
+}
+
+// JSONArrayAppend inserts value after the last element, which jsonb_insert also does for empty arrays.
+func (d PostgresDialect) JSONArrayAppend(field string, path string, value string) string {
+	return fmt.Sprintf("jsonb_insert(%s, %s, %s, true)", field, d.textArrayPath(path+"[-1]"), value)
+}
+
+func (d PostgresDialect) JSONArrayInsert(field string, path string, index int, value string) string {
+	return fmt.Sprintf("jsonb_insert(%s, %s, %s)", field, d.textArrayPath(fmt.Sprintf("%s[%d]", path, index)), value)
+}
+
+func (d PostgresDialect) JSONNot(field string, path string) string {
+	return fmt.Sprintf("(case when %s = cast('true' as jsonb) then cast('false' as jsonb) else cast('true' as jsonb) end)", d.accessor(field, path, false))
+}
+
+func (d PostgresDialect) JSONArrayContains(field string, path string, value string) string {
+	return fmt.Sprintf("(%s @> jsonb_build_array(%s))", d.accessor(field, path, false), value)
+}
+
+func (d PostgresDialect) JSONArrayLength(field string, path string) string {
+	return fmt.Sprintf("jsonb_array_length(%s)", d.accessor(field, path, false))
+}
+
+func (d PostgresDialect) JSONLiteral(text string) string {
+	return fmt.Sprintf("cast(%s as jsonb)", d.QuoteString(text))
+}
+
+func (PostgresDialect) JSONArray(values ...string) string {
+	return fmt.Sprintf("jsonb_build_array(%s)", strings.Join(values, ","))
+}
+
+func (PostgresDialect) JSONObject(values ...string) string {
+	return fmt.Sprintf("jsonb_build_object(%s)", strings.Join(values, ","))
+}
+
+func (PostgresDialect) JSONValue(value string) string {
+	return fmt.Sprintf("to_jsonb(%s)", value)
+}
+
+// JSONColumn returns a stored column since PostgreSQL does not support virtual generated columns.
+func (d PostgresDialect) JSONColumn(name string, field string, path string, t ValueType) string {
+	switch t {
+	case IntValue:
+		return fmt.Sprintf("%s bigint generated always as (cast(%s as bigint)) stored", name, d.JSONExtract(field, path))
+	case FloatValue:
+		return fmt.Sprintf("%s double precision generated always as (cast(%s as double precision)) stored", name, d.JSONExtract(field, path))
+	default:
+		return fmt.Sprintf("%s text generated always as (%s) stored", name, d.JSONExtract(field, path))
+	}
+}
+
+func (PostgresDialect) Upsert(table string, columns []string, keys []string, updates []string) string {
+	return onConflictUpsert(table, columns, keys, updates)
+}
+
+func (PostgresDialect) CreateIndexQuery(index Index) string {
+	return index.PostgresAddQuery()
+}
+
+func (PostgresDialect) DropIndexQuery(index Index) string {
+	return index.PostgresDropQuery()
+}
+
+func (d PostgresDialect) IndexesQuery(table string) string {
+	return fmt.Sprintf("select indexname from pg_indexes where tablename=%s", d.QuoteString(table))
+}
+
+func (d PostgresDialect) IndexColumnsQuery(table string) string {
+	return fmt.Sprintf("select ic.relname, a.attname, i.indisunique::int, (i.indoption[k.n - 1] & 1)::int, 0, "+
+		"coalesce(pg_get_expr(i.indpred, i.indrelid), '') from pg_index i "+
+		"join pg_class ic on ic.oid = i.indexrelid join pg_class t on t.oid = i.indrelid "+
+		"cross join lateral unnest(i.indkey) with ordinality as k(attnum, n) "+
+		"join pg_attribute a on a.attrelid = i.indrelid and a.attnum = k.attnum "+
+		"where t.relname=%s and t.relnamespace = current_schema()::regnamespace order by ic.relname, k.n", d.QuoteString(table))
+}
+
+func (PostgresDialect) TablesQuery() string {
+	return "select tablename from pg_tables where schemaname = current_schema() order by tablename"
+}
+
+func (d PostgresDialect) ColumnsQuery(table string) string {
+	return fmt.Sprintf("select c.column_name, c.data_type, c.is_nullable = 'YES', coalesce(c.column_default, ''), "+
+		"exists(select 1 from information_schema.table_constraints tc join information_schema.key_column_usage k "+
+		"on k.constraint_schema = tc.constraint_schema and k.constraint_name = tc.constraint_name "+
+		"where tc.constraint_type = 'PRIMARY KEY' and tc.table_schema = c.table_schema and tc.table_name = c.table_name "+
+		"and k.column_name = c.column_name) "+
+		"from information_schema.columns c where c.table_schema = current_schema() and c.table_name=%s order by c.ordinal_position", d.QuoteString(table))
+}
+
+func (d PostgresDialect) ForeignKeysQuery(table string) string {
+	return fmt.Sprintf("select c.conname, a.attname, rt.relname, ra.attname, c.confdeltype = 'c' from pg_constraint c "+
+		"join pg_class t on t.oid = c.conrelid join pg_class rt on rt.oid = c.confrelid "+
+		"cross join lateral unnest(c.conkey, c.confkey) with ordinality as k(attnum, refnum, n) "+
+		"join pg_attribute a on a.attrelid = c.conrelid and a.attnum = k.attnum "+
+		"join pg_attribute ra on ra.attrelid = c.confrelid and ra.attnum = k.refnum "+
+		"where c.contype = 'f' and t.relname=%s and t.relnamespace = current_schema()::regnamespace order by c.conname, k.n", d.QuoteString(table))
+}
+
+func (PostgresDialect) IsDuplicateKeyError(err error) bool {
+	return isPrimaryKeyConstraintError(err)
+}
+
+// textArrayPath returns the text array notation of a JSON path, e.g. '{a,b,0}'.
+func (d PostgresDialect) textArrayPath(path string) string {
+	return d.QuoteString("{" + strings.Join(jsonPathSegments(path), ",") + "}")
+}
+
+// accessor chains -> operators down to path. The last operator is ->> when text is true.
+func (d PostgresDialect) accessor(field string, path string, text bool) string {
+	segments := jsonPathSegments(path)
+	if len(segments) == 0 {
+		if text {
+			return "(" + field + "#>>'{}')"
+		}
+		return field
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString("(")
+	builder.WriteString(field)
+	for i, segment := range segments {
+		if text && i == len(segments)-1 {
+			builder.WriteString("->>")
+		} else {
+			builder.WriteString("->")
+		}
+
+		if _, err := strconv.Atoi(segment); err == nil {
+			builder.WriteString(segment)
+		} else {
+			builder.WriteString(d.QuoteString(segment))
+		}
+	}
+	builder.WriteString(")")
+	return builder.String()
+}
+
+func (PostgresDialect) SupportsReturning() bool {
+	return true
+}
+
+func (PostgresDialect) SkipLocked() string {
+	return " for update skip locked"
+}
+
+func (PostgresDialect) SupportsPartialIndexes() bool {
+	return false
+}
+
+func (PostgresDialect) SupportsIndexPrefixLengths() bool {
+	return false
+}
+
+func (PostgresDialect) SupportsAlterTableForeignKeys() bool {
+	return false
+}
@@ -0,0 +1,267 @@
+package bome
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// SQLiteDialect is the SQLite dialect. JSON functions require the json1 extension.
+type SQLiteDialect struct{}
+
+func (SQLiteDialect) Name() string {
+	return SQLite3
+}
+
+func (SQLiteDialect) DriverName() string {
+	return SQLite3
+}
+
+func (SQLiteDialect) DataSourceName(dsn string) string {
+	return strings.TrimPrefix(dsn, "sqlite3://")
+}
+
+func (SQLiteDialect) Configure(conn *sql.DB) error {
+	_, err := conn.Exec("PRAGMA foreign_keys=ON")
+	return err
+}
+
+func (SQLiteDialect) Variables() map[string]string {
+	return map[string]string{
+		VarLocate:            "instr",
+		VarAutoIncrement:     "AUTOINCREMENT",
+		VarAutoIncrementType: "integer",
+		VarEngine:            "",
+		VarJSON:              "json",
+	}
+}
+
+func (SQLiteDialect) Rebind(query string) string {
+	return query
+}
+
+func (SQLiteDialect) Placeholder(t ValueType) string {
+	if t == JsonValue {
+		return "json(?)"
+	}
+	return "?"
+}
+
+// MaxPlaceholders returns the default limit of SQLite versions prior to 3.32.0.
+func (SQLiteDialect) MaxPlaceholders() int {
+	return 999
+}
+
+func (SQLiteDialect) QuoteString(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
+
+func (SQLiteDialect) QuoteIdentifier(name string) string {
+	return fmt.Sprintf("`%s`", name)
+}
+
+func (SQLiteDialect) Bool(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+func (SQLiteDialect) CastNumeric(expr string) string {
+	return expr
+}
+
+func (SQLiteDialect) SortKey(expr string, numeric bool) string {
+	if numeric {
+		return fmt.Sprintf("cast(%s as real)", expr)
+	}
+	return fmt.Sprintf("cast(%s as text)", expr)
+}
+
+func (SQLiteDialect) Length(field string) string {
+	return fmt.Sprintf("length(%s)", field)
+}
+
+func (SQLiteDialect) Concat(values ...string) string {
+	return "(" + strings.Join(values, " || ") + ")"
+}
+
+func (SQLiteDialect) JSONScalar(field string) string {
+	return field
+}
+
+func (d SQLiteDialect) JSONExtract(field string, path string) string {
+	return fmt.Sprintf("json_extract(%s, %s)", field, d.QuoteString(path))
+}
+
+// JSONQuery uses the -> operator, available since SQLite 3.38.0, since json_extract converts JSON scalars to SQL values.
+func (d SQLiteDialect) JSONQuery(field string, path string) string {
+	return fmt.Sprintf("(%s -> %s)", field, d.QuoteString(path))
+}
+
+func (d SQLiteDialect) JSONSet(field string, path string, value string) string {
+	return fmt.Sprintf("json_set(%s, %s, %s)", field, d.QuoteString(normalizedJsonPath(path)), value)
+}
+
+func (d SQLiteDialect) JSONContainsPath(field string, path string) string {
+	return fmt.Sprintf("(json_quote(json_extract(%s, %s))!='null')", field, d.QuoteString(path))
+}
+
+func (d SQLiteDialect) JSONIsNull(field string, path string) string {
+	return fmt.Sprintf("(json_extract(%s, %s) is null)", field, d.QuoteString(path))
+}
+
+func (d SQLiteDialect) JSONType(field string, path string) string {
+	return fmt.Sprintf("json_type(%s, %s)", field, d.QuoteString(path))
+}
+
+func (d SQLiteDialect) JSONRemove(field string, path string) string {
+	return fmt.Sprintf("json_remove(%s, %s)", field, d.QuoteString(normalizedJsonPath(path)))
+}
+
+func (d SQLiteDialect) JSONArrayAppend(field string, path string, value string) string {
+	return fmt.Sprintf("json_insert(%s, %s, %s)", field, d.QuoteString(normalizedJsonPath(path)+"[#]"), value)
+}
+
+// JSONArrayInsert rebuilds the array from its elements since json_insert does not shift elements.
+// The elements are read with the -> operator which keeps them as JSON, and field is qualified
+// with the table name since it is shadowed by the json_each value column.
+func (d SQLiteDialect) JSONArrayInsert(field string, path string, index int, value string) string {
+	path = d.QuoteString(normalizedJsonPath(path))
+	elements := fmt.Sprintf(
+		"select item from (select (%s.%s -> e.fullkey) as item, e.key + (e.key >= %d) as position from json_each(%s.%s, %s) as e union all select json_quote(%s), %d) order by position",
+		VarTable, field, index, VarTable, field, path, value, index,
+	)
+	return fmt.Sprintf("(case when json_type(%s, %s) = 'array' then json_set(%s, %s, json('[' || (select group_concat(item, ',') from (%s)) || ']')) else %s end)",
+		field, path, field, path, elements, field)
+}
+
+func (d SQLiteDialect) JSONNot(field string, path string) string {
+	return fmt.Sprintf("json(case when json_type(%s, %s) = 'true' then 'false' else 'true' end)", field, d.QuoteString(path))
+}
+
+// JSONArrayContains qualifies field with the table name since it is shadowed by the json_each value column.
+func (d SQLiteDialect) JSONArrayContains(field string, path string, value string) string {
+	return fmt.Sprintf("(exists (select 1 from json_each(%s.%s, %s) as elements where elements.value = %s))", VarTable, field, d.QuoteString(path), value)
+}
+
+func (d SQLiteDialect) JSONArrayLength(field string, path string) string {
+	return fmt.Sprintf("json_array_length(%s, %s)", field, d.QuoteString(path))
+}
+
+func (d SQLiteDialect) JSONLiteral(text string) string {
+	return fmt.Sprintf("json(%s)", d.QuoteString(text))
+}
+
+func (SQLiteDialect) JSONArray(values ...string) string {
+	return fmt.Sprintf("json_array(%s)", strings.Join(values, ","))
+}
+
+func (SQLiteDialect) JSONObject(values ...string) string {
+	return fmt.Sprintf("json_object(%s)", strings.Join(values, ","))
+}
+
+func (SQLiteDialect) JSONValue(value string) string {
+	return value
+}
+
+func (d SQLiteDialect) JSONColumn(name string, field string, path string, t ValueType) string {
+	columnType := "text"
+	switch t {
+	case IntValue:
+		columnType = "integer"
+	case FloatValue:
+		columnType = "real"
+	}
+	return fmt.Sprintf("%s %s generated always as (%s) virtual", name, columnType, d.JSONExtract(field, path))
+}
+
+func (SQLiteDialect) Upsert(table string, columns []string, keys []string, updates []string) string {
+	return onConflictUpsert(table, columns, keys, updates)
+}
+
+func (SQLiteDialect) CreateIndexQuery(index Index) string {
+	return index.SQLiteAddQuery()
+}
+
+func (SQLiteDialect) DropIndexQuery(index Index) string {
+	return index.SQLiteDropQuery()
+}
+
+func (d SQLiteDialect) IndexesQuery(table string) string {
+	return fmt.Sprintf("select name from pragma_index_list(%s)", d.QuoteString(table))
+}
+
+func (d SQLiteDialect) IndexColumnsQuery(table string) string {
+	return fmt.Sprintf("select il.name, ix.name, il.\"unique\", ix.desc, 0, "+
+		"case when instr(lower(m.sql), ' where ') > 0 then substr(m.sql, instr(lower(m.sql), ' where ') + 7) else '' end "+
+		"from pragma_index_list(%s) as il join pragma_index_xinfo(il.name) as ix on ix.key = 1 "+
+		"left join sqlite_master as m on m.type = 'index' and m.name = il.name order by il.name, ix.seqno", d.QuoteString(table))
+}
+
+func (SQLiteDialect) TablesQuery() string {
+	return "select name from sqlite_master where type = 'table' and name not like 'sqlite_%' order by name"
+}
+
+func (d SQLiteDialect) ColumnsQuery(table string) string {
+	return fmt.Sprintf("select name, type, \"notnull\" = 0, coalesce(dflt_value, ''), pk > 0 from pragma_table_info(%s) order by cid", d.QuoteString(table))
+}
+
+// ForeignKeysQuery names foreign keys after their position, as SQLite does not keep their names.
+func (d SQLiteDialect) ForeignKeysQuery(table string) string {
+	return fmt.Sprintf("select 'fk_' || fk.id, fk.\"from\", fk.\"table\", "+
+		"coalesce(fk.\"to\", (select name from pragma_table_info(fk.\"table\") where pk = fk.seq + 1)), upper(fk.on_delete) = 'CASCADE' "+
+		"from pragma_foreign_key_list(%s) as fk order by fk.id, fk.seq", d.QuoteString(table))
+}
+
+func (SQLiteDialect) IsDuplicateKeyError(err error) bool {
+	return isPrimaryKeyConstraintError(err)
+}
+
+// onConflictUpsert renders the "insert ... on conflict do update" statement shared by SQLite and PostgreSQL.
+func onConflictUpsert(table string, columns []string, keys []string, updates []string) string {
+	if len(updates) == 0 {
+		return fmt.Sprintf("insert into %s (%s) values (%s) on conflict (%s) do nothing",
+			table,
+			strings.Join(columns, ","),
+			placeholders(len(columns)),
+			strings.Join(keys, ","),
+		)
+	}
+
+	var assignments []string
+	for _, column := range updates {
+		if strings.Contains(column, "=") {
+			assignments = append(assignments, column)
+		} else {
+			assignments = append(assignments, fmt.Sprintf("%s=excluded.%s", column, column))
+		}
+	}
+	return fmt.Sprintf("insert into %s (%s) values (%s) on conflict (%s) do update set %s",
+		table,
+		strings.Join(columns, ","),
+		placeholders(len(columns)),
+		strings.Join(keys, ","),
+		strings.Join(assignments, ","),
+	)
+}
+
+func (SQLiteDialect) SupportsReturning() bool {
+	return true
+}
+
+func (SQLiteDialect) SkipLocked() string {
+	return ""
+}
+
+func (SQLiteDialect) SupportsPartialIndexes() bool {
+	return true
+}
+
+func (SQLiteDialect) SupportsIndexPrefixLengths() bool {
+	return false
+}
+
+func (SQLiteDialect) SupportsAlterTableForeignKeys() bool {
+	return false
+}
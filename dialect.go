@@ -1,9 +1,284 @@
 package bome
 
+import (
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
 const (
 	// MySQL is the value for MySQL dialect.
 	MySQL = "mysql"
 
 	// SQLite3 is the value for SQLite dialect.
 	SQLite3 = "sqlite3"
+
+	// Postgres is the value for PostgreSQL dialect.
+	Postgres = "postgres"
 )
+
+// Dialect renders the SQL that differs from a database engine to another.
+// DB, Builder and expressions consult the dialect instead of hard-coding engines behaviours.
+//
+// Values are always bound to placeholders. JSON paths are rendered as string literals
+// quoted with QuoteString, so that they match the expressions of JSON indexes.
+//
+// Methods are added to Dialect as features need new SQL. Dialects registered with RegisterDialect should embed
+// BaseDialect, or one of the dialects of this package, so that they keep implementing it.
+type Dialect interface {
+	// Name returns the dialect name. It is the value passed to Builder.SetDialect and the scheme handled by Open.
+	Name() string
+
+	// DriverName returns the name of the database/sql driver used to open connections.
+	DriverName() string
+
+	// DataSourceName converts a bome DSN (scheme://...) into the driver data source name.
+	DataSourceName(dsn string) string
+
+	// Configure is called once when a connection is wrapped in a DB.
+	Configure(conn *sql.DB) error
+
+	// Variables returns the values of the predefined variables ($locate$, $auto_increment$, $auto_increment_type$,
+	// $engine$, $json$).
+	Variables() map[string]string
+
+	// Rebind converts '?' placeholders into the dialect placeholder style.
+	Rebind(query string) string
+
+	// Placeholder returns the '?' placeholder of a value of type t, annotated with
+	// its type when the engine cannot infer it.
+	Placeholder(t ValueType) string
+
+	// MaxPlaceholders returns the maximum number of placeholders a statement can have.
+	MaxPlaceholders() int
+
+	// QuoteString returns value as an SQL string literal.
+	QuoteString(value string) string
+
+	// QuoteIdentifier returns name as a quoted SQL identifier.
+	QuoteIdentifier(name string) string
+
+	// Bool returns the SQL literal of value.
+	Bool(value bool) string
+
+	// CastNumeric converts expr so that it can be compared with numbers.
+	CastNumeric(expr string) string
+
+	// SortKey converts expr so that rows are sorted by its numeric value when numeric is set, by its text otherwise.
+	SortKey(expr string, numeric bool) string
+
+	// Length returns the SQL expression computing the text length of field.
+	Length(field string) string
+
+	// Concat returns the SQL expression concatenating values as text.
+	Concat(values ...string) string
+
+	// JSONScalar returns the SQL expression of the whole JSON value of field as it is compared with scalars.
+	JSONScalar(field string) string
+
+	// JSONExtract returns the SQL expression that extracts the unquoted value found at path in field.
+	JSONExtract(field string, path string) string
+
+	// JSONQuery returns the SQL expression of the JSON value found at path in field, kept as JSON
+	// so that objects and arrays can be embedded in other JSON documents.
+	JSONQuery(field string, path string) string
+
+	// JSONSet returns the SQL expression that sets value at path in field. value is rendered with JSONValue.
+	JSONSet(field string, path string, value string) string
+
+	// JSONContainsPath returns the SQL condition that tells if field has a value at path.
+	JSONContainsPath(field string, path string) string
+
+	// JSONIsNull returns the SQL condition that tells if field has no value or a JSON null at path.
+	JSONIsNull(field string, path string) string
+
+	// JSONType returns the SQL expression of the lower case type name of the JSON value found at path in field,
+	// such as 'object' or 'array'. It is null when field has no value at path.
+	JSONType(field string, path string) string
+
+	// JSONRemove returns the SQL expression that removes the value found at path in field.
+	JSONRemove(field string, path string) string
+
+	// JSONArrayAppend returns the SQL expression that appends value to the JSON array found at path in field.
+	// value is rendered with JSONValue. field is unchanged when it has no array at path.
+	JSONArrayAppend(field string, path string, value string) string
+
+	// JSONArrayInsert returns the SQL expression that inserts value at index in the JSON array found at path in field,
+	// shifting the following elements. value is appended when index is past the end of the array.
+	JSONArrayInsert(field string, path string, index int, value string) string
+
+	// JSONNot returns the SQL expression of the JSON boolean negating the value found at path in field,
+	// which is true when the value is not the JSON true.
+	JSONNot(field string, path string) string
+
+	// JSONArrayContains returns the SQL condition that tells if the JSON array found at path in field
+	// contains the scalar value.
+	JSONArrayContains(field string, path string, value string) string
+
+	// JSONArrayLength returns the SQL expression computing the length of the JSON array found at path in field.
+	JSONArrayLength(field string, path string) string
+
+	// JSONLiteral returns the SQL expression of the JSON document text, typed as JSON.
+	JSONLiteral(text string) string
+
+	// JSONArray returns the SQL expression building a JSON array from values.
+	JSONArray(values ...string) string
+
+	// JSONObject returns the SQL expression building a JSON object from key/value expressions.
+	JSONObject(values ...string) string
+
+	// JSONValue converts the SQL expression value into a value that can be set in a JSON document.
+	JSONValue(value string) string
+
+	// JSONColumn returns the definition of the column name generated from the value found at path in field,
+	// converted to values of type t.
+	JSONColumn(name string, field string, path string, t ValueType) string
+
+	// Upsert returns an insert statement of columns into table that updates the updates columns
+	// when a row with the same keys already exists. An update is either a column, which is set to the inserted value,
+	// or an assignment such as "version=version+1" evaluated on the existing row. The statement does nothing
+	// for existing rows when updates is empty.
+	Upsert(table string, columns []string, keys []string, updates []string) string
+
+	// CreateIndexQuery returns the statement that creates index.
+	CreateIndexQuery(index Index) string
+
+	// DropIndexQuery returns the statement that drops index.
+	DropIndexQuery(index Index) string
+
+	// IndexesQuery returns a query listing the names of the indexes of table.
+	IndexesQuery(table string) string
+
+	// IndexColumnsQuery returns a query listing the columns of the indexes of table, ordered by index name
+	// and position in the index. Each row holds the index name, the column name, 1 if the index is unique,
+	// 1 if the column is sorted in descending order, the column prefix length or 0, and the partial index predicate
+	// or an empty string.
+	IndexColumnsQuery(table string) string
+
+	// TablesQuery returns a query listing the names of the tables of the database, ordered by name.
+	TablesQuery() string
+
+	// ColumnsQuery returns a query listing the columns of table, in order. Each row holds the column name,
+	// its type, whether it is nullable, its default value expression or an empty string, and whether it is part
+	// of the primary key.
+	ColumnsQuery(table string) string
+
+	// ForeignKeysQuery returns a query listing the columns of the foreign keys of table, ordered by foreign key name
+	// and position. Each row holds the foreign key name, the column name, the referenced table, the referenced column
+	// and whether deletes cascade.
+	ForeignKeysQuery(table string) string
+
+	// IsDuplicateKeyError tells if err is raised by a unique constraint violation.
+	IsDuplicateKeyError(err error) bool
+
+	// SupportsReturning tells if insert and update statements can return the rows they write with a returning clause.
+	SupportsReturning() bool
+
+	// SkipLocked returns the clause appended to select statements to lock the selected rows and skip the rows
+	// locked by other transactions. It is empty for engines that lock the whole database when writing.
+	SkipLocked() string
+
+	// SupportsPartialIndexes tells if CreateIndexQuery renders the Where predicate of indexes.
+	SupportsPartialIndexes() bool
+
+	// SupportsIndexPrefixLengths tells if CreateIndexQuery renders the Lengths of indexes and IndexColumnsQuery reads them.
+	SupportsIndexPrefixLengths() bool
+
+	// SupportsAlterTableForeignKeys tells if DB.AddForeignKey adds foreign keys to existing tables.
+	// Foreign keys are only declared in table definitions otherwise.
+	SupportsAlterTableForeignKeys() bool
+}
+
+var (
+	dialectsMux = new(sync.RWMutex)
+	dialects    = map[string]Dialect{
+		MySQL:    MySQLDialect{},
+		SQLite3:  SQLiteDialect{},
+		Postgres: PostgresDialect{},
+	}
+)
+
+// RegisterDialect registers a dialect under its name. It replaces any dialect registered with the same name.
+// See BaseDialect for the implementation of custom dialects.
+func RegisterDialect(dialect Dialect) {
+	dialectsMux.Lock()
+	defer dialectsMux.Unlock()
+	dialects[dialect.Name()] = dialect
+}
+
+// LookupDialect returns the dialect registered with name.
+func LookupDialect(name string) (Dialect, bool) {
+	dialectsMux.RLock()
+	defer dialectsMux.RUnlock()
+	dialect, found := dialects[name]
+	return dialect, found
+}
+
+var limitOffsetPattern = regexp.MustCompile(`(?i)limit\s+\?\s*,\s*\?`)
+
+// rebind converts '?' placeholders into PostgreSQL positional placeholders ($1, $2, ...).
+// The "limit ?, ?" clause is rewritten as "offset ? limit ?" so that arguments keep their order.
+func rebind(query string) string {
+	query = limitOffsetPattern.ReplaceAllString(query, "offset ? limit ?")
+
+	var (
+		builder strings.Builder
+		quote   byte
+		n       int
+	)
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			builder.WriteByte(c)
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+			builder.WriteByte(c)
+		case '?':
+			n++
+			builder.WriteString("$" + strconv.Itoa(n))
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
+
+// jsonPathSegments splits a JSON path like $.a.b[0] into its keys and array indexes.
+func jsonPathSegments(path string) []string {
+	path = strings.TrimPrefix(normalizedJsonPath(path), "$")
+
+	var segments []string
+	for _, part := range strings.Split(path, ".") {
+		if part == "" {
+			continue
+		}
+
+		for part != "" {
+			open := strings.Index(part, "[")
+			if open == -1 {
+				segments = append(segments, part)
+				break
+			}
+			if open > 0 {
+				segments = append(segments, part[:open])
+			}
+			end := strings.Index(part, "]")
+			if end == -1 {
+				segments = append(segments, part[open:])
+				break
+			}
+			segments = append(segments, part[open+1:end])
+			part = part[end+1:]
+		}
+	}
+	return segments
+}
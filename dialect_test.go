@@ -0,0 +1,151 @@
+package bome
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type mariaDBDialect struct {
+	MySQLDialect
+}
+
+func (mariaDBDialect) Name() string {
+	return "mariadb"
+}
+
+func TestRegisterDialect(t *testing.T) {
+	Convey("Register a custom dialect", t, func() {
+		_, found := LookupDialect("mariadb")
+		So(found, ShouldBeFalse)
+
+		RegisterDialect(mariaDBDialect{})
+		dialect, found := LookupDialect("mariadb")
+		So(found, ShouldBeTrue)
+		So(dialect.DriverName(), ShouldEqual, MySQL)
+	})
+}
+
+type cockroachDialect struct {
+	BaseDialect
+}
+
+func (cockroachDialect) Name() string {
+	return "cockroach"
+}
+
+func (cockroachDialect) DriverName() string {
+	return Postgres
+}
+
+func (cockroachDialect) DataSourceName(dsn string) string {
+	return PostgresDialect{}.DataSourceName(dsn)
+}
+
+func (cockroachDialect) Rebind(query string) string {
+	return rebind(query)
+}
+
+func (cockroachDialect) SkipLocked() string {
+	return PostgresDialect{}.SkipLocked()
+}
+
+func TestBaseDialect(t *testing.T) {
+	Convey("Custom dialects embedding BaseDialect only implement what differs", t, func() {
+		var dialect Dialect = cockroachDialect{}
+		So(dialect.Rebind("select * from t where a=?"), ShouldEqual, "select * from t where a=$1")
+		So(dialect.JSONExtract("value", "$.a"), ShouldEqual, SQLiteDialect{}.JSONExtract("value", "$.a"))
+		So(dialect.Configure(nil), ShouldBeNil)
+	})
+
+	Convey("Statements depend on dialect capabilities rather than on dialect names", t, func() {
+		upsert := saveStatement(cockroachDialect{}, saveUpsert, []string{"name", "value"}, []string{"name"}, []string{"value"})
+		So(upsert.query(1), ShouldEndWith, " returning version;")
+
+		upsert = saveStatement(mariaDBDialect{}, saveUpsert, []string{"name", "value"}, []string{"name"}, []string{"value"})
+		So(upsert.query(1), ShouldNotContainSubstring, "returning")
+
+		So(cockroachDialect{}.SkipLocked(), ShouldEqual, " for update skip locked")
+	})
+}
+
+func TestRebind(t *testing.T) {
+	Convey("Rebind placeholders for PostgreSQL", t, func() {
+		So(rebind("select * from t where a=? and b='?' limit ?, ?;"), ShouldEqual, "select * from t where a=$1 and b='?' offset $2 limit $3;")
+	})
+}
+
+func TestPostgresJsonAccessor(t *testing.T) {
+	Convey("PostgreSQL json accessor", t, func() {
+		d := PostgresDialect{}
+		So(d.JSONExtract("value", "$.address.city"), ShouldEqual, "(value->'address'->>'city')")
+		So(d.JSONExtract("value", "$.items[2].name"), ShouldEqual, "(value->'items'->2->>'name')")
+		So(d.JSONSet("value", "$.a.b", "to_jsonb(1)"), ShouldEqual, "jsonb_set(value, '{a,b}', to_jsonb(1), true)")
+		So(d.JSONRemove("value", "$.a.b"), ShouldEqual, "(value #- '{a,b}')")
+		So(d.JSONArrayAppend("value", "$.tags", "to_jsonb(1)"), ShouldEqual, "jsonb_insert(value, '{tags,-1}', to_jsonb(1), true)")
+		So(d.JSONArrayInsert("value", "$.tags", 2, "to_jsonb(1)"), ShouldEqual, "jsonb_insert(value, '{tags,2}', to_jsonb(1))")
+	})
+}
+
+func TestPostgresExpressions(t *testing.T) {
+	Convey("PostgreSQL expressions rendering", t, func() {
+		sql, args := conditionSQL(PostgresDialect{}, JsonAtGt("$.age", IntExpr(29)))
+		So(sql, ShouldEqual, "(cast((value->>'age') as numeric) > cast(? as bigint))")
+		So(args, ShouldResemble, []interface{}{int64(29)})
+
+		sql, args = conditionSQL(PostgresDialect{}, JsonAtEq("$.o'neil", StringExpr("val")))
+		So(sql, ShouldEqual, "((value->>'o''neil') = cast(? as text))")
+		So(args, ShouldResemble, []interface{}{"val"})
+
+		sql, args = conditionSQL(PostgresDialect{}, JsonArrayContains("$.tags", StringExpr("go")))
+		So(sql, ShouldEqual, "((value->'tags') @> jsonb_build_array(cast(? as text)))")
+		So(args, ShouldResemble, []interface{}{"go"})
+
+		sql, _ = conditionSQL(PostgresDialect{}, JsonAtIsNull("$.a.b"))
+		So(sql, ShouldEqual, "((value->'a'->'b') is null or jsonb_typeof((value->'a'->'b')) = 'null')")
+
+		sql, _ = conditionSQL(PostgresDialect{}, JsonArrayLength("$.tags", OpGt, 1))
+		So(sql, ShouldEqual, "(jsonb_array_length((value->'tags')) > cast(? as bigint))")
+
+		value, _ := jsonValueSQL(PostgresDialect{}, Add(Coalesce(JsonAt("$.visits"), IntExpr(0)), FloatExpr(0.5)))
+		So(value, ShouldEqual, "to_jsonb((coalesce(cast((value->>'visits') as numeric), cast(? as bigint)) + cast(? as double precision)))")
+
+		value, _ = jsonValueSQL(PostgresDialect{}, BoolLiteral(true))
+		So(value, ShouldEqual, "to_jsonb(cast('true' as jsonb))")
+	})
+}
+
+func TestMySQLArrayEdits(t *testing.T) {
+	Convey("MySQL array edits", t, func() {
+		d := MySQLDialect{}
+		So(d.JSONArrayAppend("value", "$.tags", "?"), ShouldEqual, "json_array_append(value, '$.tags', ?)")
+		So(d.JSONArrayInsert("value", "tags", 0, "?"), ShouldEqual, "json_array_insert(value, '$.tags[0]', ?)")
+		So(d.JSONRemove("value", "$.a"), ShouldEqual, "json_remove(value, '$.a')")
+	})
+}
+
+func TestAggregateSQL(t *testing.T) {
+	Convey("Aggregates compare JSON values as numbers", t, func() {
+		So(Avg("$.age").sql(PostgresDialect{}, "value"), ShouldEqual, "avg(cast((value->>'age') as numeric))")
+		So(Max("$.age").sql(MySQLDialect{}, "value"), ShouldEqual, "max((json_unquote(json_extract(value, '$.age'))+0))")
+		So(CountDistinct("$.city").sql(SQLiteDialect{}, "value"), ShouldEqual, "count(distinct json_extract(value, '$.city'))")
+		So(CountAll().sql(MySQLDialect{}, "value"), ShouldEqual, "count(*)")
+	})
+}
+
+func TestUpsert(t *testing.T) {
+	Convey("Upsert statements with assignments or without update", t, func() {
+		columns := []string{"name", "value"}
+		keys := []string{"name"}
+
+		So(MySQLDialect{}.Upsert("t", columns, keys, []string{"value", "version=version+1"}), ShouldEqual,
+			"insert into t (name,value) values (?,?) on duplicate key update value=values(value),version=version+1")
+		So(MySQLDialect{}.Upsert("t", columns, keys, nil), ShouldEqual,
+			"insert into t (name,value) values (?,?) on duplicate key update name=name")
+
+		So(SQLiteDialect{}.Upsert("t", columns, keys, []string{"value", "version=version+1"}), ShouldEqual,
+			"insert into t (name,value) values (?,?) on conflict (name) do update set value=excluded.value,version=version+1")
+		So(PostgresDialect{}.Upsert("t", columns, keys, nil), ShouldEqual,
+			"insert into t (name,value) values (?,?) on conflict (name) do nothing")
+	})
+}
@@ -5,8 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"reflect"
-	"strings"
+
+	"github.com/omecodes/errors"
 )
 
 type DMap struct {
@@ -14,7 +14,8 @@ type DMap struct {
 	*JsonValueHolder
 	tx        *TX
 	tableName string
-	dialect   string
+	dialect   Dialect
+	sweeper   *sweeper
 }
 
 func (s *DMap) Table() string {
@@ -35,7 +36,7 @@ func (s *DMap) Transaction(ctx context.Context) (context.Context, *DMap, error)
 		}
 
 		var err error
-		tx, err = s.BeginTx()
+		tx, err = s.BeginTxContext(ctx, nil)
 		if err != nil {
 			return ctx, nil, err
 		}
@@ -43,9 +44,16 @@ func (s *DMap) Transaction(ctx context.Context) (context.Context, *DMap, error)
 		newCtx := contextWithTransaction(ctx, tx)
 		return newCtx, &DMap{
 			JsonValueHolder: &JsonValueHolder{
-				dialect: s.dialect,
-				tx:      tx,
+				DB:        s.DB,
+				field:     "value",
+				columns:   s.columns,
+				expiry:    s.expiry,
+				versioned: s.versioned,
+				keys:      s.keys,
+				dialect:   s.dialect,
+				tx:        tx,
 			},
+			DB:        s.DB,
 			tableName: s.tableName,
 			tx:        tx,
 			dialect:   s.dialect,
@@ -65,9 +73,16 @@ func (s *DMap) Transaction(ctx context.Context) (context.Context, *DMap, error)
 	newCtx := contextWithTransaction(ctx, tx)
 	return newCtx, &DMap{
 		JsonValueHolder: &JsonValueHolder{
-			dialect: s.dialect,
-			tx:      tx,
+			DB:        s.DB,
+			field:     "value",
+			columns:   s.columns,
+			expiry:    s.expiry,
+			versioned: s.versioned,
+			keys:      s.keys,
+			dialect:   s.dialect,
+			tx:        tx,
 		},
+		DB:        s.DB,
 		tableName: s.tableName,
 		tx:        tx,
 		dialect:   s.dialect,
@@ -95,13 +110,38 @@ func (s *DMap) Client() Client {
 	return s.DB
 }
 
+// client returns the client that runs queries for ctx. When the collection is not bound to a transaction,
+// the transaction stored in ctx is used if it was started on the same database.
+func (s *DMap) client(ctx context.Context) Client {
+	if s.tx == nil {
+		if tx := transaction(ctx); tx != nil && tx.db.sqlDb == s.DB.sqlDb {
+			return tx.New(s.DB)
+		}
+	}
+	return s.Client()
+}
+
 func (s *DMap) Contains(key1, key2 string) (bool, error) {
-	o, err := s.Client().QueryFirst("select 1 from $table$ where first_key=? and second_key=?;", BoolScanner, key1, key2)
-	return o.(bool), err
+	return s.ContainsContext(context.Background(), key1, key2)
+}
+
+func (s *DMap) ContainsContext(ctx context.Context, key1, key2 string) (bool, error) {
+	o, err := s.client(ctx).QueryFirstContext(ctx, "select 1 from $table$ where first_key=? and second_key=? and "+notExpired+";", BoolScanner, key1, key2, nowMillis())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return o.(bool), nil
 }
 
 func (s *DMap) Count() (int64, error) {
-	o, err := s.Client().QueryFirst("select count(*) from $table$;", IntScanner)
+	return s.CountContext(context.Background())
+}
+
+func (s *DMap) CountContext(ctx context.Context) (int64, error) {
+	o, err := s.client(ctx).QueryFirstContext(ctx, "select count(*) from $table$ where "+notExpired+";", IntScanner, nowMillis())
 	if err != nil {
 		return 0, err
 	}
@@ -109,23 +149,36 @@ func (s *DMap) Count() (int64, error) {
 }
 
 func (s *DMap) CountForFirstKey(key string) (int, error) {
-	o, err := s.Client().QueryFirst("select count(*) from $table$ where first_key=?;", IntScanner, key)
+	return s.CountForFirstKeyContext(context.Background(), key)
+}
+
+func (s *DMap) CountForFirstKeyContext(ctx context.Context, key string) (int, error) {
+	o, err := s.client(ctx).QueryFirstContext(ctx, "select count(*) from $table$ where first_key=? and "+notExpired+";", IntScanner, key, nowMillis())
 	if err != nil {
 		return 0, err
 	}
-	return o.(int), nil
+	return int(o.(int64)), nil
 }
 
 func (s *DMap) CountForSecondKey(key string) (int, error) {
-	o, err := s.Client().QueryFirst("select count(*) from $table$ where second_key=?;", IntScanner, key)
+	return s.CountForSecondKeyContext(context.Background(), key)
+}
+
+func (s *DMap) CountForSecondKeyContext(ctx context.Context, key string) (int, error) {
+	o, err := s.client(ctx).QueryFirstContext(ctx, "select count(*) from $table$ where second_key=? and "+notExpired+";", IntScanner, key, nowMillis())
 	if err != nil {
 		return 0, err
 	}
-	return o.(int), nil
+	return int(o.(int64)), nil
 }
 
 func (s *DMap) Size(key1 string, key2 string) (int64, error) {
-	o, err := s.Client().QueryFirst("select coalesce(length(value), 0) from $table$ where first_key=? and second_key=?;", IntScanner, key1, key2)
+	return s.SizeContext(context.Background(), key1, key2)
+}
+
+func (s *DMap) SizeContext(ctx context.Context, key1 string, key2 string) (int64, error) {
+	rawQuery := fmt.Sprintf("select coalesce(%s, 0) from $table$ where first_key=? and second_key=? and %s;", s.dialect.Length("value"), notExpired)
+	o, err := s.client(ctx).QueryFirstContext(ctx, rawQuery, IntScanner, key1, key2, nowMillis())
 	if err != nil {
 		return 0, err
 	}
@@ -133,7 +186,12 @@ func (s *DMap) Size(key1 string, key2 string) (int64, error) {
 }
 
 func (s *DMap) TotalSize() (int64, error) {
-	o, err := s.Client().QueryFirst("select coalesce(sum(length(value)), 0) from $table$;", IntScanner)
+	return s.TotalSizeContext(context.Background())
+}
+
+func (s *DMap) TotalSizeContext(ctx context.Context) (int64, error) {
+	rawQuery := fmt.Sprintf("select coalesce(sum(%s), 0) from $table$ where %s;", s.dialect.Length("value"), notExpired)
+	o, err := s.client(ctx).QueryFirstContext(ctx, rawQuery, IntScanner, nowMillis())
 	if err != nil {
 		return 0, err
 	}
@@ -141,35 +199,221 @@ func (s *DMap) TotalSize() (int64, error) {
 }
 
 func (s *DMap) Save(key1, key2 string, value string, opts SaveOptions) error {
-	err := s.Client().Exec("insert into $table$ values (?, ?, ?);", key1, key2, value).Error
-	if err != nil && isPrimaryKeyConstraintError(err) && opts.UpdateExisting {
-		return s.Client().Exec("update $table$ set value=? where first_key=? and second_key=?;", value, key1, key2).Error
+	return s.SaveContext(context.Background(), key1, key2, value, opts)
+}
+
+func (s *DMap) SaveContext(ctx context.Context, key1, key2 string, value string, opts SaveOptions) error {
+	return s.PutContext(ctx, key1, key2, value, opts).Error
+}
+
+// Put is like Save but its result tells if the entry was inserted or updated.
+func (s *DMap) Put(key1, key2 string, value string, opts SaveOptions) Result {
+	return s.PutContext(context.Background(), key1, key2, value, opts)
+}
+
+// PutContext saves the (key1, key2) entry in a single statement when opts.UpdateExisting or opts.OnlyIfAbsent is set.
+// An existing entry is replaced if it is expired. Otherwise, it fails with a duplicate key error unless
+// opts.UpdateExisting is set, or is left unchanged if opts.OnlyIfAbsent is set.
+func (s *DMap) PutContext(ctx context.Context, key1, key2 string, value string, opts SaveOptions) Result {
+	expiresAt := opts.expiresAt()
+	columns := []string{"first_key", "second_key", "value", "expires_at"}
+
+	if opts.UpdateExisting && !opts.OnlyIfAbsent {
+		return upsert(ctx, s.client(ctx), s.dialect, columns, s.Keys(), []string{"value", "expires_at"}, key1, key2, value, expiresAt)
+	}
+
+	var result Result
+	if opts.OnlyIfAbsent {
+		result = insertIfAbsent(ctx, s.client(ctx), s.dialect, columns, s.Keys(), key1, key2, value, expiresAt)
+	} else {
+		result = insert(ctx, s.client(ctx), s.dialect, columns, key1, key2, value, expiresAt)
+	}
+	if result.Inserted == 1 || (result.Error != nil && !s.dialect.IsDuplicateKeyError(result.Error)) {
+		return result
+	}
+
+	replaced := s.client(ctx).ExecContext(ctx, "update $table$ set value=?, expires_at=?, version=version+1 where first_key=? and second_key=? and expires_at<=?;",
+		value, expiresAt, key1, key2, nowMillis())
+	if replaced.Error != nil || replaced.AffectedRows == 0 {
+		return result
 	}
-	return err
+	replaced.Updated = replaced.AffectedRows
+	return replaced
 }
 
-func (s *DMap) Read(key1, key2 string, o interface{}) error {
-	res, err := s.Client().QueryFirst("select value from $table$ where first_key=? and second_key=?;", StringScanner, key1, key2)
+// SaveMany saves entries by chunks in a single transaction, or in the transaction of ctx. opts applies to all entries.
+// Expired entries of the saved keys are purged first unless opts.UpdateExisting is set.
+func (s *DMap) SaveMany(entries []*DoubleMapEntry, opts SaveOptions) BatchResult {
+	return s.SaveManyContext(context.Background(), entries, opts)
+}
+
+func (s *DMap) SaveManyContext(ctx context.Context, entries []*DoubleMapEntry, opts SaveOptions) BatchResult {
+	expiresAt := opts.expiresAt()
+	rows := make([]batchRow, 0, len(entries))
+	for _, entry := range entries {
+		rows = append(rows, batchRow{
+			key:  DoubleMapKey{FirstKey: entry.FirstKey, SecondKey: entry.SecondKey},
+			args: []interface{}{entry.FirstKey, entry.SecondKey, entry.Value, expiresAt},
+		})
+	}
+	stmt := saveStatement(s.dialect, opts.mode(), []string{"first_key", "second_key", "value", "expires_at"}, s.Keys(), []string{"value", "expires_at"})
+
+	owned := s.tx == nil && transaction(ctx) == nil
+	ctx, ts, err := s.Transaction(ctx)
+	if err != nil {
+		return BatchResult{Error: err}
+	}
+
+	if opts.mode() != saveUpsert {
+		keyRows := make([]batchRow, 0, len(rows))
+		for _, row := range rows {
+			keyRows = append(keyRows, batchRow{key: row.key, args: row.args[:2]})
+		}
+		if purged := writeBatch(ctx, ts.client(ctx), s.dialect, purgeExpiredStatement(s.Keys()), keyRows); purged.Error != nil {
+			return endBatch(owned, ts, BatchResult{Error: purged.Error})
+		}
+	}
+	return endBatch(owned, ts, writeBatch(ctx, ts.client(ctx), s.dialect, stmt, rows))
+}
+
+// DeleteMany deletes the entries of keys by chunks in a single transaction, or in the transaction of ctx.
+func (s *DMap) DeleteMany(keys []DoubleMapKey) BatchResult {
+	return s.DeleteManyContext(context.Background(), keys)
+}
+
+func (s *DMap) DeleteManyContext(ctx context.Context, keys []DoubleMapKey) BatchResult {
+	rows := make([]batchRow, 0, len(keys))
+	for _, key := range keys {
+		rows = append(rows, batchRow{key: key, args: []interface{}{key.FirstKey, key.SecondKey}})
+	}
+
+	owned := s.tx == nil && transaction(ctx) == nil
+	ctx, ts, err := s.Transaction(ctx)
+	if err != nil {
+		return BatchResult{Error: err}
+	}
+	return endBatch(owned, ts, writeBatch(ctx, ts.client(ctx), s.dialect, deleteStatement(s.Keys()), rows))
+}
+
+// SaveIfVersion is like Save but fails with a VersionConflictError if the (key1, key2) entry version is not expectedVersion.
+// An expectedVersion of 0 means that the (key1, key2) entry must not exist.
+func (s *DMap) SaveIfVersion(key1, key2 string, value string, expectedVersion int64) error {
+	return s.SaveIfVersionContext(context.Background(), key1, key2, value, expectedVersion)
+}
+
+func (s *DMap) SaveIfVersionContext(ctx context.Context, key1, key2 string, value string, expectedVersion int64) error {
+	if expectedVersion == 0 {
+		result := s.PutContext(ctx, key1, key2, value, SaveOptions{OnlyIfAbsent: true})
+		if result.Error == nil && result.Inserted+result.Updated == 0 {
+			return &VersionConflictError{Expected: expectedVersion}
+		}
+		return result.Error
+	}
+
+	result := s.client(ctx).ExecContext(ctx, "update $table$ set value=?, version=version+1 where first_key=? and second_key=? and version=? and "+notExpired+";",
+		value, key1, key2, expectedVersion, nowMillis())
+	return versionResultError(result, expectedVersion)
+}
+
+// CompareAndSwap replaces the (key1, key2) value with newValue if it is the same JSON value as oldValue.
+// It fails with a VersionConflictError if the value is different or is modified concurrently.
+func (s *DMap) CompareAndSwap(key1, key2 string, oldValue, newValue string) error {
+	return s.CompareAndSwapContext(context.Background(), key1, key2, oldValue, newValue)
+}
+
+func (s *DMap) CompareAndSwapContext(ctx context.Context, key1, key2 string, oldValue, newValue string) error {
+	current, version, err := s.ReadRawWithVersionContext(ctx, key1, key2)
 	if err != nil {
 		return err
 	}
 
-	if o == nil {
-		o = reflect.New(reflect.TypeOf(o))
+	if !sameJSON(current, oldValue) {
+		return &VersionConflictError{Expected: version}
 	}
+	return s.SaveIfVersionContext(ctx, key1, key2, newValue, version)
+}
+
+func (s *DMap) Read(key1, key2 string, o interface{}) error {
+	return s.ReadContext(context.Background(), key1, key2, o)
+}
+
+func (s *DMap) ReadContext(ctx context.Context, key1, key2 string, o interface{}) error {
+	res, err := s.client(ctx).QueryFirstContext(ctx, "select value from $table$ where first_key=? and second_key=? and "+notExpired+";", StringScanner, key1, key2, nowMillis())
+	if err != nil {
+		return err
+	}
+
 	return json.Unmarshal([]byte(res.(string)), o)
 }
 
 func (s *DMap) ReadRaw(key1, key2 string) (string, error) {
-	o, err := s.Client().QueryFirst("select value from $table$ where first_key=? and second_key=?;", StringScanner, key1, key2)
+	return s.ReadRawContext(context.Background(), key1, key2)
+}
+
+func (s *DMap) ReadRawContext(ctx context.Context, key1, key2 string) (string, error) {
+	o, err := s.client(ctx).QueryFirstContext(ctx, "select value from $table$ where first_key=? and second_key=? and "+notExpired+";", StringScanner, key1, key2, nowMillis())
 	if err != nil {
 		return "", err
 	}
 	return o.(string), nil
 }
 
+// ReadMany returns the values of pairs. Pairs are read by chunks sized to the dialect placeholders limit.
+func (s *DMap) ReadMany(pairs []DoubleMapKey) (*BatchRead[DoubleMapKey, string], error) {
+	return s.ReadManyContext(context.Background(), pairs)
+}
+
+func (s *DMap) ReadManyContext(ctx context.Context, pairs []DoubleMapKey) (*BatchRead[DoubleMapKey, string], error) {
+	return readBatch(ctx, s.client(ctx), s.dialect, &batchQuery[DoubleMapKey, string]{
+		query: func(n int) string {
+			return "select first_key, second_key, value from $table$ where " + inKeys(s.Keys(), n) + " and " + notExpired + ";"
+		},
+		args: func(key DoubleMapKey) []interface{} {
+			return []interface{}{key.FirstKey, key.SecondKey}
+		},
+		extra:   []interface{}{nowMillis()},
+		scanner: DoubleMapEntryScanner,
+		entry: func(o interface{}) (DoubleMapKey, string) {
+			entry := o.(*DoubleMapEntry)
+			return DoubleMapKey{FirstKey: entry.FirstKey, SecondKey: entry.SecondKey}, entry.Value
+		},
+	}, pairs)
+}
+
+// ReadWithVersion decodes the (key1, key2) value in o and returns the entry version.
+func (s *DMap) ReadWithVersion(key1, key2 string, o interface{}) (int64, error) {
+	return s.ReadWithVersionContext(context.Background(), key1, key2, o)
+}
+
+func (s *DMap) ReadWithVersionContext(ctx context.Context, key1, key2 string, o interface{}) (int64, error) {
+	value, version, err := s.ReadRawWithVersionContext(ctx, key1, key2)
+	if err != nil {
+		return 0, err
+	}
+	return version, json.Unmarshal([]byte(value), o)
+}
+
+// ReadRawWithVersion returns the (key1, key2) value and the entry version.
+func (s *DMap) ReadRawWithVersion(key1, key2 string) (string, int64, error) {
+	return s.ReadRawWithVersionContext(context.Background(), key1, key2)
+}
+
+func (s *DMap) ReadRawWithVersionContext(ctx context.Context, key1, key2 string) (string, int64, error) {
+	o, err := s.client(ctx).QueryFirstContext(ctx, "select value, version from $table$ where first_key=? and second_key=? and "+notExpired+";",
+		VersionedValueScanner, key1, key2, nowMillis())
+	if err != nil {
+		return "", 0, err
+	}
+	v := o.(*VersionedValue)
+	return v.Value, v.Version, nil
+}
+
 func (s *DMap) RangeByFirstKey(key string, offset, count int) ([]*MapEntry, error) {
-	c, err := s.Client().Query("select second_key, value from $table$ where first_key=? limit ?, ?;", MapEntryScanner, key, offset, count)
+	return s.RangeByFirstKeyContext(context.Background(), key, offset, count)
+}
+
+func (s *DMap) RangeByFirstKeyContext(ctx context.Context, key string, offset, count int) ([]*MapEntry, error) {
+	c, err := s.client(ctx).QueryContext(ctx, "select second_key, value from $table$ where first_key=? and "+notExpired+" limit ?, ?;", MapEntryScanner, key, nowMillis(), offset, count)
 	if err != nil {
 		return nil, err
 	}
@@ -193,7 +437,11 @@ func (s *DMap) RangeByFirstKey(key string, offset, count int) ([]*MapEntry, erro
 }
 
 func (s *DMap) RangeBySecondKey(key string, offset, count int) ([]*MapEntry, error) {
-	c, err := s.Client().Query("select first_key, value from $table$ where second_key=? limit ?, ?;", MapEntryScanner, key, offset, count)
+	return s.RangeBySecondKeyContext(context.Background(), key, offset, count)
+}
+
+func (s *DMap) RangeBySecondKeyContext(ctx context.Context, key string, offset, count int) ([]*MapEntry, error) {
+	c, err := s.client(ctx).QueryContext(ctx, "select first_key, value from $table$ where second_key=? and "+notExpired+" limit ?, ?;", MapEntryScanner, key, nowMillis(), offset, count)
 	if err != nil {
 		return nil, err
 	}
@@ -216,7 +464,11 @@ func (s *DMap) RangeBySecondKey(key string, offset, count int) ([]*MapEntry, err
 }
 
 func (s *DMap) Range(offset, count int) ([]*DoubleMapEntry, error) {
-	c, err := s.Client().Query("select * from $table$ limit ?, ?;", DoubleMapEntryScanner, offset, count)
+	return s.RangeContext(context.Background(), offset, count)
+}
+
+func (s *DMap) RangeContext(ctx context.Context, offset, count int) ([]*DoubleMapEntry, error) {
+	c, err := s.client(ctx).QueryContext(ctx, "select first_key, second_key, value from $table$ where "+notExpired+" limit ?, ?;", DoubleMapEntryScanner, nowMillis(), offset, count)
 	if err != nil {
 		return nil, err
 	}
@@ -238,100 +490,241 @@ func (s *DMap) Range(offset, count int) ([]*DoubleMapEntry, error) {
 	return entries, nil
 }
 
+// Page returns the page of entries described by req, in (first key, second key) order.
+func (s *DMap) Page(ctx context.Context, req PageRequest) (*PageResult[*DoubleMapEntry], error) {
+	return readPage(ctx, s.client(ctx), &keyset[*DoubleMapEntry]{
+		columns:   "first_key, second_key, value",
+		keys:      s.Keys(),
+		scanner:   DoubleMapEntryScanner,
+		condition: s.condition,
+		visible:   s.visible,
+		key: func(e *DoubleMapEntry) []string {
+			return []string{e.FirstKey, e.SecondKey}
+		},
+		args: stringKeyArgs,
+	}, req)
+}
+
 func (s *DMap) GetForFirst(key1 string) (Cursor, error) {
-	return s.Client().Query("select second_key, value from $table$ where first_key=?;", MapEntryScanner, key1)
+	return s.GetForFirstContext(context.Background(), key1)
+}
+
+func (s *DMap) GetForFirstContext(ctx context.Context, key1 string) (Cursor, error) {
+	return s.client(ctx).QueryContext(ctx, "select second_key, value from $table$ where first_key=? and "+notExpired+";", MapEntryScanner, key1, nowMillis())
 }
 
 func (s *DMap) GetForSecond(key2 string) (Cursor, error) {
-	return s.Client().Query("select first_key, value from $table$ where second_key=?;", MapEntryScanner, key2)
+	return s.GetForSecondContext(context.Background(), key2)
+}
+
+func (s *DMap) GetForSecondContext(ctx context.Context, key2 string) (Cursor, error) {
+	return s.client(ctx).QueryContext(ctx, "select first_key, value from $table$ where second_key=? and "+notExpired+";", MapEntryScanner, key2, nowMillis())
 }
 
 func (s *DMap) GetAll() (Cursor, error) {
-	return s.Client().Query("select * from $table$;", DoubleMapEntryScanner)
+	return s.GetAllContext(context.Background())
+}
+
+func (s *DMap) GetAllContext(ctx context.Context) (Cursor, error) {
+	return s.client(ctx).QueryContext(ctx, "select first_key, second_key, value from $table$ where "+notExpired+";", DoubleMapEntryScanner, nowMillis())
 }
 
 func (s *DMap) AllByFirstKey(key string, where BoolExpr) (Cursor, error) {
-	where.setDialect(s.dialect)
-	rawQuery := fmt.Sprintf("select %s from $table$ where first_key=? and %s;",
+	return s.AllByFirstKeyContext(context.Background(), key, where)
+}
+
+func (s *DMap) AllByFirstKeyContext(ctx context.Context, key string, where BoolExpr) (Cursor, error) {
+	clause, args, err := s.condition(where)
+	if err != nil {
+		return nil, err
+	}
+	rawQuery := fmt.Sprintf("select %s from $table$ where first_key=? and %s and (%s);",
 		s.field,
-		where.sql(),
+		notExpired,
+		clause,
 	)
-	return s.Client().Query(rawQuery, StringScanner, key)
+	return s.client(ctx).QueryContext(ctx, rawQuery, StringScanner, append([]interface{}{key, nowMillis()}, args...)...)
 }
 
 func (s *DMap) AllBySecondKey(key string, where BoolExpr) (Cursor, error) {
-	where.setDialect(s.dialect)
-	rawQuery := fmt.Sprintf("select %s from $table$ where second_key=? and %s;",
+	return s.AllBySecondKeyContext(context.Background(), key, where)
+}
+
+func (s *DMap) AllBySecondKeyContext(ctx context.Context, key string, where BoolExpr) (Cursor, error) {
+	clause, args, err := s.condition(where)
+	if err != nil {
+		return nil, err
+	}
+	rawQuery := fmt.Sprintf("select %s from $table$ where second_key=? and %s and (%s);",
 		s.field,
-		where.sql(),
+		notExpired,
+		clause,
 	)
-	return s.Client().Query(rawQuery, StringScanner, key)
+	return s.client(ctx).QueryContext(ctx, rawQuery, StringScanner, append([]interface{}{key, nowMillis()}, args...)...)
 }
 
 func (s *DMap) Delete(key1, key2 string) error {
-	return s.Client().Exec("delete from $table$ where first_key=? and second_key=?;", key1, key2).Error
+	return s.DeleteContext(context.Background(), key1, key2)
+}
+
+func (s *DMap) DeleteContext(ctx context.Context, key1, key2 string) error {
+	return s.client(ctx).ExecContext(ctx, "delete from $table$ where first_key=? and second_key=?;", key1, key2).Error
 }
 
 func (s *DMap) DeleteAllByFirstKey(key1 string) error {
-	return s.Client().Exec("delete from $table$ where first_key=?;", key1).Error
+	return s.DeleteAllByFirstKeyContext(context.Background(), key1)
+}
+
+func (s *DMap) DeleteAllByFirstKeyContext(ctx context.Context, key1 string) error {
+	return s.client(ctx).ExecContext(ctx, "delete from $table$ where first_key=?;", key1).Error
 }
 
 func (s *DMap) DeleteByFirstKey(key string, where BoolExpr) error {
-	query := fmt.Sprintf("delete from $table$ where first_key=? and %s;", where.sql())
-	return s.Client().Exec(query, key).Error
+	return s.DeleteByFirstKeyContext(context.Background(), key, where)
+}
+
+func (s *DMap) DeleteByFirstKeyContext(ctx context.Context, key string, where BoolExpr) error {
+	clause, args, err := s.condition(where)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("delete from $table$ where first_key=? and (%s);", clause)
+	return s.client(ctx).ExecContext(ctx, query, append([]interface{}{key}, args...)...).Error
 }
 
 func (s *DMap) DeleteAllBySecondKey(key2 string) error {
-	return s.Client().Exec("delete from $table$ where second_key=?;", key2).Error
+	return s.DeleteAllBySecondKeyContext(context.Background(), key2)
+}
+
+func (s *DMap) DeleteAllBySecondKeyContext(ctx context.Context, key2 string) error {
+	return s.client(ctx).ExecContext(ctx, "delete from $table$ where second_key=?;", key2).Error
 }
 
 func (s *DMap) DeleteByDeleteAllBySecondKey(key string, where BoolExpr) error {
-	query := fmt.Sprintf("delete from $table$ where second_key=? and %s;", where.sql())
-	return s.Client().Exec(query, key).Error
+	return s.DeleteByDeleteAllBySecondKeyContext(context.Background(), key, where)
+}
+
+func (s *DMap) DeleteByDeleteAllBySecondKeyContext(ctx context.Context, key string, where BoolExpr) error {
+	clause, args, err := s.condition(where)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("delete from $table$ where second_key=? and (%s);", clause)
+	return s.client(ctx).ExecContext(ctx, query, append([]interface{}{key}, args...)...).Error
 }
 
 func (s *DMap) Edit(key1, key2 string, path string, ex Expression) error {
-	rawQuery := fmt.Sprintf("update $table$ set value=json_set(value, '%s', \"%s\") where first_key=? and second_key=?;",
-		normalizedJsonPath(path),
-		ex.eval(),
+	return s.EditContext(context.Background(), key1, key2, path, ex)
+}
+
+func (s *DMap) EditContext(ctx context.Context, key1, key2 string, path string, ex Expression) error {
+	value, args := jsonValueSQL(s.dialect, ex)
+	rawQuery := fmt.Sprintf("update $table$ set value=%s, version=version+1 where first_key=? and second_key=? and %s;",
+		s.dialect.JSONSet("value", path, value),
+		notExpired,
 	)
-	return s.Client().Exec(rawQuery, key1, key2).Error
+	return s.client(ctx).ExecContext(ctx, rawQuery, append(args, key1, key2, nowMillis())...).Error
 }
 
-func (s *DMap) String(key1, key2 string, path string) (string, error) {
-	var rawQuery string
+// EditIfVersion is like Edit but fails with a VersionConflictError if the (key1, key2) entry version is not expectedVersion.
+func (s *DMap) EditIfVersion(key1, key2 string, path string, ex Expression, expectedVersion int64) error {
+	return s.EditIfVersionContext(context.Background(), key1, key2, path, ex, expectedVersion)
+}
 
-	if s.dialect == SQLite3 {
-		rawQuery = fmt.Sprintf(
-			"select json_extract(value, '%s') from $table$ where first_key=? and second_key=?;", path)
-	} else {
-		rawQuery = fmt.Sprintf(
-			"select json_unquote(json_extract(value, '%s')) from $table$ where first_key=? and second_key=?;", path)
-	}
+func (s *DMap) EditIfVersionContext(ctx context.Context, key1, key2 string, path string, ex Expression, expectedVersion int64) error {
+	value, args := jsonValueSQL(s.dialect, ex)
+	rawQuery := fmt.Sprintf("update $table$ set value=%s, version=version+1 where first_key=? and second_key=? and version=? and %s;",
+		s.dialect.JSONSet("value", path, value),
+		notExpired,
+	)
+	result := s.client(ctx).ExecContext(ctx, rawQuery, append(args, key1, key2, expectedVersion, nowMillis())...)
+	return versionResultError(result, expectedVersion)
+}
 
-	o, err := s.Client().QueryFirst(rawQuery, StringScanner, key1, key2)
+// ApplyPatch applies the JSON Patch document patch (RFC 6902) to the (key1, key2) entry value. Either all the operations
+// are applied or none is: an operation on a missing path or a failing test operation fails with a PatchError.
+func (s *DMap) ApplyPatch(key1, key2 string, patch string) error {
+	return s.ApplyPatchContext(context.Background(), key1, key2, patch)
+}
+
+func (s *DMap) ApplyPatchContext(ctx context.Context, key1, key2 string, patch string) error {
+	return s.JsonValueHolder.applyPatchContext(ctx, And(FirstKey().Eq(StringExpr(key1)), SecondKey().Eq(StringExpr(key2))), patch)
+}
+
+// ApplyMergePatch applies the JSON Merge Patch document doc (RFC 7396) to the (key1, key2) entry value.
+func (s *DMap) ApplyMergePatch(key1, key2 string, doc string) error {
+	return s.ApplyMergePatchContext(context.Background(), key1, key2, doc)
+}
+
+func (s *DMap) ApplyMergePatchContext(ctx context.Context, key1, key2 string, doc string) error {
+	return s.JsonValueHolder.applyMergePatchContext(ctx, And(FirstKey().Eq(StringExpr(key1)), SecondKey().Eq(StringExpr(key2))), doc)
+}
+
+// RemoveAt removes the value found at path in the (key1, key2) entry value.
+func (s *DMap) RemoveAt(key1, key2 string, path string) error {
+	return s.RemoveAtContext(context.Background(), key1, key2, path)
+}
+
+func (s *DMap) RemoveAtContext(ctx context.Context, key1, key2 string, path string) error {
+	return s.JsonValueHolder.RemoveAtContext(ctx, path, And(FirstKey().Eq(StringExpr(key1)), SecondKey().Eq(StringExpr(key2))))
+}
+
+// AppendAt appends the value of ex to the array found at path in the (key1, key2) entry value.
+func (s *DMap) AppendAt(key1, key2 string, path string, ex Expression) error {
+	return s.AppendAtContext(context.Background(), key1, key2, path, ex)
+}
+
+func (s *DMap) AppendAtContext(ctx context.Context, key1, key2 string, path string, ex Expression) error {
+	return s.JsonValueHolder.AppendAtContext(ctx, path, ex, And(FirstKey().Eq(StringExpr(key1)), SecondKey().Eq(StringExpr(key2))))
+}
+
+// InsertAt inserts the value of ex at index in the array found at path in the (key1, key2) entry value, shifting the following elements.
+func (s *DMap) InsertAt(key1, key2 string, path string, index int, ex Expression) error {
+	return s.InsertAtContext(context.Background(), key1, key2, path, index, ex)
+}
+
+func (s *DMap) InsertAtContext(ctx context.Context, key1, key2 string, path string, index int, ex Expression) error {
+	return s.JsonValueHolder.InsertAtContext(ctx, path, index, ex, And(FirstKey().Eq(StringExpr(key1)), SecondKey().Eq(StringExpr(key2))))
+}
+
+// IncrementAt adds delta to the number found at path in the (key1, key2) entry value. A missing number is set to delta.
+func (s *DMap) IncrementAt(key1, key2 string, path string, delta int64) error {
+	return s.IncrementAtContext(context.Background(), key1, key2, path, delta)
+}
+
+func (s *DMap) IncrementAtContext(ctx context.Context, key1, key2 string, path string, delta int64) error {
+	return s.JsonValueHolder.IncrementAtContext(ctx, path, delta, And(FirstKey().Eq(StringExpr(key1)), SecondKey().Eq(StringExpr(key2))))
+}
+
+// ToggleAt negates the boolean found at path in the (key1, key2) entry value. A missing boolean is set to true.
+func (s *DMap) ToggleAt(key1, key2 string, path string) error {
+	return s.ToggleAtContext(context.Background(), key1, key2, path)
+}
+
+func (s *DMap) ToggleAtContext(ctx context.Context, key1, key2 string, path string) error {
+	return s.JsonValueHolder.ToggleAtContext(ctx, path, And(FirstKey().Eq(StringExpr(key1)), SecondKey().Eq(StringExpr(key2))))
+}
+
+func (s *DMap) String(key1, key2 string, path string) (string, error) {
+	return s.StringContext(context.Background(), key1, key2, path)
+}
+
+func (s *DMap) StringContext(ctx context.Context, key1, key2 string, path string) (string, error) {
+	rawQuery := fmt.Sprintf("select %s from $table$ where first_key=? and second_key=? and %s;", s.dialect.JSONExtract("value", path), notExpired)
+	o, err := s.client(ctx).QueryFirstContext(ctx, rawQuery, StringScanner, key1, key2, nowMillis())
 	if err != nil {
 		return "", err
 	}
-	value := o.(string)
-	if s.dialect == SQLite3 {
-		value = strings.Trim(value, "'")
-	}
-	return value, nil
+	return o.(string), nil
 }
 
 func (s *DMap) Float(key1, key2 string, path string) (float64, error) {
-	var rawQuery string
-
-	if s.dialect == SQLite3 {
-		rawQuery = fmt.Sprintf(
-			"select json_extract(value, '%s') from $table$ where first_key=? and second_key=?;", path)
-	} else {
-		rawQuery = fmt.Sprintf(
-			"select json_unquote(json_extract(value, '%s')) from $table$ where first_key=? and second_key=?;", path)
-	}
+	return s.FloatContext(context.Background(), key1, key2, path)
+}
 
-	o, err := s.Client().QueryFirst(rawQuery, StringScanner, key1, key2)
+func (s *DMap) FloatContext(ctx context.Context, key1, key2 string, path string) (float64, error) {
+	rawQuery := fmt.Sprintf("select %s from $table$ where first_key=? and second_key=? and %s;", s.dialect.JSONExtract("value", path), notExpired)
+	o, err := s.client(ctx).QueryFirstContext(ctx, rawQuery, FloatScanner, key1, key2, nowMillis())
 	if err != nil {
 		return 0., err
 	}
@@ -339,17 +732,12 @@ func (s *DMap) Float(key1, key2 string, path string) (float64, error) {
 }
 
 func (s *DMap) Int(key1, key2 string, path string) (int64, error) {
-	var rawQuery string
-
-	if s.dialect == SQLite3 {
-		rawQuery = fmt.Sprintf(
-			"select json_extract(value, '%s') from $table$ where first_key=? and second_key=?;", path)
-	} else {
-		rawQuery = fmt.Sprintf(
-			"select json_unquote(json_extract(value, '%s')) from $table$ where first_key=? and second_key=?;", path)
-	}
+	return s.IntContext(context.Background(), key1, key2, path)
+}
 
-	o, err := s.Client().QueryFirst(rawQuery, IntScanner, key1, key2)
+func (s *DMap) IntContext(ctx context.Context, key1, key2 string, path string) (int64, error) {
+	rawQuery := fmt.Sprintf("select %s from $table$ where first_key=? and second_key=? and %s;", s.dialect.JSONExtract("value", path), notExpired)
+	o, err := s.client(ctx).QueryFirstContext(ctx, rawQuery, IntScanner, key1, key2, nowMillis())
 	if err != nil {
 		return 0, err
 	}
@@ -357,17 +745,12 @@ func (s *DMap) Int(key1, key2 string, path string) (int64, error) {
 }
 
 func (s *DMap) Bool(key1, key2 string, path string) (bool, error) {
-	var rawQuery string
-
-	if s.dialect == SQLite3 {
-		rawQuery = fmt.Sprintf(
-			"select json_extract(value, '%s') from $table$ where first_key=? and second_key=?;", path)
-	} else {
-		rawQuery = fmt.Sprintf(
-			"select json_unquote(json_extract(value, '%s')) from $table$ where first_key=? and second_key=?;", path)
-	}
+	return s.BoolContext(context.Background(), key1, key2, path)
+}
 
-	o, err := s.Client().QueryFirst(rawQuery, BoolScanner, key1, key2)
+func (s *DMap) BoolContext(ctx context.Context, key1, key2 string, path string) (bool, error) {
+	rawQuery := fmt.Sprintf("select %s from $table$ where first_key=? and second_key=? and %s;", s.dialect.JSONExtract("value", path), notExpired)
+	o, err := s.client(ctx).QueryFirstContext(ctx, rawQuery, BoolScanner, key1, key2, nowMillis())
 	if err != nil {
 		return false, err
 	}
@@ -375,9 +758,19 @@ func (s *DMap) Bool(key1, key2 string, path string) (bool, error) {
 }
 
 func (s *DMap) Clear() error {
-	return s.Client().Exec("delete from $table$;").Error
+	return s.ClearContext(context.Background())
+}
+
+func (s *DMap) ClearContext(ctx context.Context) error {
+	return s.client(ctx).ExecContext(ctx, "delete from $table$;").Error
 }
 
 func (s *DMap) Close() error {
+	s.sweeper.Stop()
 	return s.DB.sqlDb.Close()
 }
+
+// PurgeExpired deletes the expired entries. It returns the number of deleted entries.
+func (s *DMap) PurgeExpired(ctx context.Context) (int64, error) {
+	return purgeExpired(ctx, s.client(ctx), "first_key, second_key", 0)
+}
@@ -1,11 +1,14 @@
 package bome
 
 import (
+	"context"
 	"database/sql"
 	"os"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/omecodes/errors"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -295,6 +298,403 @@ func TestDMap_SearchAtGt(t *testing.T) {
 	})
 }
 
+func TestDMap_TTL(t *testing.T) {
+	Convey("Expired entries are hidden from reads", t, func() {
+		initJsonDoubleDbMap()
+
+		err := dMap.Save("ttl", "expired", `{"ttl": true}`, SaveOptions{TTL: time.Nanosecond})
+		So(err, ShouldBeNil)
+		time.Sleep(time.Millisecond)
+
+		_, err = dMap.ReadRaw("ttl", "expired")
+		So(errors.IsNotFound(err), ShouldBeTrue)
+
+		found, err := dMap.Contains("ttl", "expired")
+		So(err, ShouldBeNil)
+		So(found, ShouldBeFalse)
+
+		count, err := dMap.CountForFirstKey("ttl")
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 0)
+
+		c, err := dMap.Where(JsonAtEq("$.ttl", RawExpr("true")))
+		So(err, ShouldBeNil)
+		So(c.HasNext(), ShouldBeFalse)
+		So(c.Close(), ShouldBeNil)
+
+		purged, err := dMap.PurgeExpired(context.Background())
+		So(err, ShouldBeNil)
+		So(purged, ShouldEqual, 1)
+	})
+
+	Convey("The expiry column is added to tables created without it", t, func() {
+		db, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+
+		_, err = db.Exec("drop table if exists legacy_dmap")
+		So(err, ShouldBeNil)
+
+		_, err = db.Exec("create table legacy_dmap (first_key varchar(255) not null, second_key varchar(255) not null, value json not null)")
+		So(err, ShouldBeNil)
+
+		_, err = db.Exec(`insert into legacy_dmap values ('a', 'b', '"legacy"')`)
+		So(err, ShouldBeNil)
+
+		m, err := Build().SetConn(db).SetDialect(testDialect).SetTableName("legacy_dmap").DMap()
+		So(err, ShouldBeNil)
+
+		value, err := m.ReadRaw("a", "b")
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, `"legacy"`)
+	})
+}
+
+func TestDMap_Version(t *testing.T) {
+	Convey("Conditional writes are guarded by the entry version", t, func() {
+		initJsonDoubleDbMap()
+
+		err := dMap.SaveIfVersion("versions", "a", `{"n": 1}`, 0)
+		So(err, ShouldBeNil)
+
+		err = dMap.Edit("versions", "a", "$.n", IntExpr(2))
+		So(err, ShouldBeNil)
+
+		value, version, err := dMap.ReadRawWithVersion("versions", "a")
+		So(err, ShouldBeNil)
+		So(version, ShouldEqual, 2)
+
+		err = dMap.EditIfVersion("versions", "a", "$.n", IntExpr(3), 1)
+		So(IsVersionConflict(err), ShouldBeTrue)
+
+		err = dMap.CompareAndSwap("versions", "a", `{"n": 1}`, `{"n": 4}`)
+		So(IsVersionConflict(err), ShouldBeTrue)
+
+		err = dMap.CompareAndSwap("versions", "a", value, `{"n": 4}`)
+		So(err, ShouldBeNil)
+
+		err = dMap.SaveIfVersion("versions", "a", `{"n": 5}`, version)
+		So(IsVersionConflict(err), ShouldBeTrue)
+
+		So(dMap.Delete("versions", "a"), ShouldBeNil)
+	})
+}
+
+func TestDMap_SaveMany(t *testing.T) {
+	Convey("SaveMany and DeleteMany write pairs of keys", t, func() {
+		initJsonDoubleDbMap()
+
+		result := dMap.SaveMany([]*DoubleMapEntry{
+			{FirstKey: "batch", SecondKey: "a", Value: `"a"`},
+			{FirstKey: "batch", SecondKey: "b", Value: `"b"`},
+		}, SaveOptions{OnlyIfAbsent: true})
+		So(result.Error, ShouldBeNil)
+		So(result.Inserted(), ShouldEqual, 2)
+
+		result = dMap.SaveMany([]*DoubleMapEntry{
+			{FirstKey: "batch", SecondKey: "b", Value: `"b"`},
+			{FirstKey: "batch", SecondKey: "b", Value: `"c"`},
+		}, SaveOptions{})
+		So(result.Error, ShouldNotBeNil)
+		So(result.FailedKey, ShouldResemble, DoubleMapKey{FirstKey: "batch", SecondKey: "b"})
+
+		result = dMap.DeleteMany([]DoubleMapKey{{FirstKey: "batch", SecondKey: "a"}, {FirstKey: "batch", SecondKey: "b"}})
+		So(result.Error, ShouldBeNil)
+		So(result.AffectedRows(), ShouldEqual, 2)
+	})
+}
+
+func TestDMap_ReadMany(t *testing.T) {
+	Convey("ReadMany reads pairs of keys", t, func() {
+		initJsonDoubleDbMap()
+
+		So(dMap.Save("many", "a", `"a"`, SaveOptions{}), ShouldBeNil)
+		So(dMap.Save("many", "b", `"b"`, SaveOptions{}), ShouldBeNil)
+
+		result, err := dMap.ReadMany([]DoubleMapKey{
+			{FirstKey: "many", SecondKey: "b"},
+			{FirstKey: "many", SecondKey: "c"},
+			{FirstKey: "many", SecondKey: "a"},
+		})
+		So(err, ShouldBeNil)
+		So(result.Missing, ShouldResemble, []DoubleMapKey{{FirstKey: "many", SecondKey: "c"}})
+		So(result.Ordered(), ShouldResemble, []string{`"b"`, `"a"`})
+
+		So(dMap.DeleteMany([]DoubleMapKey{{FirstKey: "many", SecondKey: "a"}, {FirstKey: "many", SecondKey: "b"}}).Error, ShouldBeNil)
+	})
+}
+
+func TestDMap_WhereQueryOptions(t *testing.T) {
+	Convey("Where sorts and projects entries", t, func() {
+		initJsonDoubleDbMap()
+
+		result := dMap.SaveMany([]*DoubleMapEntry{
+			{FirstKey: "sorted", SecondKey: "1", Value: `{"group": "sorted", "name": "b", "age": 10, "address": {"city": "x"}}`},
+			{FirstKey: "sorted", SecondKey: "2", Value: `{"group": "sorted", "name": "c", "age": 9}`},
+			{FirstKey: "sorted", SecondKey: "3", Value: `{"group": "sorted", "name": "a", "age": 10}`},
+			{FirstKey: "sorted", SecondKey: "4", Value: `{"group": "sorted", "name": "d", "age": 100}`},
+		}, SaveOptions{})
+		So(result.Error, ShouldBeNil)
+
+		read := func(opts QueryOptions) []string {
+			c, err := dMap.Where(JsonAtEq("$.group", StringExpr("sorted")), opts)
+			So(err, ShouldBeNil)
+			defer func() {
+				_ = c.Close()
+			}()
+
+			var values []string
+			for c.HasNext() {
+				o, err := c.Entry()
+				So(err, ShouldBeNil)
+				values = append(values, o.(*DoubleMapEntry).Value)
+			}
+			return values
+		}
+
+		values := read(QueryOptions{
+			Sort:   []SortKey{OrderByNumber("$.age", Desc), OrderBy("$.name", Asc)},
+			Fields: []string{"$.name", "$.address"},
+		})
+		So(values, ShouldResemble, []string{
+			`{"name":"d","address":null}`,
+			`{"name":"a","address":null}`,
+			`{"name":"b","address":{"city":"x"}}`,
+			`{"name":"c","address":null}`,
+		})
+
+		values = read(QueryOptions{Sort: []SortKey{OrderBy("$.age", Asc), OrderBy("$.name", Desc)}, Fields: []string{"$.name"}})
+		So(values, ShouldResemble, []string{`{"name":"b"}`, `{"name":"a"}`, `{"name":"d"}`, `{"name":"c"}`})
+
+		So(dMap.DeleteMany([]DoubleMapKey{
+			{FirstKey: "sorted", SecondKey: "1"},
+			{FirstKey: "sorted", SecondKey: "2"},
+			{FirstKey: "sorted", SecondKey: "3"},
+			{FirstKey: "sorted", SecondKey: "4"},
+		}).Error, ShouldBeNil)
+	})
+}
+
+func TestDMap_Aggregate(t *testing.T) {
+	Convey("Aggregate computes statistics grouped by JSON paths", t, func() {
+		initJsonDoubleDbMap()
+
+		result := dMap.SaveMany([]*DoubleMapEntry{
+			{FirstKey: "stats", SecondKey: "1", Value: `{"group": "stats", "age": 20, "address": {"city": "lome"}}`},
+			{FirstKey: "stats", SecondKey: "2", Value: `{"group": "stats", "age": 30, "address": {"city": "lome"}}`},
+			{FirstKey: "stats", SecondKey: "3", Value: `{"group": "stats", "age": 30, "address": {"city": "accra"}}`},
+			{FirstKey: "stats", SecondKey: "4", Value: `{"group": "stats", "age": 42}`},
+		}, SaveOptions{})
+		So(result.Error, ShouldBeNil)
+
+		rows, err := dMap.Aggregate(Avg("$.age").As("avg"), Max("$.age"), CountAll()).
+			Where(JsonAtEq("$.group", StringExpr("stats"))).
+			GroupBy("$.address.city").
+			Rows()
+		So(err, ShouldBeNil)
+		So(rows, ShouldHaveLength, 3)
+
+		So(rows[0].Group, ShouldResemble, []string{""})
+		So(rows[0].Value("avg"), ShouldEqual, 42)
+		So(rows[1].Group, ShouldResemble, []string{"accra"})
+		So(rows[2].Group, ShouldResemble, []string{"lome"})
+		So(rows[2].Value("avg"), ShouldEqual, 25)
+		So(rows[2].Value("max($.age)"), ShouldEqual, 30)
+		So(rows[2].Value("count(*)"), ShouldEqual, 2)
+
+		rows, err = dMap.Aggregate(Sum("$.age"), Min("$.age"), CountDistinct("$.age")).
+			Where(JsonAtEq("$.group", StringExpr("stats"))).
+			Rows()
+		So(err, ShouldBeNil)
+		So(rows, ShouldHaveLength, 1)
+		So(rows[0].Values, ShouldResemble, []float64{122, 20, 3})
+
+		So(dMap.DeleteMany([]DoubleMapKey{
+			{FirstKey: "stats", SecondKey: "1"},
+			{FirstKey: "stats", SecondKey: "2"},
+			{FirstKey: "stats", SecondKey: "3"},
+			{FirstKey: "stats", SecondKey: "4"},
+		}).Error, ShouldBeNil)
+	})
+}
+
+func TestDMap_WhereMembership(t *testing.T) {
+	Convey("Where filters with sets, ranges, nulls and arrays", t, func() {
+		initJsonDoubleDbMap()
+
+		result := dMap.SaveMany([]*DoubleMapEntry{
+			{FirstKey: "members", SecondKey: "1", Value: `{"group": "members", "age": 20, "tags": ["go", "sql"], "city": "lome"}`},
+			{FirstKey: "members", SecondKey: "2", Value: `{"group": "members", "age": 35, "tags": ["js"], "city": null}`},
+			{FirstKey: "members", SecondKey: "3", Value: `{"group": "members", "age": 50, "tags": []}`},
+		}, SaveOptions{})
+		So(result.Error, ShouldBeNil)
+
+		keys := func(condition BoolExpr) []string {
+			c, err := dMap.Where(And(JsonAtEq("$.group", StringExpr("members")), condition), QueryOptions{Sort: []SortKey{OrderByNumber("$.age", Asc)}})
+			So(err, ShouldBeNil)
+			defer func() {
+				_ = c.Close()
+			}()
+
+			var keys []string
+			for c.HasNext() {
+				o, err := c.Entry()
+				So(err, ShouldBeNil)
+				keys = append(keys, o.(*DoubleMapEntry).SecondKey)
+			}
+			return keys
+		}
+
+		So(keys(JsonAtIn("$.age", IntExpr(20), IntExpr(50))), ShouldResemble, []string{"1", "3"})
+		So(keys(JsonAtBetween("$.age", IntExpr(30), IntExpr(50))), ShouldResemble, []string{"2", "3"})
+		So(keys(JsonAtIsNull("$.city")), ShouldResemble, []string{"2", "3"})
+		So(keys(JsonAtIsNotNull("$.city")), ShouldResemble, []string{"1"})
+		So(keys(JsonAtNe("$.age", IntExpr(35))), ShouldResemble, []string{"1", "3"})
+		So(keys(JsonArrayContains("$.tags", StringExpr("sql"))), ShouldResemble, []string{"1"})
+		So(keys(JsonArrayLength("$.tags", OpLt, 1)), ShouldResemble, []string{"3"})
+
+		So(dMap.DeleteMany([]DoubleMapKey{
+			{FirstKey: "members", SecondKey: "1"},
+			{FirstKey: "members", SecondKey: "2"},
+			{FirstKey: "members", SecondKey: "3"},
+		}).Error, ShouldBeNil)
+	})
+}
+
+func TestDMap_KeyConditions(t *testing.T) {
+	Convey("Conditions on key columns", t, func() {
+		initJsonDoubleDbMap()
+
+		result := dMap.SaveMany([]*DoubleMapEntry{
+			{FirstKey: "cols-a", SecondKey: "1", Value: `{}`},
+			{FirstKey: "cols-a", SecondKey: "2", Value: `{}`},
+			{FirstKey: "cols-b", SecondKey: "1", Value: `{}`},
+		}, SaveOptions{})
+		So(result.Error, ShouldBeNil)
+
+		count := func(condition BoolExpr) int {
+			c, err := dMap.Where(condition)
+			So(err, ShouldBeNil)
+			defer func() {
+				_ = c.Close()
+			}()
+
+			n := 0
+			for c.HasNext() {
+				n++
+			}
+			return n
+		}
+
+		So(count(FirstKey().StartsWith(StringExpr("cols-"))), ShouldEqual, 3)
+		So(count(And(FirstKey().StartsWith(StringExpr("cols-")), SecondKey().In(StringExpr("2")))), ShouldEqual, 1)
+
+		_, err := dMap.Where(IndexColumn().Gt(IntExpr(1)))
+		So(err, ShouldResemble, &UnknownColumnError{Column: "ind"})
+
+		err = dMap.DeleteByFirstKey("cols-a", SecondKey().Gte(StringExpr("2")))
+		So(err, ShouldBeNil)
+		So(count(FirstKey().StartsWith(StringExpr("cols-"))), ShouldEqual, 2)
+
+		So(dMap.DeleteMany([]DoubleMapKey{{FirstKey: "cols-a", SecondKey: "1"}, {FirstKey: "cols-b", SecondKey: "1"}}).Error, ShouldBeNil)
+
+		for _, key := range []DoubleMapKey{{FirstKey: "users", SecondKey: "x"}, {FirstKey: "users", SecondKey: "y"}, {FirstKey: "admins", SecondKey: "y"}} {
+			So(dMap.Save(key.FirstKey, key.SecondKey, `"v"`, SaveOptions{}), ShouldBeNil)
+		}
+
+		err = dMap.DeleteByDeleteAllBySecondKey("x", Or(FirstKey().Eq(StringExpr("users")), FirstKey().Eq(StringExpr("admins"))))
+		So(err, ShouldBeNil)
+		So(count(Or(FirstKey().Eq(StringExpr("users")), FirstKey().Eq(StringExpr("admins")))), ShouldEqual, 2)
+
+		err = dMap.DeleteByFirstKey("users", Or(SecondKey().Eq(StringExpr("x")), SecondKey().Eq(StringExpr("y"))))
+		So(err, ShouldBeNil)
+		So(count(Or(FirstKey().Eq(StringExpr("users")), FirstKey().Eq(StringExpr("admins")))), ShouldEqual, 1)
+
+		value, err := dMap.ReadRaw("admins", "y")
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, `"v"`)
+
+		So(dMap.Delete("admins", "y"), ShouldBeNil)
+	})
+}
+
+func TestDMap_ApplyMergePatch(t *testing.T) {
+	Convey("Merge patches are applied to double map values", t, func() {
+		initJsonDoubleDbMap()
+
+		So(dMap.Save("patched", "entry", `{"a": 1, "b": {"c": true}}`, SaveOptions{}), ShouldBeNil)
+
+		So(dMap.ApplyMergePatch("patched", "entry", `{"a": null, "b": {"d": [1, 2]}}`), ShouldBeNil)
+		So(dMap.ApplyPatch("patched", "entry", `[{"op": "test", "path": "/b/c", "value": true}, {"op": "add", "path": "/e", "value": null}]`), ShouldBeNil)
+
+		raw, err := dMap.ReadRaw("patched", "entry")
+		So(err, ShouldBeNil)
+		So(sameJSON(raw, `{"b": {"c": true, "d": [1, 2]}, "e": null}`), ShouldBeTrue)
+
+		So(dMap.Delete("patched", "entry"), ShouldBeNil)
+	})
+}
+
+func TestDMap_BulkArrayEdits(t *testing.T) {
+	Convey("Array edits apply to all the entries matching a condition", t, func() {
+		initJsonDoubleDbMap()
+
+		So(dMap.Save("edited", "a", `{"tags": [], "n": 1}`, SaveOptions{}), ShouldBeNil)
+		So(dMap.Save("edited", "b", `{"tags": ["x"], "n": 2}`, SaveOptions{}), ShouldBeNil)
+		So(dMap.Save("other", "c", `{"tags": [], "n": 3}`, SaveOptions{}), ShouldBeNil)
+
+		edited := FirstKey().Eq(StringExpr("edited"))
+		So(dMap.JsonValueHolder.AppendAt("$.tags", StringExpr("y"), edited), ShouldBeNil)
+		So(dMap.JsonValueHolder.InsertAt("$.tags", 0, IntExpr(0), edited), ShouldBeNil)
+		So(dMap.JsonValueHolder.IncrementAt("$.n", -1, edited), ShouldBeNil)
+		So(dMap.JsonValueHolder.ToggleAt("$.flag", edited), ShouldBeNil)
+		So(dMap.JsonValueHolder.RemoveAt("$.flag", And(edited, SecondKey().Eq(StringExpr("b")))), ShouldBeNil)
+
+		raw, err := dMap.ReadRaw("edited", "a")
+		So(err, ShouldBeNil)
+		So(sameJSON(raw, `{"tags": [0, "y"], "n": 0, "flag": true}`), ShouldBeTrue)
+
+		raw, err = dMap.ReadRaw("edited", "b")
+		So(err, ShouldBeNil)
+		So(sameJSON(raw, `{"tags": [0, "x", "y"], "n": 1}`), ShouldBeTrue)
+
+		raw, err = dMap.ReadRaw("other", "c")
+		So(err, ShouldBeNil)
+		So(sameJSON(raw, `{"tags": [], "n": 3}`), ShouldBeTrue)
+
+		So(dMap.DeleteAllByFirstKey("edited"), ShouldBeNil)
+		So(dMap.Delete("other", "c"), ShouldBeNil)
+	})
+}
+
+func TestDMap_UniqueKeys(t *testing.T) {
+	Convey("Each table gets its own unique index on the pair of keys", t, func() {
+		conn, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+
+		for _, table := range []string{"jd_keys_a", "jd_keys_b"} {
+			_, err = conn.Exec("drop table if exists " + table)
+			So(err, ShouldBeNil)
+
+			m, err := Build().SetConn(conn).SetDialect(testDialect).SetTableName(table).DMap()
+			So(err, ShouldBeNil)
+
+			So(m.Put("k1", "k2", `"a"`, SaveOptions{UpdateExisting: true}).Error, ShouldBeNil)
+			So(m.Put("k1", "k2", `"b"`, SaveOptions{UpdateExisting: true}).Error, ShouldBeNil)
+			So(m.Save("k1", "k2", `"c"`, SaveOptions{}), ShouldNotBeNil)
+
+			count, err := m.Count()
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+
+			value, err := m.ReadRaw("k1", "k2")
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, `"b"`)
+		}
+
+		So(conn.Close(), ShouldBeNil)
+	})
+}
+
 func TestDMap_Clear(t *testing.T) {
 	Convey("Clear all entries", t, func() {
 		// initJsonDoubleDbMap()
@@ -1,5 +1,7 @@
 package bome
 
+import "database/sql"
+
 // ListEntry is the list entry definition.
 type ListEntry struct {
 	Index int64
@@ -19,6 +21,12 @@ type DoubleMapEntry struct {
 	Value     string
 }
 
+// DoubleMapKey is the pair of keys of a double map entry.
+type DoubleMapKey struct {
+	FirstKey  string
+	SecondKey string
+}
+
 // PairListEntry is the pairs list entry definition.
 type PairListEntry struct {
 	Index int64
@@ -26,6 +34,22 @@ type PairListEntry struct {
 	Value string
 }
 
+// VersionedValue is a value with the version of the entry that holds it. The version of an entry is 1
+// when it is created and is incremented every time it is written.
+type VersionedValue struct {
+	Value   string
+	Version int64
+}
+
+// QueueItem is a queue item definition. Lease is the token of the Dequeue call that leased the item.
+type QueueItem struct {
+	ID       int64
+	Value    string
+	Priority int64
+	Attempts int64
+	Lease    string
+}
+
 func scanListEntry(row Row) (interface{}, error) {
 	var r ListEntry
 	err := row.Scan(&r.Index, &r.Value)
@@ -46,3 +70,27 @@ func scanPairListEntry(row Row) (interface{}, error) {
 	entry := new(PairListEntry)
 	return entry, row.Scan(&entry.Index, &entry.Key, &entry.Value)
 }
+
+func scanVersionedValue(row Row) (interface{}, error) {
+	v := new(VersionedValue)
+	return v, row.Scan(&v.Value, &v.Version)
+}
+
+// versionedListEntry is a list entry with its version.
+type versionedListEntry struct {
+	ListEntry
+	version int64
+}
+
+func scanVersionedListEntry(row Row) (interface{}, error) {
+	entry := new(versionedListEntry)
+	return entry, row.Scan(&entry.Index, &entry.Value, &entry.version)
+}
+
+func scanQueueItem(row Row) (interface{}, error) {
+	var lease sql.NullString
+	item := new(QueueItem)
+	err := row.Scan(&item.ID, &item.Value, &item.Priority, &item.Attempts, &lease)
+	item.Lease = lease.String
+	return item, err
+}
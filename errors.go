@@ -1,10 +1,16 @@
 package bome
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/go-sql-driver/mysql"
 	"github.com/mattn/go-sqlite3"
 )
 
+// ErrInvalidPageToken is returned when a page is requested with a token that was not returned by a previous page.
+var ErrInvalidPageToken = errors.New("bome: invalid page token")
+
 func isPrimaryKeyConstraintError(err error) bool {
 	if err == nil {
 		return false
@@ -14,5 +20,87 @@ func isPrimaryKeyConstraintError(err error) bool {
 	} else if se, ok := err.(sqlite3.Error); ok {
 		return se.ExtendedCode == 2067 || se.ExtendedCode == 1555
 	}
+
+	// PostgreSQL drivers (lib/pq, pgx) expose the SQLSTATE code of the error.
+	// 23505 is the unique_violation code raised by inserts that conflict with a unique constraint.
+	var pe interface{ SQLState() string }
+	if errors.As(err, &pe) {
+		return pe.SQLState() == "23505"
+	}
 	return false
 }
+
+// MigrationChecksumError is returned when an applied migration script has been modified since it was applied.
+type MigrationChecksumError struct {
+	Version int64
+	Name    string
+}
+
+func (e *MigrationChecksumError) Error() string {
+	return fmt.Sprintf("bome: migration %d (%s) was modified after it was applied", e.Version, e.Name)
+}
+
+// VersionConflictError is returned when a conditional write finds an entry which version is not the expected one,
+// or which value is not the expected one for compare-and-swap operations.
+type VersionConflictError struct {
+	Expected int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("bome: entry was modified, expected version %d", e.Expected)
+}
+
+// IsVersionConflict tells if err is a VersionConflictError.
+func IsVersionConflict(err error) bool {
+	var ce *VersionConflictError
+	return errors.As(err, &ce)
+}
+
+// IndexUniquenessError is returned by DB.EnsureIndex when it would replace a unique index with a non-unique one,
+// which would stop rejecting duplicate rows. The unique index must be dropped explicitly.
+type IndexUniquenessError struct {
+	Name string
+}
+
+func (e *IndexUniquenessError) Error() string {
+	return fmt.Sprintf("bome: index %s is unique and cannot be replaced by a non-unique index", e.Name)
+}
+
+// IsIndexUniquenessError tells if err is an IndexUniquenessError.
+func IsIndexUniquenessError(err error) bool {
+	var ue *IndexUniquenessError
+	return errors.As(err, &ue)
+}
+
+// UnknownColumnError is returned when a condition refers to a column that is not a key column of the queried collection.
+type UnknownColumnError struct {
+	Column string
+}
+
+func (e *UnknownColumnError) Error() string {
+	return fmt.Sprintf("bome: unknown key column %q", e.Column)
+}
+
+// IsUnknownColumn tells if err is an UnknownColumnError.
+func IsUnknownColumn(err error) bool {
+	var ce *UnknownColumnError
+	return errors.As(err, &ce)
+}
+
+// PatchError is returned when an operation of a JSON Patch cannot be applied, including when a test operation fails.
+// None of the operations of the patch are applied in that case.
+type PatchError struct {
+	Op     string
+	Path   string
+	Reason string
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("bome: cannot apply patch operation %q at %q: %s", e.Op, e.Path, e.Reason)
+}
+
+// IsPatchError tells if err is a PatchError.
+func IsPatchError(err error) bool {
+	var pe *PatchError
+	return errors.As(err, &pe)
+}
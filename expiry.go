@@ -0,0 +1,123 @@
+package bome
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// notExpired is the condition that excludes the expired entries of Map and DMap tables.
+// It is bound to the current time returned by nowMillis.
+const notExpired = "(expires_at is null or expires_at>?)"
+
+// nowMillis returns the current time as stored in expiry columns.
+func nowMillis() int64 {
+	return time.Now().UnixMilli()
+}
+
+// expiresAt returns the expiry time of a value saved with opts in milliseconds, or nil if the value never expires.
+func (opts SaveOptions) expiresAt() interface{} {
+	if !opts.ExpiresAt.IsZero() {
+		return opts.ExpiresAt.UnixMilli()
+	}
+	if opts.TTL > 0 {
+		return time.Now().Add(opts.TTL).UnixMilli()
+	}
+	return nil
+}
+
+// purgeExpired deletes the expired entries of the table which primary key is made of keys.
+// Entries are deleted by batches of batchSize rows, all at once when batchSize is 0.
+func purgeExpired(ctx context.Context, client Client, keys string, batchSize int) (int64, error) {
+	if batchSize <= 0 {
+		result := client.ExecContext(ctx, "delete from $table$ where expires_at<=?;", nowMillis())
+		return result.AffectedRows, result.Error
+	}
+
+	rawQuery := fmt.Sprintf(
+		"delete from $table$ where (%s) in (select %s from (select %s from $table$ where expires_at<=? limit ?) as expired);",
+		keys, keys, keys,
+	)
+
+	var total int64
+	for {
+		result := client.ExecContext(ctx, rawQuery, nowMillis(), batchSize)
+		if result.Error != nil {
+			return total, result.Error
+		}
+
+		total += result.AffectedRows
+		if result.AffectedRows < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
+// purgeExpiredStatement returns the statement that deletes the expired rows among the rows which keys are bound to its arguments.
+// The current time is inlined so that chunks can use all the placeholders for keys.
+func purgeExpiredStatement(keys []string) *statement {
+	now := strconv.FormatInt(nowMillis(), 10)
+	return &statement{
+		width: len(keys),
+		query: func(n int) string {
+			return "delete from $table$ where " + inKeys(keys, n) + " and expires_at<=" + now + ";"
+		},
+		exec: func(ctx context.Context, client Client, rawQuery string, _ int, args []interface{}) Result {
+			return client.ExecContext(ctx, rawQuery, args...)
+		},
+	}
+}
+
+// expirySweeper starts the sweeper configured by opts for the table which primary key is made of keys.
+// It returns nil if no sweeper is configured.
+func expirySweeper(db *DB, keys string, opts ...Option) *sweeper {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.sweepInterval <= 0 {
+		return nil
+	}
+	return startSweeper(options.sweepInterval, options.sweepBatchSize, func(ctx context.Context, batchSize int) (int64, error) {
+		return purgeExpired(ctx, db, keys, batchSize)
+	})
+}
+
+// sweeper periodically purges expired entries in background.
+type sweeper struct {
+	stop chan struct{}
+	once sync.Once
+}
+
+func startSweeper(interval time.Duration, batchSize int, purge func(ctx context.Context, batchSize int) (int64, error)) *sweeper {
+	s := &sweeper{stop: make(chan struct{})}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				if _, err := purge(context.Background(), batchSize); err != nil {
+					log.Println(err)
+				}
+			}
+		}
+	}()
+	return s
+}
+
+// Stop stops the sweeper. It can be called several times.
+func (s *sweeper) Stop() {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		close(s.stop)
+	})
+}
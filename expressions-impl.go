@@ -1,23 +1,133 @@
 package bome
 
 import (
-	"log"
+	"fmt"
 	"strings"
 )
 
+// isNumericExpression tells if e evaluates to a number.
+func isNumericExpression(e Expression) bool {
+	switch v := e.(type) {
+	case *intExpression, *floatExpression, *arithmeticExpression, *nowExpression:
+		return true
+	case *coalesceExpression:
+		return v.numeric()
+	default:
+		return false
+	}
+}
+
+// valueOperand returns the value column as it must be compared with e.
+func valueOperand(dialect Dialect, e Expression) string {
+	operand := dialect.JSONScalar("value")
+	if isNumericExpression(e) {
+		return dialect.CastNumeric(operand)
+	}
+	return operand
+}
+
+// jsonAtOperand returns the value found at path as it must be compared with e.
+func jsonAtOperand(dialect Dialect, path string, e Expression) string {
+	operand := dialect.JSONExtract("value", path)
+	if isNumericExpression(e) {
+		return dialect.CastNumeric(operand)
+	}
+	return operand
+}
+
+// compareSQL renders the comparison of operand with e.
+func compareSQL(dialect Dialect, operand string, op string, e Expression) (string, []interface{}) {
+	e.setDialect(dialect)
+	value, args := e.eval()
+	return "(" + operand + " " + op + " " + value + ")", args
+}
+
+// likeSQL renders the like comparison of operand with the value of e surrounded with before and after.
+func likeSQL(dialect Dialect, operand string, e Expression, before, after string) (string, []interface{}) {
+	var pattern string
+	switch v := e.(type) {
+	case *stringExpression:
+		pattern = v.value
+	case *intExpression:
+		pattern = fmt.Sprintf("%d", v.value)
+	default:
+		e.setDialect(dialect)
+		value, args := e.eval()
+		var parts []string
+		if before != "" {
+			parts = append(parts, dialect.QuoteString(before))
+		}
+		parts = append(parts, value)
+		if after != "" {
+			parts = append(parts, dialect.QuoteString(after))
+		}
+		return "(" + operand + " like " + dialect.Concat(parts...) + ")", args
+	}
+	return "(" + operand + " like " + dialect.Placeholder(TextValue) + ")", []interface{}{before + pattern + after}
+}
+
+// inSQL renders the condition that tells if operand is one of values. It is false when there is no value.
+func inSQL(dialect Dialect, operand string, values []Expression) (string, []interface{}) {
+	if len(values) == 0 {
+		return "(" + dialect.Bool(false) + ")", nil
+	}
+
+	var (
+		placeholders []string
+		args         []interface{}
+		numeric      bool
+	)
+	for _, e := range values {
+		e.setDialect(dialect)
+		value, valueArgs := e.eval()
+		placeholders = append(placeholders, value)
+		args = append(args, valueArgs...)
+		numeric = numeric || isNumericExpression(e)
+	}
+
+	if numeric {
+		operand = dialect.CastNumeric(operand)
+	}
+	return "(" + operand + " in (" + strings.Join(placeholders, ", ") + "))", args
+}
+
+// betweenSQL renders the condition that tells if operand is between low and high, inclusive.
+func betweenSQL(dialect Dialect, operand string, low, high Expression) (string, []interface{}) {
+	low.setDialect(dialect)
+	high.setDialect(dialect)
+	lowValue, args := low.eval()
+	highValue, highArgs := high.eval()
+	return "(" + operand + " between " + lowValue + " and " + highValue + ")", append(args, highArgs...)
+}
+
 type funcCond struct {
 	op       string
 	operands []BoolExpr
 	dialectValue
 }
 
-func (fc *funcCond) sql() string {
-	var sqls []string
+func (fc *funcCond) columns() []string {
+	var columns []string
+	for _, cond := range fc.operands {
+		if ce, ok := cond.(columnsExpr); ok {
+			columns = append(columns, ce.columns()...)
+		}
+	}
+	return columns
+}
+
+func (fc *funcCond) sql() (string, []interface{}) {
+	var (
+		sqls []string
+		args []interface{}
+	)
 	for _, cond := range fc.operands {
 		cond.setDialect(fc.dialect)
-		sqls = append(sqls, "("+cond.sql()+")")
+		condition, conditionArgs := cond.sql()
+		sqls = append(sqls, "("+condition+")")
+		args = append(args, conditionArgs...)
 	}
-	return strings.Join(sqls, " "+fc.op+" ")
+	return strings.Join(sqls, " "+fc.op+" "), args
 }
 
 type contains struct {
@@ -25,14 +135,17 @@ type contains struct {
 	dialectValue
 }
 
-func (c *contains) sql() string {
-	c.e.setDialect(c.dialect)
-	expr := c.e.eval()
-	expr = expr[1:]
-	expr = expr[:len(expr)-1]
-	expr = "'%" + expr + "%'"
+func (c *contains) sql() (string, []interface{}) {
+	return likeSQL(c.getDialect(), valueOperand(c.getDialect(), c.e), c.e, "%", "%")
+}
+
+type in struct {
+	values []Expression
+	dialectValue
+}
 
-	return "(value like " + expr + ")"
+func (c *in) sql() (string, []interface{}) {
+	return inSQL(c.getDialect(), c.getDialect().JSONScalar("value"), c.values)
 }
 
 type startsWith struct {
@@ -40,12 +153,8 @@ type startsWith struct {
 	dialectValue
 }
 
-func (s *startsWith) sql() string {
-	s.e.setDialect(s.dialect)
-	expr := s.e.eval()
-	expr = strings.TrimSuffix(expr, "'")
-	expr = expr + "%'"
-	return "(value like " + expr + ")"
+func (s *startsWith) sql() (string, []interface{}) {
+	return likeSQL(s.getDialect(), valueOperand(s.getDialect(), s.e), s.e, "", "%")
 }
 
 type endsWith struct {
@@ -53,11 +162,8 @@ type endsWith struct {
 	dialectValue
 }
 
-func (e *endsWith) sql() string {
-	e.e.setDialect(e.dialect)
-	expr := e.e.eval()
-	expr = "'%" + expr[1:]
-	return "(value like " + expr + ")"
+func (e *endsWith) sql() (string, []interface{}) {
+	return likeSQL(e.getDialect(), valueOperand(e.getDialect(), e.e), e.e, "%", "")
 }
 
 type jsonContainsPath struct {
@@ -65,11 +171,8 @@ type jsonContainsPath struct {
 	dialectValue
 }
 
-func (c *jsonContainsPath) sql() string {
-	if c.dialect == SQLite3 {
-		return "(json_quote(json_extract(value, '" + c.path + "'))!='null')"
-	}
-	return "(json_contains_path(value, 'one',  '" + c.path + "'))"
+func (c *jsonContainsPath) sql() (string, []interface{}) {
+	return c.getDialect().JSONContainsPath("value", c.path), nil
 }
 
 type jsonAtEquals struct {
@@ -78,26 +181,8 @@ type jsonAtEquals struct {
 	dialectValue
 }
 
-func (c *jsonAtEquals) sql() string {
-	c.e.setDialect(c.dialect)
-	expr := c.e.eval()
-	expr = expr[1:]
-	expr = expr[:len(expr)-1]
-	expr = "'" + expr + "'"
-
-	builder := strings.Builder{}
-	if c.dialect == SQLite3 {
-		builder.WriteString("(json_extract(value,'")
-		builder.WriteString(c.path)
-		builder.WriteString("') = ")
-	} else {
-		builder.WriteString("(json_unquote(json_extract(value,'")
-		builder.WriteString(c.path)
-		builder.WriteString("')) = ")
-	}
-	builder.WriteString(expr)
-	builder.WriteString(")")
-	return builder.String()
+func (c *jsonAtEquals) sql() (string, []interface{}) {
+	return compareSQL(c.getDialect(), jsonAtOperand(c.getDialect(), c.path, c.e), "=", c.e)
 }
 
 type jsonAtContains struct {
@@ -106,27 +191,8 @@ type jsonAtContains struct {
 	dialectValue
 }
 
-func (c *jsonAtContains) sql() string {
-	c.e.setDialect(c.dialect)
-	expr := c.e.eval()
-	expr = expr[1:]
-	expr = expr[:len(expr)-1]
-	expr = "'%" + expr + "%'"
-
-	builder := strings.Builder{}
-	if c.dialect == SQLite3 {
-		builder.WriteString("(json_extract(value,'")
-		builder.WriteString(c.path)
-		builder.WriteString("') like ")
-	} else {
-		builder.WriteString("(json_unquote(json_extract(value,'")
-		builder.WriteString(c.path)
-		builder.WriteString("')) like ")
-	}
-
-	builder.WriteString(expr)
-	builder.WriteString(")")
-	return builder.String()
+func (c *jsonAtContains) sql() (string, []interface{}) {
+	return likeSQL(c.getDialect(), c.getDialect().JSONExtract("value", c.path), c.e, "%", "%")
 }
 
 type jsonAtStartWith struct {
@@ -135,25 +201,8 @@ type jsonAtStartWith struct {
 	dialectValue
 }
 
-func (s *jsonAtStartWith) sql() string {
-	s.e.setDialect(s.dialect)
-	expr := s.e.eval()
-	expr = expr[:len(expr)-1]
-	expr = expr + "%'"
-
-	builder := strings.Builder{}
-	if s.dialect == SQLite3 {
-		builder.WriteString("(json_extract(value,'")
-		builder.WriteString(s.path)
-		builder.WriteString("') like ")
-	} else {
-		builder.WriteString("(json_unquote(json_extract(value,'")
-		builder.WriteString(s.path)
-		builder.WriteString("')) like ")
-	}
-	builder.WriteString(expr)
-	builder.WriteString(")")
-	return builder.String()
+func (s *jsonAtStartWith) sql() (string, []interface{}) {
+	return likeSQL(s.getDialect(), s.getDialect().JSONExtract("value", s.path), s.e, "", "%")
 }
 
 type jsonAtEndsWith struct {
@@ -162,24 +211,8 @@ type jsonAtEndsWith struct {
 	dialectValue
 }
 
-func (e *jsonAtEndsWith) sql() string {
-	e.e.setDialect(e.dialect)
-	expr := e.e.eval()
-	expr = "'%" + expr[1:]
-
-	builder := strings.Builder{}
-	if e.dialect == SQLite3 {
-		builder.WriteString("(json_extract(value,'")
-		builder.WriteString(e.path)
-		builder.WriteString("') like ")
-	} else {
-		builder.WriteString("(json_unquote(json_extract(value,'")
-		builder.WriteString(e.path)
-		builder.WriteString("')) like ")
-	}
-	builder.WriteString(expr)
-	builder.WriteString(")")
-	return builder.String()
+func (e *jsonAtEndsWith) sql() (string, []interface{}) {
+	return likeSQL(e.getDialect(), e.getDialect().JSONExtract("value", e.path), e.e, "%", "")
 }
 
 type jsonAtLt struct {
@@ -188,24 +221,8 @@ type jsonAtLt struct {
 	dialectValue
 }
 
-func (c *jsonAtLt) sql() string {
-	c.e.setDialect(c.dialect)
-	expr := c.e.eval()
-	builder := strings.Builder{}
-	if c.dialect == SQLite3 {
-		builder.WriteString("(json_extract(value,'")
-		builder.WriteString(c.path)
-		builder.WriteString("')<")
-	} else {
-		builder.WriteString("(json_unquote(json_extract(value,'")
-		builder.WriteString(c.path)
-		builder.WriteString("'))<")
-	}
-	builder.WriteString(expr)
-	builder.WriteString(")")
-
-	log.Println(builder.String())
-	return builder.String()
+func (c *jsonAtLt) sql() (string, []interface{}) {
+	return compareSQL(c.getDialect(), jsonAtOperand(c.getDialect(), c.path, c.e), "<", c.e)
 }
 
 type jsonAtLe struct {
@@ -214,22 +231,8 @@ type jsonAtLe struct {
 	dialectValue
 }
 
-func (c *jsonAtLe) sql() string {
-	c.e.setDialect(c.dialect)
-	expr := c.e.eval()
-	builder := strings.Builder{}
-	if c.dialect == SQLite3 {
-		builder.WriteString("(json_extract(value,'")
-		builder.WriteString(c.path)
-		builder.WriteString("') <= ")
-	} else {
-		builder.WriteString("(json_unquote(json_extract(value,'")
-		builder.WriteString(c.path)
-		builder.WriteString("')) <= ")
-	}
-	builder.WriteString(expr)
-	builder.WriteString(")")
-	return builder.String()
+func (c *jsonAtLe) sql() (string, []interface{}) {
+	return compareSQL(c.getDialect(), jsonAtOperand(c.getDialect(), c.path, c.e), "<=", c.e)
 }
 
 type jsonAtGt struct {
@@ -238,22 +241,8 @@ type jsonAtGt struct {
 	dialectValue
 }
 
-func (c *jsonAtGt) sql() string {
-	c.e.setDialect(c.dialect)
-	expr := c.e.eval()
-	builder := strings.Builder{}
-	if c.dialect == SQLite3 {
-		builder.WriteString("(json_extract(value,'")
-		builder.WriteString(c.path)
-		builder.WriteString("') > ")
-	} else {
-		builder.WriteString("(json_unquote(json_extract(value,'")
-		builder.WriteString(c.path)
-		builder.WriteString("')) > ")
-	}
-	builder.WriteString(expr)
-	builder.WriteString(")")
-	return builder.String()
+func (c *jsonAtGt) sql() (string, []interface{}) {
+	return compareSQL(c.getDialect(), jsonAtOperand(c.getDialect(), c.path, c.e), ">", c.e)
 }
 
 type jsonAtGe struct {
@@ -262,22 +251,76 @@ type jsonAtGe struct {
 	dialectValue
 }
 
-func (c *jsonAtGe) sql() string {
-	c.e.setDialect(c.dialect)
-	expr := c.e.eval()
-	builder := strings.Builder{}
-	if c.dialect == SQLite3 {
-		builder.WriteString("(json_extract(value,'")
-		builder.WriteString(c.path)
-		builder.WriteString("') >= ")
-	} else {
-		builder.WriteString("(json_unquote(json_extract(value,'")
-		builder.WriteString(c.path)
-		builder.WriteString("')) >= ")
+func (c *jsonAtGe) sql() (string, []interface{}) {
+	return compareSQL(c.getDialect(), jsonAtOperand(c.getDialect(), c.path, c.e), ">=", c.e)
+}
+
+type jsonAtNe struct {
+	path string
+	e    Expression
+	dialectValue
+}
+
+func (c *jsonAtNe) sql() (string, []interface{}) {
+	return compareSQL(c.getDialect(), jsonAtOperand(c.getDialect(), c.path, c.e), "!=", c.e)
+}
+
+type jsonAtIn struct {
+	path   string
+	values []Expression
+	dialectValue
+}
+
+func (c *jsonAtIn) sql() (string, []interface{}) {
+	return inSQL(c.getDialect(), c.getDialect().JSONExtract("value", c.path), c.values)
+}
+
+type jsonAtBetween struct {
+	path string
+	low  Expression
+	high Expression
+	dialectValue
+}
+
+func (c *jsonAtBetween) sql() (string, []interface{}) {
+	dialect := c.getDialect()
+	operand := dialect.JSONExtract("value", c.path)
+	if isNumericExpression(c.low) || isNumericExpression(c.high) {
+		operand = dialect.CastNumeric(operand)
 	}
-	builder.WriteString(expr)
-	builder.WriteString(")")
-	return builder.String()
+	return betweenSQL(dialect, operand, c.low, c.high)
+}
+
+type jsonAtIsNull struct {
+	path string
+	dialectValue
+}
+
+func (c *jsonAtIsNull) sql() (string, []interface{}) {
+	return c.getDialect().JSONIsNull("value", c.path), nil
+}
+
+type jsonArrayContains struct {
+	path string
+	e    Expression
+	dialectValue
+}
+
+func (c *jsonArrayContains) sql() (string, []interface{}) {
+	c.e.setDialect(c.getDialect())
+	value, args := c.e.eval()
+	return c.getDialect().JSONArrayContains("value", c.path, value), args
+}
+
+type jsonArrayLength struct {
+	path   string
+	op     CompareOp
+	length Expression
+	dialectValue
+}
+
+func (c *jsonArrayLength) sql() (string, []interface{}) {
+	return compareSQL(c.getDialect(), c.getDialect().JSONArrayLength("value", c.path), string(c.op), c.length)
 }
 
 type not struct {
@@ -285,9 +328,17 @@ type not struct {
 	dialectValue
 }
 
-func (n *not) sql() string {
+func (n *not) columns() []string {
+	if ce, ok := n.e.(columnsExpr); ok {
+		return ce.columns()
+	}
+	return nil
+}
+
+func (n *not) sql() (string, []interface{}) {
 	n.e.setDialect(n.dialect)
-	return "not (" + n.e.sql() + ")"
+	condition, args := n.e.sql()
+	return "not (" + condition + ")", args
 }
 
 type eq struct {
@@ -295,9 +346,8 @@ type eq struct {
 	dialectValue
 }
 
-func (e *eq) sql() string {
-	e.e.setDialect(e.dialect)
-	return "value = " + e.e.eval()
+func (e *eq) sql() (string, []interface{}) {
+	return compareSQL(e.getDialect(), valueOperand(e.getDialect(), e.e), "=", e.e)
 }
 
 type ne struct {
@@ -305,9 +355,8 @@ type ne struct {
 	dialectValue
 }
 
-func (n *ne) sql() string {
-	n.e.setDialect(n.dialect)
-	return "value != " + n.e.eval()
+func (n *ne) sql() (string, []interface{}) {
+	return compareSQL(n.getDialect(), valueOperand(n.getDialect(), n.e), "!=", n.e)
 }
 
 type gt struct {
@@ -315,9 +364,8 @@ type gt struct {
 	dialectValue
 }
 
-func (g *gt) sql() string {
-	g.e.setDialect(g.dialect)
-	return "value > " + g.e.eval()
+func (g *gt) sql() (string, []interface{}) {
+	return compareSQL(g.getDialect(), valueOperand(g.getDialect(), g.e), ">", g.e)
 }
 
 type gte struct {
@@ -325,9 +373,8 @@ type gte struct {
 	dialectValue
 }
 
-func (g *gte) sql() string {
-	g.e.setDialect(g.dialect)
-	return "value >= " + g.e.eval()
+func (g *gte) sql() (string, []interface{}) {
+	return compareSQL(g.getDialect(), valueOperand(g.getDialect(), g.e), ">=", g.e)
 }
 
 type lt struct {
@@ -335,9 +382,8 @@ type lt struct {
 	dialectValue
 }
 
-func (l *lt) sql() string {
-	l.e.setDialect(l.dialect)
-	return "value < " + l.e.eval()
+func (l *lt) sql() (string, []interface{}) {
+	return compareSQL(l.getDialect(), valueOperand(l.getDialect(), l.e), "<", l.e)
 }
 
 type lte struct {
@@ -345,32 +391,32 @@ type lte struct {
 	dialectValue
 }
 
-func (l *lte) sql() string {
-	l.e.setDialect(l.dialect)
-	return "value <= " + l.e.eval()
+func (l *lte) sql() (string, []interface{}) {
+	return compareSQL(l.getDialect(), valueOperand(l.getDialect(), l.e), "<=", l.e)
 }
 
 type trueExpr struct {
 	dialectValue
 }
 
-func (_ *trueExpr) sql() string {
-	return "(1)"
+func (t *trueExpr) sql() (string, []interface{}) {
+	return "(" + t.getDialect().Bool(true) + ")", nil
 }
 
 type falseExpr struct {
 	dialectValue
 }
 
-func (_ *falseExpr) sql() string {
-	return "(0)"
+func (f *falseExpr) sql() (string, []interface{}) {
+	return "(" + f.getDialect().Bool(false) + ")", nil
 }
 
 type rawExpression struct {
 	rawExpression string
+	args          []interface{}
 	dialectValue
 }
 
-func (r *rawExpression) eval() string {
-	return r.rawExpression
+func (r *rawExpression) eval() (string, []interface{}) {
+	return r.rawExpression, r.args
 }
@@ -1,35 +1,74 @@
 package bome
 
-import (
-	"fmt"
-	"strings"
-)
+import "strings"
 
+// Expression is a value expression. It renders to SQL with '?' placeholders and the arguments bound to them.
 type Expression interface {
-	eval() string
-	setDialect(string)
+	eval() (string, []interface{})
+	setDialect(Dialect)
 }
 
+// BoolExpr is a condition. It renders to SQL with '?' placeholders and the arguments bound to them.
 type BoolExpr interface {
-	sql() string
-	setDialect(string)
+	sql() (string, []interface{})
+	setDialect(Dialect)
 }
 
+// ValueType is the type of value bound to a placeholder.
+type ValueType int
+
+const (
+	// TextValue is the type of string values.
+	TextValue ValueType = iota
+
+	// IntValue is the type of integer values.
+	IntValue
+
+	// FloatValue is the type of floating point values.
+	FloatValue
+
+	// JsonValue is the type of JSON documents bound as text.
+	JsonValue
+)
+
+// CompareOp is a comparison operator.
+type CompareOp string
+
+const (
+	OpEq CompareOp = "="
+	OpNe CompareOp = "!="
+	OpLt CompareOp = "<"
+	OpLe CompareOp = "<="
+	OpGt CompareOp = ">"
+	OpGe CompareOp = ">="
+)
+
+// defaultDialect renders expressions that are not bound to a collection dialect.
+var defaultDialect Dialect = MySQLDialect{}
+
 type dialectValue struct {
-	dialect string
+	dialect Dialect
 }
 
-func (v *dialectValue) setDialect(dialect string) {
+func (v *dialectValue) setDialect(dialect Dialect) {
 	v.dialect = dialect
 }
 
+// getDialect returns the dialect the expression is rendered with.
+func (v *dialectValue) getDialect() Dialect {
+	if v.dialect == nil {
+		return defaultDialect
+	}
+	return v.dialect
+}
+
 type stringExpression struct {
 	value string
 	dialectValue
 }
 
-func (s *stringExpression) eval() string {
-	return fmt.Sprintf("'%s'", escaped(s.value))
+func (s *stringExpression) eval() (string, []interface{}) {
+	return s.getDialect().Placeholder(TextValue), []interface{}{s.value}
 }
 
 type intExpression struct {
@@ -37,8 +76,155 @@ type intExpression struct {
 	dialectValue
 }
 
-func (s *intExpression) eval() string {
-	return fmt.Sprintf("%d", s.value)
+func (s *intExpression) eval() (string, []interface{}) {
+	return s.getDialect().Placeholder(IntValue), []interface{}{s.value}
+}
+
+type floatExpression struct {
+	value float64
+	dialectValue
+}
+
+func (s *floatExpression) eval() (string, []interface{}) {
+	return s.getDialect().Placeholder(FloatValue), []interface{}{s.value}
+}
+
+type rawJsonExpression struct {
+	text string
+	dialectValue
+}
+
+func (s *rawJsonExpression) eval() (string, []interface{}) {
+	return s.getDialect().Placeholder(JsonValue), []interface{}{s.text}
+}
+
+type jsonLiteral struct {
+	text string
+	dialectValue
+}
+
+func (s *jsonLiteral) eval() (string, []interface{}) {
+	return s.getDialect().JSONLiteral(s.text), nil
+}
+
+type jsonArrayExpression struct {
+	expressions []Expression
+	dialectValue
+}
+
+func (s *jsonArrayExpression) eval() (string, []interface{}) {
+	values, args := evalAll(s.getDialect(), s.expressions)
+	return s.getDialect().JSONArray(values...), args
+}
+
+type jsonAtExpression struct {
+	path string
+	dialectValue
+}
+
+func (s *jsonAtExpression) eval() (string, []interface{}) {
+	return s.getDialect().JSONExtract("value", s.path), nil
+}
+
+type arithmeticExpression struct {
+	op string
+	a  Expression
+	b  Expression
+	dialectValue
+}
+
+func (s *arithmeticExpression) eval() (string, []interface{}) {
+	dialect := s.getDialect()
+	a, args := numericOperand(dialect, s.a)
+	b, bArgs := numericOperand(dialect, s.b)
+	return "(" + a + " " + s.op + " " + b + ")", append(args, bArgs...)
+}
+
+// numericOperand evaluates e as an operand of arithmetic operations.
+func numericOperand(dialect Dialect, e Expression) (string, []interface{}) {
+	e.setDialect(dialect)
+	value, args := e.eval()
+	if _, ok := e.(*jsonAtExpression); ok {
+		return dialect.CastNumeric(value), args
+	}
+	return value, args
+}
+
+type concatExpression struct {
+	expressions []Expression
+	dialectValue
+}
+
+func (s *concatExpression) eval() (string, []interface{}) {
+	values, args := evalAll(s.getDialect(), s.expressions)
+	return s.getDialect().Concat(values...), args
+}
+
+type functionExpression struct {
+	name        string
+	expressions []Expression
+	dialectValue
+}
+
+func (s *functionExpression) eval() (string, []interface{}) {
+	values, args := evalAll(s.getDialect(), s.expressions)
+	return s.name + "(" + strings.Join(values, ", ") + ")", args
+}
+
+type coalesceExpression struct {
+	expressions []Expression
+	dialectValue
+}
+
+// numeric tells if one of the expressions is a number, in which case JSON values are converted to numbers.
+func (s *coalesceExpression) numeric() bool {
+	for _, ex := range s.expressions {
+		if isNumericExpression(ex) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *coalesceExpression) eval() (string, []interface{}) {
+	if !s.numeric() {
+		values, args := evalAll(s.getDialect(), s.expressions)
+		return "coalesce(" + strings.Join(values, ", ") + ")", args
+	}
+
+	var (
+		values []string
+		args   []interface{}
+	)
+	for _, ex := range s.expressions {
+		value, valueArgs := numericOperand(s.getDialect(), ex)
+		values = append(values, value)
+		args = append(args, valueArgs...)
+	}
+	return "coalesce(" + strings.Join(values, ", ") + ")", args
+}
+
+type nowExpression struct {
+	dialectValue
+}
+
+func (s *nowExpression) eval() (string, []interface{}) {
+	return s.getDialect().Placeholder(IntValue), []interface{}{nowMillis()}
+}
+
+// evalAll evaluates expressions with dialect.
+func evalAll(dialect Dialect, expressions []Expression) ([]string, []interface{}) {
+	var (
+		values []string
+		args   []interface{}
+	)
+	for _, ex := range expressions {
+		ex.setDialect(dialect)
+		value, valueArgs := ex.eval()
+		values = append(values, value)
+		args = append(args, valueArgs...)
+	}
+	return values, args
 }
 
 type jsonExpression struct {
@@ -46,13 +232,31 @@ type jsonExpression struct {
 	dialectValue
 }
 
-func (s *jsonExpression) eval() string {
-	var values []string
+func (s *jsonExpression) eval() (string, []interface{}) {
+	var (
+		values []string
+		args   []interface{}
+	)
 	for _, ex := range s.expressions {
-		ex.setDialect(s.dialectValue.dialect)
-		values = append(values, ex.eval())
+		ex.setDialect(s.dialect)
+		value, valueArgs := ex.eval()
+		values = append(values, value)
+		args = append(args, valueArgs...)
 	}
-	return fmt.Sprintf("json_object(%s)", strings.Join(values, ","))
+	return s.getDialect().JSONObject(values...), args
+}
+
+// jsonValueSQL evaluates ex as a value that can be set in a JSON document.
+func jsonValueSQL(dialect Dialect, ex Expression) (string, []interface{}) {
+	ex.setDialect(dialect)
+	value, args := ex.eval()
+	return dialect.JSONValue(value), args
+}
+
+// conditionSQL renders condition with dialect.
+func conditionSQL(dialect Dialect, condition BoolExpr) (string, []interface{}) {
+	condition.setDialect(dialect)
+	return condition.sql()
 }
 
 func StringExpr(value string) Expression {
@@ -63,8 +267,81 @@ func IntExpr(value int64) Expression {
 	return &intExpression{value: value}
 }
 
-func RawExpr(sqlRawExpression string) Expression {
-	return &rawExpression{rawExpression: sqlRawExpression}
+func FloatExpr(value float64) Expression {
+	return &floatExpression{value: value}
+}
+
+// BoolLiteral returns the JSON boolean value.
+func BoolLiteral(value bool) Expression {
+	if value {
+		return &jsonLiteral{text: "true"}
+	}
+	return &jsonLiteral{text: "false"}
+}
+
+// NullExpr returns the JSON null value.
+func NullExpr() Expression {
+	return &jsonLiteral{text: "null"}
+}
+
+// RawJsonExpr returns the JSON document text. Unlike StringExpr, text is set in documents as JSON and not as a string.
+func RawJsonExpr(text string) Expression {
+	return &rawJsonExpression{text: text}
+}
+
+// JsonArrayExpr returns the JSON array of the values of expressions.
+func JsonArrayExpr(expressions ...Expression) Expression {
+	return &jsonArrayExpression{expressions: expressions}
+}
+
+// JsonAt returns the scalar value found at path in the entry value. Missing values are null.
+func JsonAt(path string) Expression {
+	return &jsonAtExpression{path: path}
+}
+
+func Add(a, b Expression) Expression {
+	return &arithmeticExpression{op: "+", a: a, b: b}
+}
+
+func Sub(a, b Expression) Expression {
+	return &arithmeticExpression{op: "-", a: a, b: b}
+}
+
+func Mul(a, b Expression) Expression {
+	return &arithmeticExpression{op: "*", a: a, b: b}
+}
+
+// Div divides a by b. The division of integers is an integer division on SQLite and PostgreSQL.
+func Div(a, b Expression) Expression {
+	return &arithmeticExpression{op: "/", a: a, b: b}
+}
+
+// Concat returns the concatenation of the values of expressions as text.
+func Concat(expressions ...Expression) Expression {
+	return &concatExpression{expressions: expressions}
+}
+
+// Coalesce returns the value of the first expression which value is not null.
+func Coalesce(expressions ...Expression) Expression {
+	return &coalesceExpression{expressions: expressions}
+}
+
+// Now returns the current time in unix milliseconds, as times are stored by bome.
+func Now() Expression {
+	return &nowExpression{}
+}
+
+func Lower(e Expression) Expression {
+	return &functionExpression{name: "lower", expressions: []Expression{e}}
+}
+
+func Upper(e Expression) Expression {
+	return &functionExpression{name: "upper", expressions: []Expression{e}}
+}
+
+// RawExpr creates an expression from raw SQL. args are bound to the '?' placeholders of sqlRawExpression.
+func RawExpr(sqlRawExpression string, args ...interface{}) Expression {
+	return &rawExpression{rawExpression: sqlRawExpression, args: args}
 }
 
 func JsonExpr(expressions ...Expression) Expression {
@@ -82,8 +359,8 @@ type fieldExpression struct {
 	dialectValue
 }
 
-func (e *fieldExpression) eval() string {
-	return fmt.Sprintf("`%s`", e.field)
+func (e *fieldExpression) eval() (string, []interface{}) {
+	return e.getDialect().QuoteIdentifier(e.field), nil
 }
 
 func Or(conditions ...BoolExpr) BoolExpr {
@@ -216,6 +493,62 @@ func JsonAtGe(path string, e Expression) BoolExpr {
 	}
 }
 
+// In returns the condition that tells if the whole value is one of values.
+func In(values ...Expression) BoolExpr {
+	return &in{values: values}
+}
+
+// JsonAtIn returns the condition that tells if the value found at path is one of values.
+func JsonAtIn(path string, values ...Expression) BoolExpr {
+	return &jsonAtIn{
+		path:   path,
+		values: values,
+	}
+}
+
+// JsonAtBetween returns the condition that tells if the value found at path is between low and high, inclusive.
+func JsonAtBetween(path string, low, high Expression) BoolExpr {
+	return &jsonAtBetween{
+		path: path,
+		low:  low,
+		high: high,
+	}
+}
+
+// JsonAtIsNull returns the condition that tells if there is no value or a JSON null at path.
+func JsonAtIsNull(path string) BoolExpr {
+	return &jsonAtIsNull{path: path}
+}
+
+// JsonAtIsNotNull returns the condition that tells if there is a value other than JSON null at path.
+func JsonAtIsNotNull(path string) BoolExpr {
+	return Not(JsonAtIsNull(path))
+}
+
+func JsonAtNe(path string, e Expression) BoolExpr {
+	return &jsonAtNe{
+		path: path,
+		e:    e,
+	}
+}
+
+// JsonArrayContains returns the condition that tells if the JSON array found at path contains the scalar value e.
+func JsonArrayContains(path string, e Expression) BoolExpr {
+	return &jsonArrayContains{
+		path: path,
+		e:    e,
+	}
+}
+
+// JsonArrayLength returns the condition that compares the length of the JSON array found at path with length.
+func JsonArrayLength(path string, op CompareOp, length int64) BoolExpr {
+	return &jsonArrayLength{
+		path:   path,
+		op:     op,
+		length: IntExpr(length),
+	}
+}
+
 func EndsWith(e Expression) BoolExpr {
 	return &endsWith{e: e}
 }
@@ -2,6 +2,7 @@ package bome
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -9,186 +10,297 @@ import (
 	"github.com/xwb1989/sqlparser"
 )
 
-func testExpression(_ *testing.T, ex string) {
+func testExpression(_ *testing.T, e BoolExpr) {
+	ex, args := e.sql()
+	So(strings.Count(ex, "?"), ShouldEqual, len(args))
+
 	rawEx := RawExpr(fmt.Sprintf("select * from t where %s", ex))
-	_, err := sqlparser.Parse(rawEx.eval())
+	query, _ := rawEx.eval()
+	_, err := sqlparser.Parse(query)
 	So(err, ShouldBeNil)
 }
 
 func TestStringExpr(t *testing.T) {
 	Convey("StringExpr", t, func() {
-		str := StringExpr("val").eval()
-		So(str, ShouldEqual, "'val'")
+		str, args := StringExpr("val").eval()
+		So(str, ShouldEqual, "?")
+		So(args, ShouldResemble, []interface{}{"val"})
 	})
 }
 
 func TestIntExpr(t *testing.T) {
 	Convey("IntExpr", t, func() {
-		str := IntExpr(23).eval()
-		So(str, ShouldEqual, "23")
+		str, args := IntExpr(23).eval()
+		So(str, ShouldEqual, "?")
+		So(args, ShouldResemble, []interface{}{int64(23)})
 	})
 }
 
 func TestEq(t *testing.T) {
 	Convey("Eq", t, func() {
 		e := Eq(StringExpr("val"))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestGt(t *testing.T) {
 	Convey("Gt", t, func() {
 		e := Gt(IntExpr(23))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestContains(t *testing.T) {
 	Convey("Contains", t, func() {
 		e := Contains(StringExpr("text"))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestGte(t *testing.T) {
 	Convey("Gte", t, func() {
 		e := Gte(IntExpr(23))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestJsonAtContains(t *testing.T) {
 	Convey("JsonAtContains", t, func() {
 		e := JsonAtContains("$.tests.unit", StringExpr("pattern"))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestJsonAtEndsWith(t *testing.T) {
 	Convey("JsonAtEndsWith", t, func() {
 		e := JsonAtEndsWith("$.tests.unit", StringExpr("pattern"))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestJsonContains(t *testing.T) {
 	Convey("JsonContains", t, func() {
 		e := JsonContainsPath("$.item.at.path")
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestLt(t *testing.T) {
 	Convey("Lt", t, func() {
 		e := Lt(IntExpr(23))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestLte(t *testing.T) {
 	Convey("Lte", t, func() {
 		e := Lte(IntExpr(23))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestJsonAtEq(t *testing.T) {
 	Convey("JsonAtEq", t, func() {
 		e := JsonAtEq("$.json.item.path", StringExpr("val"))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 
 		e = JsonAtEq("$.json.item.path", IntExpr(23))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestJsonAtGe(t *testing.T) {
 	Convey("JsonAtGe", t, func() {
 		e := JsonAtGe("$.json.int.at.path", IntExpr(23))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestJsonAtGt(t *testing.T) {
 	Convey("JsonAtGt", t, func() {
 		e := JsonAtGt("$.json.int.at.path", IntExpr(23))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestNe(t *testing.T) {
 	Convey("Ne", t, func() {
 		e := Ne(IntExpr(23))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 
 		e = Ne(StringExpr("val"))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestJsonAtLe(t *testing.T) {
 	Convey("JsonAtLe", t, func() {
 		e := JsonAtLe("$.json.int.at.path", IntExpr(23))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestNot(t *testing.T) {
 	Convey("Not", t, func() {
 		e := Not(Eq(StringExpr("val")))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestJsonAtStartsWith(t *testing.T) {
 	Convey("JsonAtStartsWith", t, func() {
 		e := JsonAtStartsWith("$.json.int.at.path", StringExpr("return"))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestJsonAtLt(t *testing.T) {
 	Convey("JsonAtLt", t, func() {
 		e := JsonAtLt("$.json.int.at.path", IntExpr(23))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestRawExpr(t *testing.T) {
-	Convey("RawExpr", t, func() {})
+	Convey("RawExpr", t, func() {
+		str, args := RawExpr("length(value) > ?", 10).eval()
+		So(str, ShouldEqual, "length(value) > ?")
+		So(args, ShouldResemble, []interface{}{10})
+	})
+}
+
+func TestJsonPathQuoting(t *testing.T) {
+	Convey("JSON paths can not break out of their literal", t, func() {
+		e := JsonAtEq("$.a') or (1=1", StringExpr("val"))
+		testExpression(t, e)
+
+		sql, _ := e.sql()
+		So(sql, ShouldContainSubstring, `'$.a\') or (1=1'`)
+	})
 }
 
 func TestAnd(t *testing.T) {
 	Convey("And", t, func() {
 		e := And(Eq(StringExpr("")), StartsWith(StringExpr("str2")))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestOr(t *testing.T) {
 	Convey("Or", t, func() {
 		e := Or(Contains(StringExpr("er")), Contains(StringExpr("e")))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestStartsWith(t *testing.T) {
 	Convey("And", t, func() {
 		e := StartsWith(StringExpr("str2"))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestEndsWith(t *testing.T) {
 	Convey("And", t, func() {
 		e := EndsWith(StringExpr("str2"))
-		testExpression(t, e.sql())
+		testExpression(t, e)
 	})
 }
 
 func TestFieldExpression(t *testing.T) {
 	Convey("Field expression", t, func() {
 		/*e := Eq( FieldExpression("value"))
-		testExpression(t, e.sql()) */
+		testExpression(t, e) */
+	})
+}
+
+func TestIn(t *testing.T) {
+	Convey("In", t, func() {
+		testExpression(t, In(StringExpr("a"), StringExpr("b")))
+		testExpression(t, In(IntExpr(1), IntExpr(2)))
+		testExpression(t, In())
+	})
+}
+
+func TestJsonAtIn(t *testing.T) {
+	Convey("JsonAtIn", t, func() {
+		e := JsonAtIn("$.json.item.path", StringExpr("a"), StringExpr("b"))
+		testExpression(t, e)
+
+		sql, args := e.sql()
+		So(sql, ShouldEqual, "(json_unquote(json_extract(value, '$.json.item.path')) in (?, ?))")
+		So(args, ShouldResemble, []interface{}{"a", "b"})
+	})
+}
+
+func TestJsonAtBetween(t *testing.T) {
+	Convey("JsonAtBetween", t, func() {
+		e := JsonAtBetween("$.json.int.at.path", IntExpr(1), IntExpr(10))
+		testExpression(t, e)
+	})
+}
+
+func TestJsonAtIsNull(t *testing.T) {
+	Convey("JsonAtIsNull", t, func() {
+		testExpression(t, JsonAtIsNull("$.json.item.path"))
+		testExpression(t, JsonAtIsNotNull("$.json.item.path"))
+	})
+}
+
+func TestJsonAtNe(t *testing.T) {
+	Convey("JsonAtNe", t, func() {
+		testExpression(t, JsonAtNe("$.json.item.path", StringExpr("val")))
+		testExpression(t, JsonAtNe("$.json.item.path", IntExpr(23)))
+	})
+}
+
+func TestJsonArrayContains(t *testing.T) {
+	Convey("JsonArrayContains", t, func() {
+		e := JsonArrayContains("$.tags", StringExpr("go"))
+		testExpression(t, e)
+
+		sql, _ := e.sql()
+		So(sql, ShouldEqual, "(json_contains(value, json_array(?), '$.tags'))")
+	})
+}
+
+func TestJsonArrayLength(t *testing.T) {
+	Convey("JsonArrayLength", t, func() {
+		testExpression(t, JsonArrayLength("$.tags", OpGe, 2))
+	})
+}
+
+func TestColumnConditions(t *testing.T) {
+	Convey("Column conditions", t, func() {
+		testExpression(t, FirstKey().StartsWith(StringExpr("people")))
+		testExpression(t, Key().In(StringExpr("a"), StringExpr("b")))
+		testExpression(t, IndexColumn().Between(IntExpr(1), IntExpr(10)))
+		testExpression(t, And(SecondKey().Ne(StringExpr("a")), Not(Col("ind").Gt(IntExpr(2)))))
+
+		sql, args := FirstKey().StartsWith(StringExpr("people")).sql()
+		So(sql, ShouldEqual, "(first_key like ?)")
+		So(args, ShouldResemble, []interface{}{"people%"})
+	})
+
+	Convey("Columns are checked against the collection keys", t, func() {
+		condition := And(FirstKey().Eq(StringExpr("a")), Or(JsonAtEq("$.a", IntExpr(1)), Not(IndexColumn().Lt(IntExpr(2)))))
+		So(checkColumns(condition, []string{"first_key", "ind"}), ShouldBeNil)
+
+		err := checkColumns(condition, []string{"first_key", "second_key"})
+		So(err, ShouldResemble, &UnknownColumnError{Column: "ind"})
+	})
+}
+
+func TestComputedExpressions(t *testing.T) {
+	Convey("Computed expressions", t, func() {
+		testExpression(t, JsonAtEq("$.visits", Add(Coalesce(JsonAt("$.visits"), IntExpr(0)), IntExpr(1))))
+		testExpression(t, JsonAtGt("$.ratio", Div(Mul(JsonAt("$.a"), FloatExpr(1.5)), Sub(JsonAt("$.b"), IntExpr(1)))))
+		testExpression(t, JsonAtLt("$.updated", Now()))
+		testExpression(t, JsonAtEq("$.name", Upper(Concat(Lower(JsonAt("$.first")), StringExpr(" "), JsonAt("$.last")))))
+		testExpression(t, Eq(JsonArrayExpr(StringExpr("a"), IntExpr(1), BoolLiteral(true), NullExpr())))
+
+		sql, args := JsonAtEq("$.visits", Add(Coalesce(JsonAt("$.visits"), IntExpr(0)), IntExpr(1))).sql()
+		So(sql, ShouldEqual, "(json_unquote(json_extract(value, '$.visits')) = (coalesce(json_unquote(json_extract(value, '$.visits')), ?) + ?))")
+		So(args, ShouldResemble, []interface{}{int64(0), int64(1)})
 	})
 }
@@ -0,0 +1,74 @@
+package bome
+
+import (
+	"github.com/omecodes/errors"
+)
+
+// jsonIndex is an index on the values found at a path of JSON values, stored in a generated column. See WithJsonIndex.
+type jsonIndex struct {
+	name      string
+	path      string
+	valueType ValueType
+}
+
+// indexName returns the name of the index on table. Index names are prefixed with the table name since they must be
+// unique in a database with SQLite and PostgreSQL.
+func (index *jsonIndex) indexName(table string) string {
+	return table + "_" + index.name + "_idx"
+}
+
+// createJsonIndexes adds the generated columns of indexes to table, and indexes them.
+func createJsonIndexes(db *DB, table string, indexes []*jsonIndex) error {
+	for _, index := range indexes {
+		if index.valueType == JsonValue {
+			return errors.NotSupported()
+		}
+
+		err := ensureColumns(db, db.dialect.JSONColumn(index.name, "value", index.path, index.valueType))
+		if err != nil {
+			return err
+		}
+
+		err = db.EnsureIndex(Index{Name: index.indexName(table), Table: table, Fields: []string{index.name}, NonUnique: true})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonIndexDialect renders the values found at the paths of JSON indexes with their generated columns,
+// so that conditions on these paths use the indexes.
+type jsonIndexDialect struct {
+	Dialect
+	columns map[string]*jsonIndex
+	numeric map[string]bool
+}
+
+func newJsonIndexDialect(dialect Dialect, indexes []*jsonIndex) *jsonIndexDialect {
+	d := &jsonIndexDialect{
+		Dialect: dialect,
+		columns: map[string]*jsonIndex{},
+		numeric: map[string]bool{},
+	}
+	for _, index := range indexes {
+		d.columns[index.path] = index
+		d.numeric[index.name] = index.valueType == IntValue || index.valueType == FloatValue
+	}
+	return d
+}
+
+func (d *jsonIndexDialect) JSONExtract(field string, path string) string {
+	if index, found := d.columns[normalizedJsonPath(path)]; found && field == "value" {
+		return index.name
+	}
+	return d.Dialect.JSONExtract(field, path)
+}
+
+// CastNumeric leaves the numeric generated columns unchanged so that their indexes can be used.
+func (d *jsonIndexDialect) CastNumeric(expr string) string {
+	if d.numeric[expr] {
+		return expr
+	}
+	return d.Dialect.CastNumeric(expr)
+}
@@ -0,0 +1,92 @@
+package bome
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestJsonIndexDialect(t *testing.T) {
+	Convey("Conditions on indexed paths are rendered on generated columns", t, func() {
+		d := newJsonIndexDialect(PostgresDialect{}, []*jsonIndex{
+			{name: "age", path: "$.age", valueType: IntValue},
+			{name: "city", path: "$.address.city", valueType: TextValue},
+		})
+
+		sql, _ := conditionSQL(d, JsonAtGt("$.age", IntExpr(29)))
+		So(sql, ShouldEqual, "(age > cast(? as bigint))")
+
+		sql, _ = conditionSQL(d, JsonAtEq("address.city", StringExpr("Paris")))
+		So(sql, ShouldEqual, "(city = cast(? as text))")
+
+		sql, _ = conditionSQL(d, JsonAtEq("$.name", StringExpr("Ada")))
+		So(sql, ShouldEqual, "((value->>'name') = cast(? as text))")
+
+		So(PostgresDialect{}.JSONColumn("age", "value", "$.age", IntValue), ShouldEqual, "age bigint generated always as (cast((value->>'age') as bigint)) stored")
+		So(MySQLDialect{}.JSONColumn("city", "value", "$.city", TextValue), ShouldEqual, "city varchar(255) generated always as (json_unquote(json_extract(value, '$.city'))) virtual")
+	})
+}
+
+func TestMap_WithJsonIndex(t *testing.T) {
+	Convey("Maps filter indexed paths with the index", t, func() {
+		db, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+
+		_, err = db.Exec("drop table if exists indexed_map")
+		So(err, ShouldBeNil)
+
+		build := func() *Map {
+			m, err := Build().SetConn(db).SetDialect(testDialect).SetTableName("indexed_map").Map(WithJsonIndex("age", "$.age", IntValue))
+			So(err, ShouldBeNil)
+			return m
+		}
+
+		m := build()
+		So(m.Save("ada", map[string]interface{}{"age": 36}, SaveOptions{}), ShouldBeNil)
+		So(m.Save("alan", map[string]interface{}{"age": 41}, SaveOptions{}), ShouldBeNil)
+		So(m.Save("grace", map[string]interface{}{"name": "Grace"}, SaveOptions{}), ShouldBeNil)
+
+		m = build()
+		cursor, err := m.RangeOf(JsonAtGt("$.age", IntExpr(40)), MapEntryScanner, 0, 10)
+		So(err, ShouldBeNil)
+
+		var names []string
+		for cursor.HasNext() {
+			o, err := cursor.Entry()
+			So(err, ShouldBeNil)
+			names = append(names, o.(*MapEntry).Key)
+		}
+		So(cursor.Close(), ShouldBeNil)
+		So(names, ShouldResemble, []string{"alan"})
+
+		So(m.EditAt("ada", "$.age", Add(JsonAt("$.age"), IntExpr(10))), ShouldBeNil)
+		count, err := m.RangeOf(JsonAtGt("$.age", IntExpr(40)), MapEntryScanner, 0, 10)
+		So(err, ShouldBeNil)
+		n := 0
+		for count.HasNext() {
+			n++
+			_, err = count.Entry()
+			So(err, ShouldBeNil)
+		}
+		So(count.Close(), ShouldBeNil)
+		So(n, ShouldEqual, 2)
+
+		if testDialect == SQLite3 {
+			var plan []string
+			rows, err := db.Query("explain query plan select name from indexed_map where age > 40")
+			So(err, ShouldBeNil)
+			for rows.Next() {
+				var id, parent, unused int
+				var detail string
+				So(rows.Scan(&id, &parent, &unused, &detail), ShouldBeNil)
+				plan = append(plan, detail)
+			}
+			So(rows.Close(), ShouldBeNil)
+			So(strings.Join(plan, "\n"), ShouldContainSubstring, "indexed_map_age_idx")
+		}
+
+		So(m.Close(), ShouldBeNil)
+	})
+}
@@ -6,9 +6,13 @@ import (
 )
 
 type JsonValueHolder struct {
-	field   string
-	dialect string
-	tx      *TX
+	field     string
+	columns   string
+	expiry    bool
+	versioned bool
+	keys      []string
+	dialect   Dialect
+	tx        *TX
 	*DB
 }
 
@@ -20,15 +24,21 @@ func (s *JsonValueHolder) Transaction(ctx context.Context) (context.Context, *Js
 		}
 
 		var err error
-		tx, err = s.BeginTx()
+		tx, err = s.BeginTxContext(ctx, nil)
 		if err != nil {
 			return ctx, nil, err
 		}
 
 		newCtx := contextWithTransaction(ctx, tx)
 		return newCtx, &JsonValueHolder{
-			tx:      tx,
-			dialect: s.dialect,
+			DB:        s.DB,
+			field:     s.field,
+			columns:   s.columns,
+			expiry:    s.expiry,
+			versioned: s.versioned,
+			keys:      s.keys,
+			tx:        tx,
+			dialect:   s.dialect,
 		}, nil
 	}
 
@@ -44,8 +54,14 @@ func (s *JsonValueHolder) Transaction(ctx context.Context) (context.Context, *Js
 	tx = tx.New(s.DB)
 	newCtx := contextWithTransaction(ctx, tx)
 	return newCtx, &JsonValueHolder{
-		tx:      tx,
-		dialect: s.dialect,
+		DB:        s.DB,
+		field:     s.field,
+		columns:   s.columns,
+		expiry:    s.expiry,
+		versioned: s.versioned,
+		keys:      s.keys,
+		tx:        tx,
+		dialect:   s.dialect,
 	}, nil
 }
 
@@ -56,8 +72,57 @@ func (s *JsonValueHolder) Client() Client {
 	return s.DB
 }
 
+// client returns the client that runs queries for ctx. When the collection is not bound to a transaction,
+// the transaction stored in ctx is used if it was started on the same database.
+func (s *JsonValueHolder) client(ctx context.Context) Client {
+	if s.tx == nil {
+		if tx := transaction(ctx); tx != nil && tx.db.sqlDb == s.DB.sqlDb {
+			return tx.New(s.DB)
+		}
+	}
+	return s.Client()
+}
+
+// visible returns clause restricted to the entries that are not expired, with its arguments.
+func (s *JsonValueHolder) visible(clause string, args []interface{}) (string, []interface{}) {
+	if !s.expiry {
+		return clause, args
+	}
+	return notExpired + " and (" + clause + ")", append([]interface{}{nowMillis()}, args...)
+}
+
+// condition renders condition after checking that the columns it refers to are key columns of the collection, listed in keys.
+func (s *JsonValueHolder) condition(condition BoolExpr) (string, []interface{}, error) {
+	if err := checkColumns(condition, s.keys); err != nil {
+		return "", nil, err
+	}
+	clause, args := conditionSQL(s.dialect, condition)
+	return clause, args, nil
+}
+
+// versionUpdate returns the assignment that increments the version of edited entries, if they have one.
+func (s *JsonValueHolder) versionUpdate() string {
+	if !s.versioned {
+		return ""
+	}
+	return ", version=version+1"
+}
+
+// selectedColumns returns the columns selected when reading whole entries.
+func (s *JsonValueHolder) selectedColumns() string {
+	if s.columns == "" {
+		return "*"
+	}
+	return s.columns
+}
+
 func (s *JsonValueHolder) Count() (int64, error) {
-	o, err := s.Client().QueryFirst("select count(*) from $table$;", IntScanner)
+	return s.CountContext(context.Background())
+}
+
+func (s *JsonValueHolder) CountContext(ctx context.Context) (int64, error) {
+	clause, args := s.visible(conditionSQL(s.dialect, True()))
+	o, err := s.client(ctx).QueryFirstContext(ctx, "select count(*) from $table$ where "+clause+";", IntScanner, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -65,8 +130,20 @@ func (s *JsonValueHolder) Count() (int64, error) {
 }
 
 func (s *JsonValueHolder) Size(condition BoolExpr) (int64, error) {
-	condition.setDialect(s.dialect)
-	o, err := s.Client().QueryFirst("select coalesce(length(value), 0) from $table$ where %s;", IntScanner, condition.sql())
+	return s.SizeContext(context.Background(), condition)
+}
+
+func (s *JsonValueHolder) SizeContext(ctx context.Context, condition BoolExpr) (int64, error) {
+	clause, args, err := s.condition(condition)
+	if err != nil {
+		return 0, err
+	}
+	clause, args = s.visible(clause, args)
+	rawQuery := fmt.Sprintf("select coalesce(%s, 0) from $table$ where %s;",
+		s.dialect.Length(s.field),
+		clause,
+	)
+	o, err := s.client(ctx).QueryFirstContext(ctx, rawQuery, IntScanner, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -74,7 +151,11 @@ func (s *JsonValueHolder) Size(condition BoolExpr) (int64, error) {
 }
 
 func (s *JsonValueHolder) TotalSize() (int64, error) {
-	count, err := s.Count()
+	return s.TotalSizeContext(context.Background())
+}
+
+func (s *JsonValueHolder) TotalSizeContext(ctx context.Context) (int64, error) {
+	count, err := s.CountContext(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -83,7 +164,9 @@ func (s *JsonValueHolder) TotalSize() (int64, error) {
 		return 0, nil
 	}
 
-	o, err := s.Client().QueryFirst("select coalesce(sum(length(value)), 0) from $table$;", IntScanner)
+	clause, args := s.visible(conditionSQL(s.dialect, True()))
+	rawQuery := fmt.Sprintf("select coalesce(sum(%s), 0) from $table$ where %s;", s.dialect.Length(s.field), clause)
+	o, err := s.client(ctx).QueryFirstContext(ctx, rawQuery, IntScanner, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -91,105 +174,210 @@ func (s *JsonValueHolder) TotalSize() (int64, error) {
 }
 
 func (s *JsonValueHolder) EditAllAt(path string, ex Expression) error {
-	ex.setDialect(s.dialect)
+	return s.EditAllAtContext(context.Background(), path, ex)
+}
+
+func (s *JsonValueHolder) EditAllAtContext(ctx context.Context, path string, ex Expression) error {
+	value, args := jsonValueSQL(s.dialect, ex)
+	where := ""
+	if s.expiry {
+		where = " where " + notExpired
+		args = append(args, nowMillis())
+	}
 	rawQuery := fmt.Sprintf(
-		"update $table$ set value=json_set(%s, '%s', %s);",
-		s.field,
-		normalizedJsonPath(path),
-		ex.eval(),
+		"update $table$ set value=%s%s%s;",
+		s.dialect.JSONSet(s.field, path, value),
+		s.versionUpdate(),
+		where,
 	)
-	return s.Client().Exec(rawQuery).Error
+	return s.client(ctx).ExecContext(ctx, rawQuery, args...).Error
 }
 
 func (s *JsonValueHolder) EditAt(path string, ex Expression, where BoolExpr) error {
-	where.setDialect(s.dialect)
+	return s.EditAtContext(context.Background(), path, ex, where)
+}
+
+func (s *JsonValueHolder) EditAtContext(ctx context.Context, path string, ex Expression, where BoolExpr) error {
+	value, args := jsonValueSQL(s.dialect, ex)
+	return s.editContext(ctx, s.dialect.JSONSet(s.field, path, value), args, where)
+}
+
+// RemoveAt removes the value found at path in the values of the entries matching where.
+func (s *JsonValueHolder) RemoveAt(path string, where BoolExpr) error {
+	return s.RemoveAtContext(context.Background(), path, where)
+}
+
+func (s *JsonValueHolder) RemoveAtContext(ctx context.Context, path string, where BoolExpr) error {
+	return s.editContext(ctx, s.dialect.JSONRemove(s.field, path), nil, where)
+}
+
+// AppendAt appends the value of ex to the arrays found at path in the values of the entries matching where.
+// Values that have no array at path are unchanged.
+func (s *JsonValueHolder) AppendAt(path string, ex Expression, where BoolExpr) error {
+	return s.AppendAtContext(context.Background(), path, ex, where)
+}
+
+func (s *JsonValueHolder) AppendAtContext(ctx context.Context, path string, ex Expression, where BoolExpr) error {
+	value, args := jsonValueSQL(s.dialect, ex)
+	return s.editContext(ctx, s.dialect.JSONArrayAppend(s.field, path, value), args, where)
+}
+
+// InsertAt inserts the value of ex at index in the arrays found at path in the values of the entries matching where.
+// The value is appended to arrays that have less than index elements.
+func (s *JsonValueHolder) InsertAt(path string, index int, ex Expression, where BoolExpr) error {
+	return s.InsertAtContext(context.Background(), path, index, ex, where)
+}
+
+func (s *JsonValueHolder) InsertAtContext(ctx context.Context, path string, index int, ex Expression, where BoolExpr) error {
+	value, args := jsonValueSQL(s.dialect, ex)
+	return s.editContext(ctx, s.dialect.JSONArrayInsert(s.field, path, index, value), args, where)
+}
+
+// IncrementAt adds delta to the numbers found at path in the values of the entries matching where.
+// Missing numbers are set to delta.
+func (s *JsonValueHolder) IncrementAt(path string, delta int64, where BoolExpr) error {
+	return s.IncrementAtContext(context.Background(), path, delta, where)
+}
+
+func (s *JsonValueHolder) IncrementAtContext(ctx context.Context, path string, delta int64, where BoolExpr) error {
+	return s.EditAtContext(ctx, path, Add(Coalesce(JsonAt(path), IntExpr(0)), IntExpr(delta)), where)
+}
+
+// ToggleAt negates the booleans found at path in the values of the entries matching where.
+// Values that are not the JSON true, including missing ones, are set to true.
+func (s *JsonValueHolder) ToggleAt(path string, where BoolExpr) error {
+	return s.ToggleAtContext(context.Background(), path, where)
+}
+
+func (s *JsonValueHolder) ToggleAtContext(ctx context.Context, path string, where BoolExpr) error {
+	value := s.dialect.JSONValue(s.dialect.JSONNot(s.field, path))
+	return s.editContext(ctx, s.dialect.JSONSet(s.field, path, value), nil, where)
+}
+
+// editContext sets the values of the entries matching where to the SQL expression value, which arguments are args.
+func (s *JsonValueHolder) editContext(ctx context.Context, value string, args []interface{}, where BoolExpr) error {
+	clause, whereArgs, err := s.condition(where)
+	if err != nil {
+		return err
+	}
+	clause, whereArgs = s.visible(clause, whereArgs)
 	rawQuery := fmt.Sprintf(
-		"update $table$ set value=json_set(%s, '%s', %s) where %s",
-		s.field,
-		normalizedJsonPath(path),
-		ex.eval(),
-		where.sql(),
+		"update $table$ set value=%s%s where %s",
+		value,
+		s.versionUpdate(),
+		clause,
 	)
-	return s.Client().Exec(rawQuery).Error
+	return s.client(ctx).ExecContext(ctx, rawQuery, append(args, whereArgs...)...).Error
 }
 
 func (s *JsonValueHolder) FloatAt(path string, where BoolExpr) (Cursor, error) {
-	var rawQuery string
-	where.setDialect(s.dialect)
-	if s.dialect == SQLite3 {
-		rawQuery = fmt.Sprintf("select json_extract(%s, '%s') from $table$ where %s;",
-			s.field,
-			path,
-			where.sql(),
-		)
-	} else {
-		rawQuery = fmt.Sprintf("select json_unquote(json_extract(%s, '%s')) from $table$ where %s;",
-			s.field,
-			path,
-			where.sql(),
-		)
-	}
-	return s.Client().Query(rawQuery, FloatScanner)
+	return s.FloatAtContext(context.Background(), path, where)
+}
+
+func (s *JsonValueHolder) FloatAtContext(ctx context.Context, path string, where BoolExpr) (Cursor, error) {
+	clause, args, err := s.condition(where)
+	if err != nil {
+		return nil, err
+	}
+	clause, args = s.visible(clause, args)
+	rawQuery := fmt.Sprintf("select %s from $table$ where %s;",
+		s.dialect.JSONExtract(s.field, path),
+		clause,
+	)
+	return s.client(ctx).QueryContext(ctx, rawQuery, FloatScanner, args...)
 }
 
 func (s *JsonValueHolder) StringAt(path string, where BoolExpr) (Cursor, error) {
-	var rawQuery string
-	where.setDialect(s.dialect)
-	if s.dialect == SQLite3 {
-		rawQuery = fmt.Sprintf("select json_extract(%s, '%s') from $table$ where %s;",
-			s.field,
-			path,
-			where.sql(),
-		)
-	} else {
-		rawQuery = fmt.Sprintf("select json_unquote(json_extract(%s, '%s')) from $table$ where %s;",
-			s.field,
-			path,
-			where.sql(),
-		)
-	}
-	return s.Client().Query(rawQuery, StringScanner)
+	return s.StringAtContext(context.Background(), path, where)
+}
+
+func (s *JsonValueHolder) StringAtContext(ctx context.Context, path string, where BoolExpr) (Cursor, error) {
+	clause, args, err := s.condition(where)
+	if err != nil {
+		return nil, err
+	}
+	clause, args = s.visible(clause, args)
+	rawQuery := fmt.Sprintf("select %s from $table$ where %s;",
+		s.dialect.JSONExtract(s.field, path),
+		clause,
+	)
+	return s.client(ctx).QueryContext(ctx, rawQuery, StringScanner, args...)
 }
 
 func (s *JsonValueHolder) IntAt(path string, where BoolExpr) (Cursor, error) {
-	var rawQuery string
-	where.setDialect(s.dialect)
-	if s.dialect == SQLite3 {
-		rawQuery = fmt.Sprintf("select json_extract(%s, '%s') from $table$ where %s;",
-			s.field,
-			path,
-			where.sql(),
-		)
-	} else {
-		rawQuery = fmt.Sprintf("select json_unquote(json_extract(%s, '%s')) from $table$ where %s;",
-			s.field,
-			path,
-			where.sql(),
-		)
-	}
-	return s.Client().Query(rawQuery, IntScanner)
-}
-
-func (s *JsonValueHolder) Where(condition BoolExpr) (Cursor, error) {
-	condition.setDialect(s.dialect)
-	rawQuery := fmt.Sprintf("select * from $table$ where %s;",
-		condition.sql(),
+	return s.IntAtContext(context.Background(), path, where)
+}
+
+func (s *JsonValueHolder) IntAtContext(ctx context.Context, path string, where BoolExpr) (Cursor, error) {
+	clause, args, err := s.condition(where)
+	if err != nil {
+		return nil, err
+	}
+	clause, args = s.visible(clause, args)
+	rawQuery := fmt.Sprintf("select %s from $table$ where %s;",
+		s.dialect.JSONExtract(s.field, path),
+		clause,
 	)
-	return s.Client().Query(rawQuery, DoubleMapEntryScanner)
+	return s.client(ctx).QueryContext(ctx, rawQuery, IntScanner, args...)
 }
 
-func (s *JsonValueHolder) ValueWhere(condition BoolExpr) (Cursor, error) {
-	condition.setDialect(s.dialect)
-	rawQuery := fmt.Sprintf("select value from $table$ where %s;",
-		condition.sql(),
+// Where returns a cursor over the entries matching condition. opts set the order and the projection of the entries.
+func (s *JsonValueHolder) Where(condition BoolExpr, opts ...QueryOptions) (Cursor, error) {
+	return s.WhereContext(context.Background(), condition, opts...)
+}
+
+func (s *JsonValueHolder) WhereContext(ctx context.Context, condition BoolExpr, opts ...QueryOptions) (Cursor, error) {
+	options := mergeQueryOptions(opts)
+	clause, args, err := s.condition(condition)
+	if err != nil {
+		return nil, err
+	}
+	clause, args = s.visible(clause, args)
+	rawQuery := fmt.Sprintf("select %s from $table$ where %s%s;",
+		options.projectedColumns(s.dialect, s.selectedColumns(), s.field),
+		clause,
+		options.orderBySQL(s.dialect, s.field),
 	)
-	return s.Client().Query(rawQuery, StringScanner)
+	return s.client(ctx).QueryContext(ctx, rawQuery, DoubleMapEntryScanner, args...)
 }
 
-func (s *JsonValueHolder) RangeOf(condition BoolExpr, scannerName string, offset, count int) (Cursor, error) {
-	condition.setDialect(s.dialect)
-	rawQuery := fmt.Sprintf("select * from $table$ where %s limit ?, ?;",
-		condition.sql(),
+// ValueWhere returns a cursor over the values matching condition. opts set the order and the projection of the values.
+func (s *JsonValueHolder) ValueWhere(condition BoolExpr, opts ...QueryOptions) (Cursor, error) {
+	return s.ValueWhereContext(context.Background(), condition, opts...)
+}
+
+func (s *JsonValueHolder) ValueWhereContext(ctx context.Context, condition BoolExpr, opts ...QueryOptions) (Cursor, error) {
+	options := mergeQueryOptions(opts)
+	clause, args, err := s.condition(condition)
+	if err != nil {
+		return nil, err
+	}
+	clause, args = s.visible(clause, args)
+	rawQuery := fmt.Sprintf("select %s from $table$ where %s%s;",
+		options.projectedColumns(s.dialect, s.field, s.field),
+		clause,
+		options.orderBySQL(s.dialect, s.field),
+	)
+	return s.client(ctx).QueryContext(ctx, rawQuery, StringScanner, args...)
+}
+
+// RangeOf returns a cursor over count entries matching condition, starting at offset. opts set the order
+// and the projection of the entries.
+func (s *JsonValueHolder) RangeOf(condition BoolExpr, scannerName string, offset, count int, opts ...QueryOptions) (Cursor, error) {
+	return s.RangeOfContext(context.Background(), condition, scannerName, offset, count, opts...)
+}
+
+func (s *JsonValueHolder) RangeOfContext(ctx context.Context, condition BoolExpr, scannerName string, offset, count int, opts ...QueryOptions) (Cursor, error) {
+	options := mergeQueryOptions(opts)
+	clause, args, err := s.condition(condition)
+	if err != nil {
+		return nil, err
+	}
+	clause, args = s.visible(clause, args)
+	rawQuery := fmt.Sprintf("select %s from $table$ where %s%s limit ?, ?;",
+		options.projectedColumns(s.dialect, s.selectedColumns(), s.field),
+		clause,
+		options.orderBySQL(s.dialect, s.field),
 	)
-	return s.Client().Query(rawQuery, scannerName, offset, count)
+	return s.client(ctx).QueryContext(ctx, rawQuery, scannerName, append(args, offset, count)...)
 }
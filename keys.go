@@ -50,6 +50,92 @@ type Index struct {
 	Name   string
 	Table  string
 	Fields []string
+
+	// NonUnique accepts rows with the same values for Fields. Indexes are unique otherwise.
+	NonUnique bool
+
+	// Orders are the sort orders of Fields, by position. Fields without order are sorted in ascending order.
+	Orders []SortOrder
+
+	// Lengths are the MySQL prefix lengths of varchar and text Fields, by position. A zero length indexes
+	// the whole value. They are ignored by other dialects.
+	Lengths []int
+
+	// Where is the predicate of SQLite partial indexes. Only the rows that satisfy it are indexed.
+	Where string
+}
+
+// order returns the sort order of the field at position i.
+func (ind *Index) order(i int) SortOrder {
+	if i < len(ind.Orders) {
+		return ind.Orders[i]
+	}
+	return Asc
+}
+
+// length returns the prefix length of the field at position i.
+func (ind *Index) length(i int) int {
+	if i < len(ind.Lengths) {
+		return ind.Lengths[i]
+	}
+	return 0
+}
+
+// createQuery returns the create index statement, with the given options between "index" and the index name.
+func (ind *Index) createQuery(options string, prefixLengths bool) string {
+	var fields []string
+	for i, field := range ind.Fields {
+		if prefixLengths && ind.length(i) > 0 {
+			field = fmt.Sprintf("%s(%d)", field, ind.length(i))
+		}
+		if ind.order(i) == Desc {
+			field += " desc"
+		}
+		fields = append(fields, field)
+	}
+
+	query := "create unique index "
+	if ind.NonUnique {
+		query = "create index "
+	}
+	return fmt.Sprintf("%s%s%s on %s(%s)", query, options, ind.Name, ind.Table, strings.Join(fields, ","))
+}
+
+// sameDefinition tells if ind and other index the same fields, in the same order and with the same options.
+// Prefix lengths are compared only when prefixLengths is true.
+func (ind *Index) sameDefinition(other *Index, prefixLengths bool) bool {
+	if !strings.EqualFold(ind.Name, other.Name) || ind.NonUnique != other.NonUnique || len(ind.Fields) != len(other.Fields) {
+		return false
+	}
+
+	for i, field := range ind.Fields {
+		if !strings.EqualFold(field, other.Fields[i]) || ind.order(i) != other.order(i) {
+			return false
+		}
+		if prefixLengths && ind.length(i) != other.length(i) {
+			return false
+		}
+	}
+	return strings.Join(strings.Fields(ind.Where), " ") == strings.Join(strings.Fields(other.Where), " ")
+}
+
+// indexColumn is a row of Dialect.IndexColumnsQuery.
+type indexColumn struct {
+	index  string
+	column string
+	unique bool
+	desc   bool
+	length int
+	where  string
+}
+
+func scanIndexColumn(row Row) (interface{}, error) {
+	var unique, desc int
+	c := new(indexColumn)
+	err := row.Scan(&c.index, &c.column, &unique, &desc, &c.length, &c.where)
+	c.unique = unique == 1
+	c.desc = desc == 1
+	return c, err
 }
 
 func (ind *Index) MySQLDropQuery() string {
@@ -61,9 +147,21 @@ func (ind *Index) SQLiteDropQuery() string {
 }
 
 func (ind *Index) MySQLAddQuery() string {
-	return fmt.Sprintf("create unique index %s on %s(%s)", ind.Name, ind.Table, strings.Join(ind.Fields, ","))
+	return ind.createQuery("", true)
 }
 
 func (ind *Index) SQLiteAddQuery() string {
-	return fmt.Sprintf("create unique index if not exists %s on %s(%s)", ind.Name, ind.Table, strings.Join(ind.Fields, ","))
+	query := ind.createQuery("if not exists ", false)
+	if ind.Where != "" {
+		query += " where " + ind.Where
+	}
+	return query
+}
+
+func (ind *Index) PostgresDropQuery() string {
+	return fmt.Sprintf("drop index if exists %s", ind.Name)
+}
+
+func (ind *Index) PostgresAddQuery() string {
+	return ind.createQuery("if not exists ", false)
 }
@@ -0,0 +1,121 @@
+package bome
+
+import (
+	"database/sql"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestIndex_AddQuery(t *testing.T) {
+	Convey("Indexes are rendered with their uniqueness, orders, prefix lengths and predicate", t, func() {
+		index := Index{
+			Name:    "people_name_idx",
+			Table:   "people",
+			Fields:  []string{"name", "age"},
+			Orders:  []SortOrder{Asc, Desc},
+			Lengths: []int{20},
+			Where:   "age > 18",
+
+			NonUnique: true,
+		}
+		So(index.MySQLAddQuery(), ShouldEqual, "create index people_name_idx on people(name(20),age desc)")
+		So(index.SQLiteAddQuery(), ShouldEqual, "create index if not exists people_name_idx on people(name,age desc) where age > 18")
+
+		index.NonUnique = false
+		So(index.PostgresAddQuery(), ShouldEqual, "create unique index if not exists people_name_idx on people(name,age desc)")
+	})
+}
+
+func TestDB_EnsureIndex(t *testing.T) {
+	Convey("Indexes are recreated only when their definition changes", t, func() {
+		conn, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+
+		_, err = conn.Exec("drop table if exists idx_people")
+		So(err, ShouldBeNil)
+
+		db, err := NewLite(conn)
+		So(err, ShouldBeNil)
+		db.AddTableDefinition("create table if not exists idx_people (name varchar(255) not null, age int);")
+		So(db.Init(), ShouldBeNil)
+
+		definition := func() *Index {
+			indexes, err := db.Indexes("idx_people")
+			So(err, ShouldBeNil)
+			So(indexes, ShouldHaveLength, 1)
+			return indexes[0]
+		}
+
+		index := Index{Name: "idx_people_age", Table: "idx_people", Fields: []string{"age", "name"}, Orders: []SortOrder{Desc}, NonUnique: true}
+		So(db.EnsureIndex(index), ShouldBeNil)
+		So(definition(), ShouldResemble, &Index{
+			Name:    "idx_people_age",
+			Table:   "idx_people",
+			Fields:  []string{"age", "name"},
+			Orders:  []SortOrder{Desc, Asc},
+			Lengths: []int{0, 0},
+
+			NonUnique: true,
+		})
+
+		_, err = conn.Exec("insert into idx_people values ('ada', 36), ('alan', 36)")
+		So(err, ShouldBeNil)
+
+		index.NonUnique = false
+		index.Fields = []string{"age"}
+		index.Where = "age > 40"
+		So(db.EnsureIndex(index), ShouldBeNil)
+		So(db.EnsureIndex(index), ShouldBeNil)
+
+		current := definition()
+		So(current.NonUnique, ShouldBeFalse)
+		So(current.Fields, ShouldResemble, []string{"age"})
+		So(current.Where, ShouldEqual, "age > 40")
+
+		nonUnique := index
+		nonUnique.NonUnique = true
+		So(IsIndexUniquenessError(db.EnsureIndex(nonUnique)), ShouldBeTrue)
+		So(definition().NonUnique, ShouldBeFalse)
+
+		// Without the predicate, the duplicate ages violate the unique index.
+		index.Where = ""
+		So(db.EnsureIndex(index), ShouldNotBeNil)
+
+		So(db.AddUniqueIndex(Index{Name: "idx_people_name", Table: "idx_people", Fields: []string{"name"}}, false), ShouldBeNil)
+		So(db.Exec("insert into idx_people values ('ada', 50)").Error, ShouldNotBeNil)
+
+		So(conn.Close(), ShouldBeNil)
+	})
+
+	Convey("Indexes created by previous versions of WithIndex stay unique", t, func() {
+		conn, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+
+		_, err = conn.Exec("drop table if exists upgraded_map")
+		So(err, ShouldBeNil)
+
+		m, err := Build().SetConn(conn).SetDialect(testDialect).SetTableName("upgraded_map").Map()
+		So(err, ShouldBeNil)
+		_, err = conn.Exec("create unique index if not exists upgraded_map_value on upgraded_map(value)")
+		So(err, ShouldBeNil)
+
+		m, err = Build().SetConn(conn).SetDialect(testDialect).SetTableName("upgraded_map").
+			Map(WithIndex(&Index{Name: "upgraded_map_value", Table: "$table$", Fields: []string{"value"}}))
+		So(err, ShouldBeNil)
+
+		var unique int
+		So(conn.QueryRow("select \"unique\" from pragma_index_list('upgraded_map') where name = 'upgraded_map_value'").Scan(&unique), ShouldBeNil)
+		So(unique, ShouldEqual, 1)
+
+		So(m.SaveRaw("a", `"same"`, SaveOptions{}), ShouldBeNil)
+		So(m.SaveRaw("b", `"same"`, SaveOptions{}), ShouldNotBeNil)
+
+		_, err = Build().SetConn(conn).SetDialect(testDialect).SetTableName("upgraded_map").
+			Map(WithIndex(&Index{Name: "upgraded_map_value", Table: "$table$", Fields: []string{"value"}, NonUnique: true}))
+		So(IsIndexUniquenessError(err), ShouldBeTrue)
+
+		So(conn.QueryRow("select \"unique\" from pragma_index_list('upgraded_map') where name = 'upgraded_map_value'").Scan(&unique), ShouldBeNil)
+		So(unique, ShouldEqual, 1)
+	})
+}
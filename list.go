@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"reflect"
+	"strconv"
 )
 
 type List struct {
@@ -12,7 +12,7 @@ type List struct {
 	*DB
 	tx        *TX
 	tableName string
-	dialect   string
+	dialect   Dialect
 }
 
 func (l *List) Table() string {
@@ -33,7 +33,7 @@ func (l *List) Transaction(ctx context.Context) (context.Context, *List, error)
 		}
 
 		var err error
-		tx, err = l.BeginTx()
+		tx, err = l.BeginTxContext(ctx, nil)
 		if err != nil {
 			return ctx, nil, err
 		}
@@ -41,9 +41,15 @@ func (l *List) Transaction(ctx context.Context) (context.Context, *List, error)
 		newCtx := contextWithTransaction(ctx, tx)
 		return newCtx, &List{
 			JsonValueHolder: &JsonValueHolder{
-				dialect: l.dialect,
-				tx:      tx,
+				DB:        l.DB,
+				field:     "value",
+				columns:   l.columns,
+				versioned: l.versioned,
+				keys:      l.keys,
+				dialect:   l.dialect,
+				tx:        tx,
 			},
+			DB:        l.DB,
 			tableName: l.tableName,
 			tx:        tx,
 			dialect:   l.dialect,
@@ -63,9 +69,15 @@ func (l *List) Transaction(ctx context.Context) (context.Context, *List, error)
 	newCtx := contextWithTransaction(ctx, tx)
 	return newCtx, &List{
 		JsonValueHolder: &JsonValueHolder{
-			dialect: l.dialect,
-			tx:      tx,
+			DB:        l.DB,
+			field:     "value",
+			columns:   l.columns,
+			versioned: l.versioned,
+			keys:      l.keys,
+			dialect:   l.dialect,
+			tx:        tx,
 		},
+		DB:        l.DB,
 		tableName: l.tableName,
 		tx:        tx,
 		dialect:   l.dialect,
@@ -79,23 +91,99 @@ func (l *List) Client() Client {
 	return l.DB
 }
 
+// client returns the client that runs queries for ctx. When the collection is not bound to a transaction,
+// the transaction stored in ctx is used if it was started on the same database.
+func (l *List) client(ctx context.Context) Client {
+	if l.tx == nil {
+		if tx := transaction(ctx); tx != nil && tx.db.sqlDb == l.DB.sqlDb {
+			return tx.New(l.DB)
+		}
+	}
+	return l.Client()
+}
+
 func (l *List) EditAt(index int64, path string, ex Expression) error {
+	return l.EditAtContext(context.Background(), index, path, ex)
+}
+
+func (l *List) EditAtContext(ctx context.Context, index int64, path string, ex Expression) error {
+	value, args := jsonValueSQL(l.dialect, ex)
 	rawQuery := fmt.Sprintf(
-		"update $table$ set value=json_set(value, '%s', %s) where ind=?;", path, ex.eval())
-	return l.Client().Exec(rawQuery, index).Error
+		"update $table$ set value=%s, version=version+1 where ind=?;", l.dialect.JSONSet("value", path, value))
+	return l.client(ctx).ExecContext(ctx, rawQuery, append(args, index)...).Error
+}
+
+// ApplyPatch applies the JSON Patch document patch (RFC 6902) to the value at index. Either all the operations
+// are applied or none is: an operation on a missing path or a failing test operation fails with a PatchError.
+func (l *List) ApplyPatch(index int64, patch string) error {
+	return l.ApplyPatchContext(context.Background(), index, patch)
+}
+
+func (l *List) ApplyPatchContext(ctx context.Context, index int64, patch string) error {
+	return l.JsonValueHolder.applyPatchContext(ctx, IndexColumn().Eq(IntExpr(index)), patch)
+}
+
+// ApplyMergePatch applies the JSON Merge Patch document doc (RFC 7396) to the value at index.
+func (l *List) ApplyMergePatch(index int64, doc string) error {
+	return l.ApplyMergePatchContext(context.Background(), index, doc)
+}
+
+func (l *List) ApplyMergePatchContext(ctx context.Context, index int64, doc string) error {
+	return l.JsonValueHolder.applyMergePatchContext(ctx, IndexColumn().Eq(IntExpr(index)), doc)
+}
+
+// RemoveAt removes the value found at path in the value at index.
+func (l *List) RemoveAt(index int64, path string) error {
+	return l.RemoveAtContext(context.Background(), index, path)
+}
+
+func (l *List) RemoveAtContext(ctx context.Context, index int64, path string) error {
+	return l.JsonValueHolder.RemoveAtContext(ctx, path, IndexColumn().Eq(IntExpr(index)))
+}
+
+// AppendAt appends the value of ex to the array found at path in the value at index.
+func (l *List) AppendAt(index int64, path string, ex Expression) error {
+	return l.AppendAtContext(context.Background(), index, path, ex)
+}
+
+func (l *List) AppendAtContext(ctx context.Context, index int64, path string, ex Expression) error {
+	return l.JsonValueHolder.AppendAtContext(ctx, path, ex, IndexColumn().Eq(IntExpr(index)))
+}
+
+// InsertAt inserts the value of ex at position in the array found at path in the value at index, shifting the following elements.
+func (l *List) InsertAt(index int64, path string, position int, ex Expression) error {
+	return l.InsertAtContext(context.Background(), index, path, position, ex)
+}
+
+func (l *List) InsertAtContext(ctx context.Context, index int64, path string, position int, ex Expression) error {
+	return l.JsonValueHolder.InsertAtContext(ctx, path, position, ex, IndexColumn().Eq(IntExpr(index)))
+}
+
+// IncrementAt adds delta to the number found at path in the value at index. A missing number is set to delta.
+func (l *List) IncrementAt(index int64, path string, delta int64) error {
+	return l.IncrementAtContext(context.Background(), index, path, delta)
+}
+
+func (l *List) IncrementAtContext(ctx context.Context, index int64, path string, delta int64) error {
+	return l.JsonValueHolder.IncrementAtContext(ctx, path, delta, IndexColumn().Eq(IntExpr(index)))
+}
+
+// ToggleAt negates the boolean found at path in the value at index. A missing boolean is set to true.
+func (l *List) ToggleAt(index int64, path string) error {
+	return l.ToggleAtContext(context.Background(), index, path)
+}
+
+func (l *List) ToggleAtContext(ctx context.Context, index int64, path string) error {
+	return l.JsonValueHolder.ToggleAtContext(ctx, path, IndexColumn().Eq(IntExpr(index)))
 }
 
 func (l *List) ExtractAt(index int64, path string) (string, error) {
-	var rawQuery string
-
-	if l.dialect == SQLite3 {
-		rawQuery = fmt.Sprintf(
-			"select json_extract(value, '%s') from $table$ where ind=?;", path)
-	} else {
-		rawQuery = fmt.Sprintf(
-			"select json_unquote(json_extract(value, '%s')) from $table$ where ind=?;", path)
-	}
-	o, err := l.Client().QueryFirst(rawQuery, StringScanner, index)
+	return l.ExtractAtContext(context.Background(), index, path)
+}
+
+func (l *List) ExtractAtContext(ctx context.Context, index int64, path string) (string, error) {
+	rawQuery := fmt.Sprintf("select %s from $table$ where ind=?;", l.dialect.JSONExtract("value", path))
+	o, err := l.client(ctx).QueryFirstContext(ctx, rawQuery, StringScanner, index)
 	if err != nil {
 		return "", err
 	}
@@ -103,28 +191,91 @@ func (l *List) ExtractAt(index int64, path string) (string, error) {
 }
 
 func (l *List) SaveAt(index int64, o interface{}, opts SaveOptions) error {
+	return l.SaveAtContext(context.Background(), index, o, opts)
+}
+
+func (l *List) SaveAtContext(ctx context.Context, index int64, o interface{}, opts SaveOptions) error {
 	data, err := json.Marshal(o)
 	if err != nil {
 		return err
 	}
 
-	err = l.Client().Exec("insert into $table$ values (?, ?);", index, string(data)).Error
-	if err != nil && isPrimaryKeyConstraintError(err) && opts.UpdateExisting {
-		return l.Client().Exec("update $table$ set value=? where ind=?;", string(data), index).Error
+	return l.PutAtContext(ctx, index, string(data), opts).Error
+}
+
+// PutAt saves the raw value at index and tells if it was inserted or updated. The value at index is updated
+// if opts.UpdateExisting is set, and is left unchanged if opts.OnlyIfAbsent is set. Otherwise, PutAt fails with
+// a duplicate key error.
+func (l *List) PutAt(index int64, value string, opts SaveOptions) Result {
+	return l.PutAtContext(context.Background(), index, value, opts)
+}
+
+func (l *List) PutAtContext(ctx context.Context, index int64, value string, opts SaveOptions) Result {
+	columns := []string{"ind", "value"}
+	switch {
+	case opts.OnlyIfAbsent:
+		return insertIfAbsent(ctx, l.client(ctx), l.dialect, columns, l.Keys(), index, value)
+	case opts.UpdateExisting:
+		return upsert(ctx, l.client(ctx), l.dialect, columns, l.Keys(), []string{"value"}, index, value)
+	default:
+		return insert(ctx, l.client(ctx), l.dialect, columns, index, value)
 	}
-	return err
+}
+
+// SaveMany saves entries at their index by chunks in a single transaction, or in the transaction of ctx.
+// opts applies to all entries.
+func (l *List) SaveMany(entries []*ListEntry, opts SaveOptions) BatchResult {
+	return l.SaveManyContext(context.Background(), entries, opts)
+}
+
+func (l *List) SaveManyContext(ctx context.Context, entries []*ListEntry, opts SaveOptions) BatchResult {
+	rows := make([]batchRow, 0, len(entries))
+	for _, entry := range entries {
+		rows = append(rows, batchRow{key: entry.Index, args: []interface{}{entry.Index, entry.Value}})
+	}
+	stmt := saveStatement(l.dialect, opts.mode(), []string{"ind", "value"}, l.Keys(), []string{"value"})
+
+	owned := l.tx == nil && transaction(ctx) == nil
+	ctx, tl, err := l.Transaction(ctx)
+	if err != nil {
+		return BatchResult{Error: err}
+	}
+	return endBatch(owned, tl, writeBatch(ctx, tl.client(ctx), l.dialect, stmt, rows))
+}
+
+// DeleteMany deletes the entries at indexes by chunks in a single transaction, or in the transaction of ctx.
+func (l *List) DeleteMany(indexes []int64) BatchResult {
+	return l.DeleteManyContext(context.Background(), indexes)
+}
+
+func (l *List) DeleteManyContext(ctx context.Context, indexes []int64) BatchResult {
+	rows := make([]batchRow, 0, len(indexes))
+	for _, index := range indexes {
+		rows = append(rows, batchRow{key: index, args: []interface{}{index}})
+	}
+
+	owned := l.tx == nil && transaction(ctx) == nil
+	ctx, tl, err := l.Transaction(ctx)
+	if err != nil {
+		return BatchResult{Error: err}
+	}
+	return endBatch(owned, tl, writeBatch(ctx, tl.client(ctx), l.dialect, deleteStatement(l.Keys()), rows))
 }
 
 func (l *List) Save(value string) error {
-	return l.Client().Exec("insert into $table$ (value) values (?);", value).Error
+	return l.SaveContext(context.Background(), value)
+}
+
+func (l *List) SaveContext(ctx context.Context, value string) error {
+	return l.client(ctx).ExecContext(ctx, "insert into $table$ (value) values (?);", value).Error
 }
 
 func (l *List) Read(index int64, o interface{}) error {
-	if o == nil {
-		o = reflect.New(reflect.TypeOf(o))
-	}
+	return l.ReadContext(context.Background(), index, o)
+}
 
-	value, err := l.Client().QueryFirst("select value from $table$ where ind=?;", StringScanner, index)
+func (l *List) ReadContext(ctx context.Context, index int64, o interface{}) error {
+	value, err := l.client(ctx).QueryFirstContext(ctx, "select value from $table$ where ind=?;", StringScanner, index)
 	if err != nil {
 		return err
 	}
@@ -132,7 +283,11 @@ func (l *List) Read(index int64, o interface{}) error {
 }
 
 func (l *List) MinIndex() (int64, error) {
-	res, err := l.Client().QueryFirst("select min(ind) from $table$;", IntScanner)
+	return l.MinIndexContext(context.Background())
+}
+
+func (l *List) MinIndexContext(ctx context.Context) (int64, error) {
+	res, err := l.client(ctx).QueryFirstContext(ctx, "select min(ind) from $table$;", IntScanner)
 	if err != nil {
 		return 0, err
 	}
@@ -140,7 +295,11 @@ func (l *List) MinIndex() (int64, error) {
 }
 
 func (l *List) MaxIndex() (int64, error) {
-	res, err := l.Client().QueryFirst("select max(ind) from $table$;", IntScanner)
+	return l.MaxIndexContext(context.Background())
+}
+
+func (l *List) MaxIndexContext(ctx context.Context) (int64, error) {
+	res, err := l.client(ctx).QueryFirstContext(ctx, "select max(ind) from $table$;", IntScanner)
 	if err != nil {
 		return 0, err
 	}
@@ -148,7 +307,11 @@ func (l *List) MaxIndex() (int64, error) {
 }
 
 func (l *List) Count() (int64, error) {
-	res, err := l.Client().QueryFirst("select count(ind) from $table$;", IntScanner)
+	return l.CountContext(context.Background())
+}
+
+func (l *List) CountContext(ctx context.Context) (int64, error) {
+	res, err := l.client(ctx).QueryFirstContext(ctx, "select count(ind) from $table$;", IntScanner)
 	if err != nil {
 		return 0, err
 	}
@@ -156,7 +319,12 @@ func (l *List) Count() (int64, error) {
 }
 
 func (l *List) Size(index int64) (int64, error) {
-	o, err := l.Client().QueryFirst("select coalesce(length(value)), 0) from $table$ where ind=?;", IntScanner, index)
+	return l.SizeContext(context.Background(), index)
+}
+
+func (l *List) SizeContext(ctx context.Context, index int64) (int64, error) {
+	rawQuery := fmt.Sprintf("select coalesce(%s, 0) from $table$ where ind=?;", l.dialect.Length("value"))
+	o, err := l.client(ctx).QueryFirstContext(ctx, rawQuery, IntScanner, index)
 	if err != nil {
 		return 0, err
 	}
@@ -164,7 +332,12 @@ func (l *List) Size(index int64) (int64, error) {
 }
 
 func (l *List) TotalSize() (int64, error) {
-	o, err := l.Client().QueryFirst("select coalesce(sum(length(value)), 0) from $table$;", IntScanner)
+	return l.TotalSizeContext(context.Background())
+}
+
+func (l *List) TotalSizeContext(ctx context.Context) (int64, error) {
+	rawQuery := fmt.Sprintf("select coalesce(sum(%s), 0) from $table$;", l.dialect.Length("value"))
+	o, err := l.client(ctx).QueryFirstContext(ctx, rawQuery, IntScanner)
 	if err != nil {
 		return 0, err
 	}
@@ -172,38 +345,64 @@ func (l *List) TotalSize() (int64, error) {
 }
 
 func (l *List) ReadNext(index int64, o interface{}) error {
-	if o == nil {
-		o = reflect.New(reflect.TypeOf(o))
-	}
+	return l.ReadNextContext(context.Background(), index, o)
+}
 
-	value, err := l.Client().QueryFirst("select * from $table$ where ind>? order by ind;", ListEntryScanner, index)
+func (l *List) ReadNextContext(ctx context.Context, index int64, o interface{}) error {
+	value, err := l.client(ctx).QueryFirstContext(ctx, "select ind, value from $table$ where ind>? order by ind;", ListEntryScanner, index)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal([]byte(value.(string)), o)
+	return json.Unmarshal([]byte(value.(*ListEntry).Value), o)
 }
 
 func (l *List) RangeFrom(index int64, offset, count int) (Cursor, error) {
-	return l.Client().Query("select value from $table$ where ind>? order by ind limit ?, ?;", StringScanner, index, offset, count)
+	return l.RangeFromContext(context.Background(), index, offset, count)
+}
+
+func (l *List) RangeFromContext(ctx context.Context, index int64, offset, count int) (Cursor, error) {
+	return l.client(ctx).QueryContext(ctx, "select value from $table$ where ind>? order by ind limit ?, ?;", StringScanner, index, offset, count)
 }
 
 func (l *List) Range(offset, count int) (Cursor, error) {
-	return l.Client().Query("select * from $table$ order by ind limit ?, ?;", ListEntryScanner, offset, count)
+	return l.RangeContext(context.Background(), offset, count)
+}
+
+func (l *List) RangeContext(ctx context.Context, offset, count int) (Cursor, error) {
+	return l.client(ctx).QueryContext(ctx, "select ind, value from $table$ order by ind limit ?, ?;", ListEntryScanner, offset, count)
+}
+
+// Page returns the page of entries described by req, in index order.
+func (l *List) Page(ctx context.Context, req PageRequest) (*PageResult[*ListEntry], error) {
+	return readPage(ctx, l.client(ctx), &keyset[*ListEntry]{
+		columns:   "ind, value",
+		keys:      l.Keys(),
+		scanner:   ListEntryScanner,
+		condition: l.condition,
+		key: func(e *ListEntry) []string {
+			return []string{strconv.FormatInt(e.Index, 10)}
+		},
+		args: intKeyArgs,
+	}, req)
 }
 
 func (l *List) IndexInRange(after, before int64) (Cursor, int64, error) {
+	return l.IndexInRangeContext(context.Background(), after, before)
+}
+
+func (l *List) IndexInRangeContext(ctx context.Context, after, before int64) (Cursor, int64, error) {
 	var (
 		total int64
 		c     Cursor
 	)
 
-	o, err := l.Client().QueryFirst("select count(ind) from $table$ where ind > ? and ind < ?;", IntScanner, after, before)
+	o, err := l.client(ctx).QueryFirstContext(ctx, "select count(ind) from $table$ where ind > ? and ind < ?;", IntScanner, after, before)
 	if err != nil {
 		return nil, 0, err
 	}
 	total = o.(int64)
 
-	c, err = l.Client().Query("select * from $table$ where ind > ? and ind < ?;", ListEntryScanner, after, before)
+	c, err = l.client(ctx).QueryContext(ctx, "select ind, value from $table$ where ind > ? and ind < ?;", ListEntryScanner, after, before)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -212,31 +411,47 @@ func (l *List) IndexInRange(after, before int64) (Cursor, int64, error) {
 }
 
 func (l *List) IndexBefore(index int64) (Cursor, int64, error) {
-	o, err := l.Client().QueryFirst("select count(ind) from $table$ where ind < ?;", IntScanner, index)
+	return l.IndexBeforeContext(context.Background(), index)
+}
+
+func (l *List) IndexBeforeContext(ctx context.Context, index int64) (Cursor, int64, error) {
+	o, err := l.client(ctx).QueryFirstContext(ctx, "select count(ind) from $table$ where ind < ?;", IntScanner, index)
 	if err != nil {
 		return nil, 0, err
 	}
 	total := o.(int64)
-	c, err := l.Client().Query("select * from $table$ where ind<? order by ind;", ListEntryScanner, index)
+	c, err := l.client(ctx).QueryContext(ctx, "select ind, value from $table$ where ind<? order by ind;", ListEntryScanner, index)
 	return c, total, err
 }
 
 func (l *List) IndexAfter(index int64) (Cursor, int64, error) {
-	o, err := l.Client().QueryFirst("select count(ind) from $table$ where ind>?;", IntScanner, index)
+	return l.IndexAfterContext(context.Background(), index)
+}
+
+func (l *List) IndexAfterContext(ctx context.Context, index int64) (Cursor, int64, error) {
+	o, err := l.client(ctx).QueryFirstContext(ctx, "select count(ind) from $table$ where ind>?;", IntScanner, index)
 	if err != nil {
 		return nil, 0, err
 	}
 	total := o.(int64)
-	c, err := l.Client().Query("select * from $table$ where ind>? order by ind;", ListEntryScanner, index)
+	c, err := l.client(ctx).QueryContext(ctx, "select ind, value from $table$ where ind>? order by ind;", ListEntryScanner, index)
 	return c, total, err
 }
 
 func (l *List) Delete(index int64) error {
-	return l.Client().Exec("delete from $table$ where ind=?;", index).Error
+	return l.DeleteContext(context.Background(), index)
+}
+
+func (l *List) DeleteContext(ctx context.Context, index int64) error {
+	return l.client(ctx).ExecContext(ctx, "delete from $table$ where ind=?;", index).Error
 }
 
 func (l *List) Clear() error {
-	return l.Client().Exec("delete from $table$;").Error
+	return l.ClearContext(context.Background())
+}
+
+func (l *List) ClearContext(ctx context.Context) error {
+	return l.client(ctx).ExecContext(ctx, "delete from $table$;").Error
 }
 
 func (l *List) Close() error {
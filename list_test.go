@@ -1,6 +1,7 @@
 package bome
 
 import (
+	"context"
 	"database/sql"
 	"os"
 	"testing"
@@ -85,6 +86,85 @@ func TestJsonListDB_ExtractAt(t *testing.T) {
 	})
 }
 
+func TestJsonListDB_PutAt(t *testing.T) {
+	Convey("PutAt tells if the value was inserted or updated", t, func() {
+		initJsonList(t)
+
+		result := dbJsonList.PutAt(100, `{"put": 1}`, SaveOptions{UpdateExisting: true})
+		So(result.Error, ShouldBeNil)
+		So(result.Inserted, ShouldEqual, 1)
+
+		result = dbJsonList.PutAt(100, `{"put": 2}`, SaveOptions{UpdateExisting: true})
+		So(result.Error, ShouldBeNil)
+		So(result.Updated, ShouldEqual, 1)
+
+		result = dbJsonList.PutAt(100, `{"put": 3}`, SaveOptions{OnlyIfAbsent: true})
+		So(result.Error, ShouldBeNil)
+		So(result.Inserted+result.Updated, ShouldEqual, 0)
+
+		result = dbJsonList.PutAt(100, `{"put": 3}`, SaveOptions{})
+		So(result.Error, ShouldNotBeNil)
+
+		value, err := dbJsonList.ExtractAt(100, "$.put")
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, "2")
+
+		So(dbJsonList.Delete(100), ShouldBeNil)
+	})
+}
+
+func TestJsonListDB_Page(t *testing.T) {
+	Convey("Page reads entries in index order", t, func() {
+		initJsonList(t)
+
+		for i := int64(200); i < 205; i++ {
+			So(dbJsonList.SaveAt(i, map[string]int{"page": 1}, SaveOptions{}), ShouldBeNil)
+		}
+
+		req := PageRequest{Limit: 3, Filter: JsonAtEq("$.page", IntExpr(1)), Desc: true}
+		page, err := dbJsonList.Page(context.Background(), req)
+		So(err, ShouldBeNil)
+		So(page.Entries, ShouldHaveLength, 3)
+		So(page.Entries[0].Index, ShouldEqual, 204)
+
+		req.After = page.Next
+		page, err = dbJsonList.Page(context.Background(), req)
+		So(err, ShouldBeNil)
+		So(page.Entries, ShouldHaveLength, 2)
+		So(page.Entries[1].Index, ShouldEqual, 200)
+		So(page.Next, ShouldBeEmpty)
+
+		So(dbJsonList.DeleteMany([]int64{200, 201, 202, 203, 204}).Error, ShouldBeNil)
+	})
+}
+
+func TestJsonListDB_IndexConditions(t *testing.T) {
+	Convey("RangeOf filters on index ranges", t, func() {
+		initJsonList(t)
+
+		for i := int64(300); i < 305; i++ {
+			So(dbJsonList.SaveAt(i, i, SaveOptions{}), ShouldBeNil)
+		}
+
+		c, err := dbJsonList.RangeOf(IndexColumn().Between(IntExpr(301), IntExpr(303)), ListEntryScanner, 0, 10, QueryOptions{Sort: []SortKey{OrderByNumber("$", Desc)}})
+		So(err, ShouldBeNil)
+
+		var indexes []int64
+		for c.HasNext() {
+			o, err := c.Entry()
+			So(err, ShouldBeNil)
+			indexes = append(indexes, o.(*ListEntry).Index)
+		}
+		So(c.Close(), ShouldBeNil)
+		So(indexes, ShouldResemble, []int64{303, 302, 301})
+
+		_, err = dbJsonList.Where(Key().Eq(StringExpr("a")))
+		So(IsUnknownColumn(err), ShouldBeTrue)
+
+		So(dbJsonList.DeleteMany([]int64{300, 301, 302, 303, 304}).Error, ShouldBeNil)
+	})
+}
+
 func TestJsonListDB_Clear(t *testing.T) {
 	Convey("Clear all entries", t, func() {
 		err := dbJsonList.Clear()
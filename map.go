@@ -14,8 +14,9 @@ type Map struct {
 	*DB
 	*JsonValueHolder
 	tx        *TX
-	dialect   string
+	dialect   Dialect
 	tableName string
+	sweeper   *sweeper
 }
 
 func (m *Map) Table() string {
@@ -36,7 +37,7 @@ func (m *Map) Transaction(ctx context.Context) (context.Context, *Map, error) {
 		}
 
 		var err error
-		tx, err = m.BeginTx()
+		tx, err = m.BeginTxContext(ctx, nil)
 		if err != nil {
 			return ctx, nil, err
 		}
@@ -44,9 +45,16 @@ func (m *Map) Transaction(ctx context.Context) (context.Context, *Map, error) {
 		newCtx := contextWithTransaction(ctx, tx)
 		return newCtx, &Map{
 			JsonValueHolder: &JsonValueHolder{
-				dialect: m.dialect,
-				tx:      tx,
+				DB:        m.DB,
+				field:     "value",
+				columns:   m.columns,
+				expiry:    m.expiry,
+				versioned: m.versioned,
+				keys:      m.keys,
+				dialect:   m.dialect,
+				tx:        tx,
 			},
+			DB:        m.DB,
 			tableName: m.tableName,
 			tx:        tx,
 			dialect:   m.dialect,
@@ -66,9 +74,16 @@ func (m *Map) Transaction(ctx context.Context) (context.Context, *Map, error) {
 	newCtx := contextWithTransaction(ctx, tx)
 	return newCtx, &Map{
 		JsonValueHolder: &JsonValueHolder{
-			dialect: m.dialect,
-			tx:      tx,
+			DB:        m.DB,
+			field:     "value",
+			columns:   m.columns,
+			expiry:    m.expiry,
+			versioned: m.versioned,
+			keys:      m.keys,
+			dialect:   m.dialect,
+			tx:        tx,
 		},
+		DB:        m.DB,
 		tableName: m.tableName,
 		tx:        tx,
 		dialect:   m.dialect,
@@ -96,33 +111,209 @@ func (m *Map) Client() Client {
 	return m.DB
 }
 
+// client returns the client that runs queries for ctx. When the collection is not bound to a transaction,
+// the transaction stored in ctx is used if it was started on the same database.
+func (m *Map) client(ctx context.Context) Client {
+	if m.tx == nil {
+		if tx := transaction(ctx); tx != nil && tx.db.sqlDb == m.DB.sqlDb {
+			return tx.New(m.DB)
+		}
+	}
+	return m.Client()
+}
+
 func (m *Map) Save(key string, o interface{}, opts SaveOptions) error {
+	return m.SaveContext(context.Background(), key, o, opts)
+}
+
+func (m *Map) SaveContext(ctx context.Context, key string, o interface{}, opts SaveOptions) error {
+	return m.PutContext(ctx, key, o, opts).Error
+}
+
+func (m *Map) SaveRaw(key string, value string, opts SaveOptions) error {
+	return m.SaveRawContext(context.Background(), key, value, opts)
+}
+
+func (m *Map) SaveRawContext(ctx context.Context, key string, value string, opts SaveOptions) error {
+	return m.PutRawContext(ctx, key, value, opts).Error
+}
+
+// Put is like Save but its result tells if the entry was inserted or updated.
+func (m *Map) Put(key string, o interface{}, opts SaveOptions) Result {
+	return m.PutContext(context.Background(), key, o, opts)
+}
+
+func (m *Map) PutContext(ctx context.Context, key string, o interface{}, opts SaveOptions) Result {
+	data, err := json.Marshal(o)
+	if err != nil {
+		return Result{Error: err}
+	}
+	return m.PutRawContext(ctx, key, string(data), opts)
+}
+
+// PutRaw is like SaveRaw but its result tells if the entry was inserted or updated.
+func (m *Map) PutRaw(key string, value string, opts SaveOptions) Result {
+	return m.PutRawContext(context.Background(), key, value, opts)
+}
+
+// PutRawContext saves the key entry in a single statement when opts.UpdateExisting or opts.OnlyIfAbsent is set.
+// An existing entry is replaced if it is expired. Otherwise, it fails with a duplicate key error unless
+// opts.UpdateExisting is set, or is left unchanged if opts.OnlyIfAbsent is set.
+func (m *Map) PutRawContext(ctx context.Context, key string, value string, opts SaveOptions) Result {
+	expiresAt := opts.expiresAt()
+	columns := []string{"name", "value", "expires_at"}
+
+	if opts.UpdateExisting && !opts.OnlyIfAbsent {
+		return upsert(ctx, m.client(ctx), m.dialect, columns, m.Keys(), []string{"value", "expires_at"}, key, value, expiresAt)
+	}
+
+	var result Result
+	if opts.OnlyIfAbsent {
+		result = insertIfAbsent(ctx, m.client(ctx), m.dialect, columns, m.Keys(), key, value, expiresAt)
+	} else {
+		result = insert(ctx, m.client(ctx), m.dialect, columns, key, value, expiresAt)
+	}
+	if result.Inserted == 1 || (result.Error != nil && !m.dialect.IsDuplicateKeyError(result.Error)) {
+		return result
+	}
+
+	replaced := m.client(ctx).ExecContext(ctx, "update $table$ set value=?, expires_at=?, version=version+1 where name=? and expires_at<=?;",
+		value, expiresAt, key, nowMillis())
+	if replaced.Error != nil || replaced.AffectedRows == 0 {
+		return result
+	}
+	replaced.Updated = replaced.AffectedRows
+	return replaced
+}
+
+// SaveMany saves entries by chunks in a single transaction, or in the transaction of ctx. Entry values are JSON encoded
+// and opts applies to all of them. Expired entries of the saved keys are purged first unless opts.UpdateExisting is set.
+func (m *Map) SaveMany(entries []*MapEntry, opts SaveOptions) BatchResult {
+	return m.SaveManyContext(context.Background(), entries, opts)
+}
+
+func (m *Map) SaveManyContext(ctx context.Context, entries []*MapEntry, opts SaveOptions) BatchResult {
+	expiresAt := opts.expiresAt()
+	rows := make([]batchRow, 0, len(entries))
+	for _, entry := range entries {
+		rows = append(rows, batchRow{key: entry.Key, args: []interface{}{entry.Key, entry.Value, expiresAt}})
+	}
+	stmt := saveStatement(m.dialect, opts.mode(), []string{"name", "value", "expires_at"}, m.Keys(), []string{"value", "expires_at"})
+
+	owned := m.tx == nil && transaction(ctx) == nil
+	ctx, tm, err := m.Transaction(ctx)
+	if err != nil {
+		return BatchResult{Error: err}
+	}
+
+	if opts.mode() != saveUpsert {
+		keyRows := make([]batchRow, 0, len(rows))
+		for _, row := range rows {
+			keyRows = append(keyRows, batchRow{key: row.key, args: row.args[:1]})
+		}
+		if purged := writeBatch(ctx, tm.client(ctx), m.dialect, purgeExpiredStatement(m.Keys()), keyRows); purged.Error != nil {
+			return endBatch(owned, tm, BatchResult{Error: purged.Error})
+		}
+	}
+	return endBatch(owned, tm, writeBatch(ctx, tm.client(ctx), m.dialect, stmt, rows))
+}
+
+// DeleteMany deletes the entries of keys by chunks in a single transaction, or in the transaction of ctx.
+func (m *Map) DeleteMany(keys []string) BatchResult {
+	return m.DeleteManyContext(context.Background(), keys)
+}
+
+func (m *Map) DeleteManyContext(ctx context.Context, keys []string) BatchResult {
+	rows := make([]batchRow, 0, len(keys))
+	for _, key := range keys {
+		rows = append(rows, batchRow{key: key, args: []interface{}{key}})
+	}
+
+	owned := m.tx == nil && transaction(ctx) == nil
+	ctx, tm, err := m.Transaction(ctx)
+	if err != nil {
+		return BatchResult{Error: err}
+	}
+	return endBatch(owned, tm, writeBatch(ctx, tm.client(ctx), m.dialect, deleteStatement(m.Keys()), rows))
+}
+
+// SaveIfVersion is like Save but fails with a VersionConflictError if the key entry version is not expectedVersion.
+// An expectedVersion of 0 means that the key entry must not exist.
+func (m *Map) SaveIfVersion(key string, o interface{}, expectedVersion int64) error {
+	return m.SaveIfVersionContext(context.Background(), key, o, expectedVersion)
+}
+
+func (m *Map) SaveIfVersionContext(ctx context.Context, key string, o interface{}, expectedVersion int64) error {
 	data, err := json.Marshal(o)
 	if err != nil {
 		return err
 	}
+	return m.SaveRawIfVersionContext(ctx, key, string(data), expectedVersion)
+}
 
-	err = m.Client().Exec("insert into $table$ values (?, ?);", key, string(data)).Error
-	if opts.UpdateExisting && isPrimaryKeyConstraintError(err) {
-		return m.Client().Exec("update $table$ set value=? where name=?;", string(data), key).Error
+// SaveRawIfVersion is like SaveRaw but fails with a VersionConflictError if the key entry version is not expectedVersion.
+// An expectedVersion of 0 means that the key entry must not exist.
+func (m *Map) SaveRawIfVersion(key string, value string, expectedVersion int64) error {
+	return m.SaveRawIfVersionContext(context.Background(), key, value, expectedVersion)
+}
+
+func (m *Map) SaveRawIfVersionContext(ctx context.Context, key string, value string, expectedVersion int64) error {
+	if expectedVersion == 0 {
+		result := m.PutRawContext(ctx, key, value, SaveOptions{OnlyIfAbsent: true})
+		if result.Error == nil && result.Inserted+result.Updated == 0 {
+			return &VersionConflictError{Expected: expectedVersion}
+		}
+		return result.Error
 	}
-	return err
+
+	result := m.client(ctx).ExecContext(ctx, "update $table$ set value=?, version=version+1 where name=? and version=? and "+notExpired+";",
+		value, key, expectedVersion, nowMillis())
+	return versionResultError(result, expectedVersion)
 }
 
-func (m *Map) SaveRaw(key string, value string, opts SaveOptions) error {
-	err := m.Client().Exec("insert into $table$ values (?, ?);", key, value).Error
-	if opts.UpdateExisting && isPrimaryKeyConstraintError(err) {
-		return m.Client().Exec("update $table$ set value=? where name=?;", value, key).Error
+// CompareAndSwap replaces the key value with newValue if it is the same JSON value as oldValue.
+// It fails with a VersionConflictError if the value is different or is modified concurrently.
+func (m *Map) CompareAndSwap(key string, oldValue, newValue interface{}) error {
+	return m.CompareAndSwapContext(context.Background(), key, oldValue, newValue)
+}
+
+func (m *Map) CompareAndSwapContext(ctx context.Context, key string, oldValue, newValue interface{}) error {
+	oldData, err := json.Marshal(oldValue)
+	if err != nil {
+		return err
 	}
-	return err
+
+	newData, err := json.Marshal(newValue)
+	if err != nil {
+		return err
+	}
+	return m.CompareAndSwapRawContext(ctx, key, string(oldData), string(newData))
 }
 
-func (m *Map) Get(key string, o interface{}) error {
-	if o == nil {
-		o = reflect.New(reflect.TypeOf(o))
+// CompareAndSwapRaw replaces the key value with newValue if it is the same JSON value as oldValue.
+// It fails with a VersionConflictError if the value is different or is modified concurrently.
+func (m *Map) CompareAndSwapRaw(key string, oldValue, newValue string) error {
+	return m.CompareAndSwapRawContext(context.Background(), key, oldValue, newValue)
+}
+
+func (m *Map) CompareAndSwapRawContext(ctx context.Context, key string, oldValue, newValue string) error {
+	current, version, err := m.GetRawWithVersionContext(ctx, key)
+	if err != nil {
+		return err
 	}
 
-	value, err := m.Client().QueryFirst("select value from $table$ where name=?;", StringScanner, key)
+	if !sameJSON(current, oldValue) {
+		return &VersionConflictError{Expected: version}
+	}
+	return m.SaveRawIfVersionContext(ctx, key, newValue, version)
+}
+
+func (m *Map) Get(key string, o interface{}) error {
+	return m.GetContext(context.Background(), key, o)
+}
+
+func (m *Map) GetContext(ctx context.Context, key string, o interface{}) error {
+	value, err := m.client(ctx).QueryFirstContext(ctx, "select value from $table$ where name=? and "+notExpired+";", StringScanner, key, nowMillis())
 	if err != nil {
 		return err
 	}
@@ -131,15 +322,106 @@ func (m *Map) Get(key string, o interface{}) error {
 }
 
 func (m *Map) GetRaw(key string) (string, error) {
-	value, err := m.Client().QueryFirst("select value from $table$ where name=?;", StringScanner, key)
+	return m.GetRawContext(context.Background(), key)
+}
+
+func (m *Map) GetRawContext(ctx context.Context, key string) (string, error) {
+	value, err := m.client(ctx).QueryFirstContext(ctx, "select value from $table$ where name=? and "+notExpired+";", StringScanner, key, nowMillis())
 	if err != nil {
 		return "", err
 	}
 	return value.(string), nil
 }
 
+// GetMany decodes the values of keys in o and returns the keys that were not found. o must point to a map
+// of string keys, which receives the found values, or to a slice, which receives the found values in keys order.
+// Keys are read by chunks sized to the dialect placeholders limit.
+func (m *Map) GetMany(keys []string, o interface{}) ([]string, error) {
+	return m.GetManyContext(context.Background(), keys, o)
+}
+
+func (m *Map) GetManyContext(ctx context.Context, keys []string, o interface{}) ([]string, error) {
+	result, err := m.GetRawManyContext(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	if t := reflect.TypeOf(o); t != nil && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Slice {
+		values := make([]json.RawMessage, 0, len(result.Found))
+		for _, value := range result.Ordered() {
+			values = append(values, json.RawMessage(value))
+		}
+		data, err = json.Marshal(values)
+	} else {
+		values := make(map[string]json.RawMessage, len(result.Found))
+		for key, value := range result.Found {
+			values[key] = json.RawMessage(value)
+		}
+		data, err = json.Marshal(values)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.Missing, json.Unmarshal(data, o)
+}
+
+// GetRawMany returns the values of keys. Keys are read by chunks sized to the dialect placeholders limit.
+func (m *Map) GetRawMany(keys []string) (*BatchRead[string, string], error) {
+	return m.GetRawManyContext(context.Background(), keys)
+}
+
+func (m *Map) GetRawManyContext(ctx context.Context, keys []string) (*BatchRead[string, string], error) {
+	return readBatch(ctx, m.client(ctx), m.dialect, &batchQuery[string, string]{
+		query: func(n int) string {
+			return "select name, value from $table$ where " + inKeys(m.Keys(), n) + " and " + notExpired + ";"
+		},
+		args: func(key string) []interface{} {
+			return []interface{}{key}
+		},
+		extra:   []interface{}{nowMillis()},
+		scanner: MapEntryScanner,
+		entry: func(o interface{}) (string, string) {
+			entry := o.(*MapEntry)
+			return entry.Key, entry.Value
+		},
+	}, keys)
+}
+
+// GetWithVersion decodes the key value in o and returns the key entry version.
+func (m *Map) GetWithVersion(key string, o interface{}) (int64, error) {
+	return m.GetWithVersionContext(context.Background(), key, o)
+}
+
+func (m *Map) GetWithVersionContext(ctx context.Context, key string, o interface{}) (int64, error) {
+	value, version, err := m.GetRawWithVersionContext(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return version, json.Unmarshal([]byte(value), o)
+}
+
+// GetRawWithVersion returns the key value and the key entry version.
+func (m *Map) GetRawWithVersion(key string) (string, int64, error) {
+	return m.GetRawWithVersionContext(context.Background(), key)
+}
+
+func (m *Map) GetRawWithVersionContext(ctx context.Context, key string) (string, int64, error) {
+	o, err := m.client(ctx).QueryFirstContext(ctx, "select value, version from $table$ where name=? and "+notExpired+";", VersionedValueScanner, key, nowMillis())
+	if err != nil {
+		return "", 0, err
+	}
+	v := o.(*VersionedValue)
+	return v.Value, v.Version, nil
+}
+
 func (m *Map) Size(key string) (int64, error) {
-	o, err := m.Client().QueryFirst("select coalesce(length(value), 0) from $table$ where name=?;", IntScanner, key)
+	return m.SizeContext(context.Background(), key)
+}
+
+func (m *Map) SizeContext(ctx context.Context, key string) (int64, error) {
+	rawQuery := fmt.Sprintf("select coalesce(%s, 0) from $table$ where name=? and %s;", m.dialect.Length("value"), notExpired)
+	o, err := m.client(ctx).QueryFirstContext(ctx, rawQuery, IntScanner, key, nowMillis())
 	if err != nil {
 		return 0, err
 	}
@@ -147,7 +429,12 @@ func (m *Map) Size(key string) (int64, error) {
 }
 
 func (m *Map) TotalSize() (int64, error) {
-	o, err := m.Client().QueryFirst("select coalesce(sum(length(value)), 0) from $table$;", IntScanner)
+	return m.TotalSizeContext(context.Background())
+}
+
+func (m *Map) TotalSizeContext(ctx context.Context) (int64, error) {
+	rawQuery := fmt.Sprintf("select coalesce(sum(%s), 0) from $table$ where %s;", m.dialect.Length("value"), notExpired)
+	o, err := m.client(ctx).QueryFirstContext(ctx, rawQuery, IntScanner, nowMillis())
 	if err != nil {
 		return 0, err
 	}
@@ -155,7 +442,11 @@ func (m *Map) TotalSize() (int64, error) {
 }
 
 func (m *Map) Contains(key string) (bool, error) {
-	res, err := m.Client().QueryFirst("select 1 from $table$ where name=?;", BoolScanner, key)
+	return m.ContainsContext(context.Background(), key)
+}
+
+func (m *Map) ContainsContext(ctx context.Context, key string) (bool, error) {
+	res, err := m.client(ctx).QueryFirstContext(ctx, "select 1 from $table$ where name=? and "+notExpired+";", BoolScanner, key, nowMillis())
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return false, nil
@@ -166,7 +457,11 @@ func (m *Map) Contains(key string) (bool, error) {
 }
 
 func (m *Map) Range(offset, count int) ([]*MapEntry, error) {
-	c, err := m.Client().Query("select * from $table$ limit ?, ?;", MapEntryScanner, offset, count)
+	return m.RangeContext(context.Background(), offset, count)
+}
+
+func (m *Map) RangeContext(ctx context.Context, offset, count int) ([]*MapEntry, error) {
+	c, err := m.client(ctx).QueryContext(ctx, "select name, value from $table$ where "+notExpired+" limit ?, ?;", MapEntryScanner, nowMillis(), offset, count)
 	if err != nil {
 		return nil, err
 	}
@@ -187,24 +482,61 @@ func (m *Map) Range(offset, count int) ([]*MapEntry, error) {
 	return entries, nil
 }
 
+// Page returns the page of entries described by req, in key order.
+func (m *Map) Page(ctx context.Context, req PageRequest) (*PageResult[*MapEntry], error) {
+	return readPage(ctx, m.client(ctx), &keyset[*MapEntry]{
+		columns:   "name, value",
+		keys:      m.Keys(),
+		scanner:   MapEntryScanner,
+		condition: m.condition,
+		visible:   m.visible,
+		key: func(e *MapEntry) []string {
+			return []string{e.Key}
+		},
+		args: stringKeyArgs,
+	}, req)
+}
+
 func (m *Map) Delete(key string) error {
-	return m.Client().Exec("delete from $table$ where name=?;", key).Error
+	return m.DeleteContext(context.Background(), key)
+}
+
+func (m *Map) DeleteContext(ctx context.Context, key string) error {
+	return m.client(ctx).ExecContext(ctx, "delete from $table$ where name=?;", key).Error
 }
 
 func (m *Map) List() (Cursor, error) {
-	return m.Client().Query("select * from $table$;", MapEntryScanner)
+	return m.ListContext(context.Background())
+}
+
+func (m *Map) ListContext(ctx context.Context) (Cursor, error) {
+	return m.client(ctx).QueryContext(ctx, "select name, value from $table$ where "+notExpired+";", MapEntryScanner, nowMillis())
 }
 
 func (m *Map) Clear() error {
-	return m.Client().Exec("delete from $table$;").Error
+	return m.ClearContext(context.Background())
+}
+
+func (m *Map) ClearContext(ctx context.Context) error {
+	return m.client(ctx).ExecContext(ctx, "delete from $table$;").Error
 }
 
 func (m *Map) Close() error {
+	m.sweeper.Stop()
 	return m.DB.sqlDb.Close()
 }
 
+// PurgeExpired deletes the expired entries. It returns the number of deleted entries.
+func (m *Map) PurgeExpired(ctx context.Context) (int64, error) {
+	return purgeExpired(ctx, m.client(ctx), "name", 0)
+}
+
 func (m *Map) Count() (int64, error) {
-	o, err := m.Client().QueryFirst("select count(*) from $table$;", IntScanner)
+	return m.CountContext(context.Background())
+}
+
+func (m *Map) CountContext(ctx context.Context) (int64, error) {
+	o, err := m.client(ctx).QueryFirstContext(ctx, "select count(*) from $table$ where "+notExpired+";", IntScanner, nowMillis())
 	if err != nil {
 		return 0, err
 	}
@@ -212,67 +544,157 @@ func (m *Map) Count() (int64, error) {
 }
 
 func (m *Map) EditAll(path string, ex Expression) error {
-	rawQuery := fmt.Sprintf(
-		"update $table$ set value=json_set(value, '%s', %s);",
-		normalizedJsonPath(path),
-		ex.eval(),
-	)
-	return m.Client().Exec(rawQuery).Error
+	return m.EditAllContext(context.Background(), path, ex)
+}
+
+func (m *Map) EditAllContext(ctx context.Context, path string, ex Expression) error {
+	return m.EditAllAtContext(ctx, path, ex)
 }
 
 func (m *Map) EditAllMatching(path string, ex Expression, condition BoolExpr) error {
-	rawQuery := fmt.Sprintf(
-		"update $table$ set value=json_set(value, '%s', %s) where %s",
-		normalizedJsonPath(path),
-		ex.eval(),
-		condition.sql(),
-	)
-	return m.Client().Exec(rawQuery).Error
+	return m.EditAllMatchingContext(context.Background(), path, ex, condition)
+}
+
+func (m *Map) EditAllMatchingContext(ctx context.Context, path string, ex Expression, condition BoolExpr) error {
+	return m.JsonValueHolder.EditAtContext(ctx, path, ex, condition)
 }
 
-func (m *Map) ExtractAll(path string, condition BoolExpr, scannerName string) (Cursor, error) {
-	var rawQuery string
+// ExtractAll returns a cursor over the values found at path in the entries matching condition.
+// opts set the order of the values. Their fields are not used since a single path is extracted.
+func (m *Map) ExtractAll(path string, condition BoolExpr, scannerName string, opts ...QueryOptions) (Cursor, error) {
+	return m.ExtractAllContext(context.Background(), path, condition, scannerName, opts...)
+}
 
-	if m.dialect == SQLite3 {
-		rawQuery = fmt.Sprintf("select json_extract(value, '%s') from $table$ where %s;",
-			path,
-			condition.sql(),
-		)
-	} else {
-		rawQuery = fmt.Sprintf("select json_unquote(json_extract(value, '%s')) from $table$ where %s;",
-			path,
-			condition.sql(),
-		)
+func (m *Map) ExtractAllContext(ctx context.Context, path string, condition BoolExpr, scannerName string, opts ...QueryOptions) (Cursor, error) {
+	clause, args, err := m.condition(condition)
+	if err != nil {
+		return nil, err
 	}
+	rawQuery := fmt.Sprintf("select %s from $table$ where %s and (%s)%s;",
+		m.dialect.JSONExtract("value", path),
+		notExpired,
+		clause,
+		mergeQueryOptions(opts).orderBySQL(m.dialect, "value"),
+	)
+	return m.client(ctx).QueryContext(ctx, rawQuery, scannerName, append([]interface{}{nowMillis()}, args...)...)
+}
 
-	return m.Client().Query(rawQuery, scannerName)
+// RangeOf returns a cursor over count entries matching condition, starting at offset. opts set the order
+// and the projection of the entries.
+func (m *Map) RangeOf(condition BoolExpr, scannerName string, offset, count int, opts ...QueryOptions) (Cursor, error) {
+	return m.RangeOfContext(context.Background(), condition, scannerName, offset, count, opts...)
 }
 
-func (m *Map) RangeOf(condition BoolExpr, scannerName string, offset, count int) (Cursor, error) {
-	rawQuery := fmt.Sprintf("select * from $table$ where %s limit ?, ?;",
-		condition.sql(),
+func (m *Map) RangeOfContext(ctx context.Context, condition BoolExpr, scannerName string, offset, count int, opts ...QueryOptions) (Cursor, error) {
+	options := mergeQueryOptions(opts)
+	clause, args, err := m.condition(condition)
+	if err != nil {
+		return nil, err
+	}
+	rawQuery := fmt.Sprintf("select %s from $table$ where %s and (%s)%s limit ?, ?;",
+		options.projectedColumns(m.dialect, "name, value", "value"),
+		notExpired,
+		clause,
+		options.orderBySQL(m.dialect, "value"),
 	)
-	return m.Client().Query(rawQuery, scannerName, offset, count)
+	return m.client(ctx).QueryContext(ctx, rawQuery, scannerName, append(append([]interface{}{nowMillis()}, args...), offset, count)...)
 }
 
 func (m *Map) EditAt(key string, path string, ex Expression) error {
-	ex.setDialect(m.dialect)
-	rawQuery := fmt.Sprintf("update $table$ set value=json_set(value, '%s', %s) where name=?;",
-		normalizedJsonPath(path),
-		ex.eval())
-	return m.Client().Exec(rawQuery, key).Error
+	return m.EditAtContext(context.Background(), key, path, ex)
 }
 
-func (m *Map) ExtractAt(key string, path string) (string, error) {
-	var rawQuery string
+func (m *Map) EditAtContext(ctx context.Context, key string, path string, ex Expression) error {
+	value, args := jsonValueSQL(m.dialect, ex)
+	rawQuery := fmt.Sprintf("update $table$ set value=%s, version=version+1 where name=? and %s;",
+		m.dialect.JSONSet("value", path, value), notExpired)
+	return m.client(ctx).ExecContext(ctx, rawQuery, append(args, key, nowMillis())...).Error
+}
 
-	if m.dialect == SQLite3 {
-		rawQuery = fmt.Sprintf("select json_extract(value, '%s') from $table$ where name=?;", path)
-	} else {
-		rawQuery = fmt.Sprintf("select json_unquote(json_extract(value, '%s')) from $table$ where name=?;", path)
-	}
+// EditAtIfVersion is like EditAt but fails with a VersionConflictError if the key entry version is not expectedVersion.
+func (m *Map) EditAtIfVersion(key string, path string, ex Expression, expectedVersion int64) error {
+	return m.EditAtIfVersionContext(context.Background(), key, path, ex, expectedVersion)
+}
+
+func (m *Map) EditAtIfVersionContext(ctx context.Context, key string, path string, ex Expression, expectedVersion int64) error {
+	value, args := jsonValueSQL(m.dialect, ex)
+	rawQuery := fmt.Sprintf("update $table$ set value=%s, version=version+1 where name=? and version=? and %s;",
+		m.dialect.JSONSet("value", path, value), notExpired)
+	result := m.client(ctx).ExecContext(ctx, rawQuery, append(args, key, expectedVersion, nowMillis())...)
+	return versionResultError(result, expectedVersion)
+}
+
+// ApplyPatch applies the JSON Patch document patch (RFC 6902) to the key value. Either all the operations are applied
+// or none is: an operation on a missing path or a failing test operation fails with a PatchError.
+func (m *Map) ApplyPatch(key string, patch string) error {
+	return m.ApplyPatchContext(context.Background(), key, patch)
+}
+
+func (m *Map) ApplyPatchContext(ctx context.Context, key string, patch string) error {
+	return m.JsonValueHolder.applyPatchContext(ctx, Key().Eq(StringExpr(key)), patch)
+}
+
+// ApplyMergePatch applies the JSON Merge Patch document doc (RFC 7396) to the key value.
+func (m *Map) ApplyMergePatch(key string, doc string) error {
+	return m.ApplyMergePatchContext(context.Background(), key, doc)
+}
+
+func (m *Map) ApplyMergePatchContext(ctx context.Context, key string, doc string) error {
+	return m.JsonValueHolder.applyMergePatchContext(ctx, Key().Eq(StringExpr(key)), doc)
+}
+
+// RemoveAt removes the value found at path in the key value.
+func (m *Map) RemoveAt(key string, path string) error {
+	return m.RemoveAtContext(context.Background(), key, path)
+}
+
+func (m *Map) RemoveAtContext(ctx context.Context, key string, path string) error {
+	return m.JsonValueHolder.RemoveAtContext(ctx, path, Key().Eq(StringExpr(key)))
+}
+
+// AppendAt appends the value of ex to the array found at path in the key value.
+func (m *Map) AppendAt(key string, path string, ex Expression) error {
+	return m.AppendAtContext(context.Background(), key, path, ex)
+}
+
+func (m *Map) AppendAtContext(ctx context.Context, key string, path string, ex Expression) error {
+	return m.JsonValueHolder.AppendAtContext(ctx, path, ex, Key().Eq(StringExpr(key)))
+}
+
+// InsertAt inserts the value of ex at index in the array found at path in the key value, shifting the following elements.
+func (m *Map) InsertAt(key string, path string, index int, ex Expression) error {
+	return m.InsertAtContext(context.Background(), key, path, index, ex)
+}
+
+func (m *Map) InsertAtContext(ctx context.Context, key string, path string, index int, ex Expression) error {
+	return m.JsonValueHolder.InsertAtContext(ctx, path, index, ex, Key().Eq(StringExpr(key)))
+}
+
+// IncrementAt adds delta to the number found at path in the key value. A missing number is set to delta.
+func (m *Map) IncrementAt(key string, path string, delta int64) error {
+	return m.IncrementAtContext(context.Background(), key, path, delta)
+}
+
+func (m *Map) IncrementAtContext(ctx context.Context, key string, path string, delta int64) error {
+	return m.JsonValueHolder.IncrementAtContext(ctx, path, delta, Key().Eq(StringExpr(key)))
+}
+
+// ToggleAt negates the boolean found at path in the key value. A missing boolean is set to true.
+func (m *Map) ToggleAt(key string, path string) error {
+	return m.ToggleAtContext(context.Background(), key, path)
+}
+
+func (m *Map) ToggleAtContext(ctx context.Context, key string, path string) error {
+	return m.JsonValueHolder.ToggleAtContext(ctx, path, Key().Eq(StringExpr(key)))
+}
+
+func (m *Map) ExtractAt(key string, path string) (string, error) {
+	return m.ExtractAtContext(context.Background(), key, path)
+}
 
-	o, err := m.Client().QueryFirst(rawQuery, StringScanner, key)
+func (m *Map) ExtractAtContext(ctx context.Context, key string, path string) (string, error) {
+	rawQuery := fmt.Sprintf("select %s from $table$ where name=? and %s;", m.dialect.JSONExtract("value", path), notExpired)
+	o, err := m.client(ctx).QueryFirstContext(ctx, rawQuery, StringScanner, key, nowMillis())
 	if err != nil {
 		return "", err
 	}
@@ -1,9 +1,12 @@
 package bome
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/omecodes/errors"
@@ -159,6 +162,526 @@ func TestMap_ExtractAt(t *testing.T) {
 	})
 }
 
+func TestMap_Context(t *testing.T) {
+	Convey("Context methods use the transaction stored in the context", t, func() {
+		initDbMap(t)
+
+		ctx, _, err := dbMap.Transaction(context.Background())
+		So(err, ShouldBeNil)
+
+		err = dbMap.SaveRawContext(ctx, "ctx-key", `"ctx-value"`, SaveOptions{})
+		So(err, ShouldBeNil)
+
+		value, err := dbMap.GetRawContext(ctx, "ctx-key")
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, `"ctx-value"`)
+
+		So(Rollback(ctx), ShouldBeNil)
+
+		found, err := dbMap.Contains("ctx-key")
+		So(err, ShouldBeNil)
+		So(found, ShouldBeFalse)
+	})
+
+	Convey("Context methods fail when the context is canceled", t, func() {
+		initDbMap(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := dbMap.CountContext(ctx)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestMap_TTL(t *testing.T) {
+	Convey("Expired entries are hidden and can be replaced", t, func() {
+		initDbMap(t)
+
+		err := dbMap.SaveRaw("ttl-expired", `"old"`, SaveOptions{ExpiresAt: time.Now().Add(-time.Second)})
+		So(err, ShouldBeNil)
+
+		err = dbMap.SaveRaw("ttl-alive", `"alive"`, SaveOptions{TTL: time.Hour})
+		So(err, ShouldBeNil)
+
+		_, err = dbMap.GetRaw("ttl-expired")
+		So(errors.IsNotFound(err), ShouldBeTrue)
+
+		found, err := dbMap.Contains("ttl-expired")
+		So(err, ShouldBeNil)
+		So(found, ShouldBeFalse)
+
+		value, err := dbMap.GetRaw("ttl-alive")
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, `"alive"`)
+
+		err = dbMap.SaveRaw("ttl-alive", `"other"`, SaveOptions{})
+		So(err, ShouldNotBeNil)
+
+		err = dbMap.SaveRaw("ttl-expired", `"new"`, SaveOptions{})
+		So(err, ShouldBeNil)
+
+		value, err = dbMap.GetRaw("ttl-expired")
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, `"new"`)
+
+		So(dbMap.Delete("ttl-expired"), ShouldBeNil)
+		So(dbMap.Delete("ttl-alive"), ShouldBeNil)
+	})
+
+	Convey("Conditions and edits do not reach expired entries", t, func() {
+		initDbMap(t)
+
+		err := dbMap.SaveRaw("ttl-or-expired", `{"ttl": 1}`, SaveOptions{ExpiresAt: time.Now().Add(-time.Second)})
+		So(err, ShouldBeNil)
+
+		err = dbMap.SaveRaw("ttl-or-alive", `{"ttl": 2}`, SaveOptions{TTL: time.Hour})
+		So(err, ShouldBeNil)
+
+		cursor, err := dbMap.RangeOf(Or(StartsWith(StringExpr(`{"ttl": 2`)), StartsWith(StringExpr(`{"ttl": 1`))), MapEntryScanner, 0, 10)
+		So(err, ShouldBeNil)
+
+		var keys []string
+		for cursor.HasNext() {
+			o, err := cursor.Entry()
+			So(err, ShouldBeNil)
+			keys = append(keys, o.(*MapEntry).Key)
+		}
+		So(cursor.Close(), ShouldBeNil)
+		So(keys, ShouldResemble, []string{"ttl-or-alive"})
+
+		So(dbMap.EditAt("ttl-or-expired", "$.ttl", IntExpr(3)), ShouldBeNil)
+		So(dbMap.JsonValueHolder.EditAt("$.ttl", IntExpr(4), Or(Key().Eq(StringExpr("ttl-or-expired")), Key().Eq(StringExpr("none")))), ShouldBeNil)
+
+		value, err := dbMap.Client().QueryFirst("select value from $table$ where name=?;", StringScanner, "ttl-or-expired")
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, `{"ttl": 1}`)
+
+		So(dbMap.Delete("ttl-or-expired"), ShouldBeNil)
+		So(dbMap.Delete("ttl-or-alive"), ShouldBeNil)
+	})
+
+	Convey("Edits of all entries leave the expired ones unchanged", t, func() {
+		conn, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+		_, err = conn.Exec("drop table if exists ttl_edits")
+		So(err, ShouldBeNil)
+
+		m, err := Build().SetConn(conn).SetDialect(testDialect).SetTableName("ttl_edits").Map()
+		So(err, ShouldBeNil)
+
+		So(m.SaveRaw("expired", `{"ttl": 1}`, SaveOptions{ExpiresAt: time.Now().Add(-time.Second)}), ShouldBeNil)
+		So(m.SaveRaw("alive", `{"ttl": 1}`, SaveOptions{TTL: time.Hour}), ShouldBeNil)
+
+		So(m.EditAll("$.ttl", IntExpr(2)), ShouldBeNil)
+		So(m.EditAllMatching("$.ttl", IntExpr(3), Or(Key().Eq(StringExpr("expired")), Key().Eq(StringExpr("alive")))), ShouldBeNil)
+
+		value, err := m.Client().QueryFirst("select value from $table$ where name=?;", StringScanner, "expired")
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, `{"ttl": 1}`)
+
+		value, err = m.GetRaw("alive")
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, `{"ttl":3}`)
+
+		So(conn.Close(), ShouldBeNil)
+	})
+
+	Convey("PurgeExpired deletes the expired entries", t, func() {
+		initDbMap(t)
+
+		err := dbMap.SaveRaw("ttl-purged", `"purged"`, SaveOptions{ExpiresAt: time.Now().Add(-time.Second)})
+		So(err, ShouldBeNil)
+
+		count, err := dbMap.PurgeExpired(context.Background())
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 1)
+
+		count, err = dbMap.PurgeExpired(context.Background())
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 0)
+	})
+
+	Convey("The expiry sweeper deletes the expired entries in background", t, func() {
+		db, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+
+		_, err = db.Exec("drop table if exists swept_map")
+		So(err, ShouldBeNil)
+
+		m, err := Build().SetConn(db).SetDialect(testDialect).SetTableName("swept_map").Map(WithExpirySweeper(10*time.Millisecond, 1))
+		So(err, ShouldBeNil)
+
+		for _, key := range []string{"a", "b", "c"} {
+			err = m.SaveRaw(key, `"swept"`, SaveOptions{ExpiresAt: time.Now().Add(-time.Second)})
+			So(err, ShouldBeNil)
+		}
+
+		So(func() bool {
+			for i := 0; i < 100; i++ {
+				o, err := m.QueryFirst("select count(*) from $table$;", IntScanner)
+				if err == nil && o.(int64) == 0 {
+					return true
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			return false
+		}(), ShouldBeTrue)
+
+		So(m.Close(), ShouldBeNil)
+	})
+}
+
+func TestMap_Put(t *testing.T) {
+	Convey("Put tells if the entry was inserted or updated", t, func() {
+		initDbMap(t)
+
+		result := dbMap.PutRaw("put", `"first"`, SaveOptions{UpdateExisting: true})
+		So(result.Error, ShouldBeNil)
+		So(result.Inserted, ShouldEqual, 1)
+		So(result.Updated, ShouldEqual, 0)
+
+		result = dbMap.PutRaw("put", `"second"`, SaveOptions{UpdateExisting: true})
+		So(result.Error, ShouldBeNil)
+		So(result.Inserted, ShouldEqual, 0)
+		So(result.Updated, ShouldEqual, 1)
+
+		result = dbMap.PutRaw("put", `"third"`, SaveOptions{OnlyIfAbsent: true})
+		So(result.Error, ShouldBeNil)
+		So(result.Inserted+result.Updated, ShouldEqual, 0)
+
+		value, err := dbMap.GetRaw("put")
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, `"second"`)
+
+		result = dbMap.Put("put-absent", "absent", SaveOptions{OnlyIfAbsent: true})
+		So(result.Error, ShouldBeNil)
+		So(result.Inserted, ShouldEqual, 1)
+
+		So(dbMap.Delete("put"), ShouldBeNil)
+		So(dbMap.Delete("put-absent"), ShouldBeNil)
+	})
+}
+
+func TestMap_SaveMany(t *testing.T) {
+	Convey("SaveMany writes entries by chunks", t, func() {
+		initDbMap(t)
+
+		var entries []*MapEntry
+		var keys []string
+		for i := 0; i < 1000; i++ {
+			key := fmt.Sprintf("batch-%d", i)
+			keys = append(keys, key)
+			entries = append(entries, &MapEntry{Key: key, Value: fmt.Sprintf(`{"i": %d}`, i)})
+		}
+
+		result := dbMap.SaveMany(entries, SaveOptions{})
+		So(result.Error, ShouldBeNil)
+		So(len(result.Chunks), ShouldBeGreaterThan, 1)
+		So(result.Inserted(), ShouldEqual, 1000)
+
+		result = dbMap.SaveMany(entries[:10], SaveOptions{UpdateExisting: true})
+		So(result.Error, ShouldBeNil)
+		So(result.Updated(), ShouldEqual, 10)
+		So(result.Inserted(), ShouldEqual, 0)
+
+		result = dbMap.DeleteMany(keys)
+		So(result.Error, ShouldBeNil)
+		So(result.AffectedRows(), ShouldEqual, 1000)
+	})
+
+	Convey("SaveMany reports the first failing key and writes nothing", t, func() {
+		initDbMap(t)
+
+		err := dbMap.SaveRaw("batch-existing", `"existing"`, SaveOptions{})
+		So(err, ShouldBeNil)
+
+		result := dbMap.SaveMany([]*MapEntry{
+			{Key: "batch-new", Value: `"new"`},
+			{Key: "batch-existing", Value: `"other"`},
+		}, SaveOptions{})
+		So(result.Error, ShouldNotBeNil)
+		So(result.FailedKey, ShouldEqual, "batch-existing")
+
+		found, err := dbMap.Contains("batch-new")
+		So(err, ShouldBeNil)
+		So(found, ShouldBeFalse)
+
+		So(dbMap.Delete("batch-existing"), ShouldBeNil)
+	})
+
+	Convey("SaveMany replaces the expired entries of its keys only", t, func() {
+		initDbMap(t)
+
+		So(dbMap.SaveRaw("batch-expired", `"old"`, SaveOptions{ExpiresAt: time.Now().Add(-time.Second)}), ShouldBeNil)
+		So(dbMap.SaveRaw("batch-expired-other", `"old"`, SaveOptions{ExpiresAt: time.Now().Add(-time.Second)}), ShouldBeNil)
+
+		result := dbMap.SaveMany([]*MapEntry{{Key: "batch-expired", Value: `"new"`}}, SaveOptions{})
+		So(result.Error, ShouldBeNil)
+
+		value, err := dbMap.GetRaw("batch-expired")
+		So(err, ShouldBeNil)
+		So(value, ShouldEqual, `"new"`)
+
+		count, err := dbMap.Client().QueryFirst("select count(*) from $table$ where name=?;", IntScanner, "batch-expired-other")
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 1)
+
+		So(dbMap.Delete("batch-expired"), ShouldBeNil)
+		So(dbMap.Delete("batch-expired-other"), ShouldBeNil)
+	})
+}
+
+func TestMap_GetMany(t *testing.T) {
+	Convey("GetMany reads entries by chunks and reports missing keys", t, func() {
+		initDbMap(t)
+
+		var entries []*MapEntry
+		var keys []string
+		for i := 0; i < 1200; i++ {
+			key := fmt.Sprintf("many-%d", i)
+			keys = append(keys, key)
+			if i%2 == 0 {
+				entries = append(entries, &MapEntry{Key: key, Value: fmt.Sprintf("%d", i)})
+			}
+		}
+		So(dbMap.SaveMany(entries, SaveOptions{}).Error, ShouldBeNil)
+
+		result, err := dbMap.GetRawMany(keys)
+		So(err, ShouldBeNil)
+		So(result.Found, ShouldHaveLength, 600)
+		So(result.Missing, ShouldHaveLength, 600)
+		So(result.Missing[0], ShouldEqual, "many-1")
+		So(result.Ordered()[1], ShouldEqual, "2")
+
+		var values []int
+		missing, err := dbMap.GetMany([]string{"many-4", "many-3", "many-2"}, &values)
+		So(err, ShouldBeNil)
+		So(missing, ShouldResemble, []string{"many-3"})
+		So(values, ShouldResemble, []int{4, 2})
+
+		var byKey map[string]int
+		_, err = dbMap.GetMany([]string{"many-4", "many-2"}, &byKey)
+		So(err, ShouldBeNil)
+		So(byKey, ShouldResemble, map[string]int{"many-4": 4, "many-2": 2})
+
+		So(dbMap.DeleteMany(keys).Error, ShouldBeNil)
+	})
+}
+
+func TestMap_Page(t *testing.T) {
+	Convey("Page reads entries in key order with continuation tokens", t, func() {
+		initDbMap(t)
+
+		var entries []*MapEntry
+		var keys []string
+		for i := 0; i < 25; i++ {
+			key := fmt.Sprintf("page-%02d", i)
+			keys = append(keys, key)
+			entries = append(entries, &MapEntry{Key: key, Value: fmt.Sprintf(`{"page": 1, "n": %d}`, i)})
+		}
+		So(dbMap.SaveMany(entries, SaveOptions{}).Error, ShouldBeNil)
+
+		req := PageRequest{Limit: 10, Filter: StartsWith(StringExpr(`{"page"`))}
+		var read []string
+		for {
+			page, err := dbMap.Page(context.Background(), req)
+			So(err, ShouldBeNil)
+			for _, entry := range page.Entries {
+				read = append(read, entry.Key)
+			}
+			if page.Next == "" {
+				break
+			}
+			req.After = page.Next
+		}
+		So(read, ShouldResemble, keys)
+
+		req = PageRequest{Limit: 10, Filter: Or(StartsWith(StringExpr(`{"page": 1, "n": 2`)), StartsWith(StringExpr(`{"page"`)))}
+		read = nil
+		for i := 0; i < 5; i++ {
+			page, err := dbMap.Page(context.Background(), req)
+			So(err, ShouldBeNil)
+			for _, entry := range page.Entries {
+				read = append(read, entry.Key)
+			}
+			if page.Next == "" {
+				break
+			}
+			req.After = page.Next
+		}
+		So(read, ShouldResemble, keys)
+
+		page, err := dbMap.Page(context.Background(), PageRequest{Limit: 2, Filter: StartsWith(StringExpr(`{"page"`)), Desc: true})
+		So(err, ShouldBeNil)
+		So(page.Entries[0].Key, ShouldEqual, "page-24")
+
+		page, err = dbMap.Page(context.Background(), PageRequest{After: page.Next, Limit: 2, Filter: StartsWith(StringExpr(`{"page"`)), Desc: true})
+		So(err, ShouldBeNil)
+		So(page.Entries[0].Key, ShouldEqual, "page-22")
+
+		_, err = dbMap.Page(context.Background(), PageRequest{After: "invalid", Limit: 2})
+		So(err, ShouldEqual, ErrInvalidPageToken)
+
+		So(dbMap.DeleteMany(keys).Error, ShouldBeNil)
+	})
+}
+
+func TestMap_RangeOfQueryOptions(t *testing.T) {
+	Convey("RangeOf and ExtractAll sort values", t, func() {
+		initDbMap(t)
+
+		var keys []string
+		for i, score := range []int{5, 40, 300} {
+			key := fmt.Sprintf("scored-%d", i)
+			keys = append(keys, key)
+			So(dbMap.Save(key, map[string]interface{}{"scored": true, "score": score}, SaveOptions{}), ShouldBeNil)
+		}
+
+		filter := StartsWith(StringExpr(`{"score`))
+		c, err := dbMap.RangeOf(filter, MapEntryScanner, 0, 2, QueryOptions{
+			Sort:   []SortKey{OrderByNumber("$.score", Desc)},
+			Fields: []string{"$.score"},
+		})
+		So(err, ShouldBeNil)
+
+		var entries []*MapEntry
+		for c.HasNext() {
+			o, err := c.Entry()
+			So(err, ShouldBeNil)
+			entries = append(entries, o.(*MapEntry))
+		}
+		So(c.Close(), ShouldBeNil)
+		So(entries, ShouldResemble, []*MapEntry{
+			{Key: "scored-2", Value: `{"score":300}`},
+			{Key: "scored-1", Value: `{"score":40}`},
+		})
+
+		c, err = dbMap.ExtractAll("$.score", filter, IntScanner, QueryOptions{Sort: []SortKey{OrderBy("$.score", Asc)}})
+		So(err, ShouldBeNil)
+
+		var scores []int64
+		for c.HasNext() {
+			o, err := c.Entry()
+			So(err, ShouldBeNil)
+			scores = append(scores, o.(int64))
+		}
+		So(c.Close(), ShouldBeNil)
+		So(scores, ShouldResemble, []int64{300, 40, 5})
+
+		So(dbMap.DeleteMany(keys).Error, ShouldBeNil)
+	})
+}
+
+func TestMap_EditAtComputed(t *testing.T) {
+	Convey("EditAt sets computed values", t, func() {
+		initDbMap(t)
+
+		So(dbMap.Save("computed", map[string]interface{}{"visits": 2, "name": "Ada"}, SaveOptions{}), ShouldBeNil)
+
+		So(dbMap.EditAt("computed", "$.visits", Add(JsonAt("$.visits"), IntExpr(1))), ShouldBeNil)
+		So(dbMap.EditAt("computed", "$.likes", Add(Coalesce(JsonAt("$.likes"), IntExpr(0)), IntExpr(1))), ShouldBeNil)
+		So(dbMap.EditAt("computed", "$.half", Div(JsonAt("$.visits"), FloatExpr(2))), ShouldBeNil)
+		So(dbMap.EditAt("computed", "$.label", Concat(Upper(JsonAt("$.name")), StringExpr("-"), Lower(JsonAt("$.name")))), ShouldBeNil)
+		So(dbMap.EditAt("computed", "$.active", BoolLiteral(true)), ShouldBeNil)
+		So(dbMap.EditAt("computed", "$.deleted", NullExpr()), ShouldBeNil)
+		So(dbMap.EditAt("computed", "$.tags", JsonArrayExpr(StringExpr("a"), IntExpr(1))), ShouldBeNil)
+
+		before := time.Now().UnixMilli()
+		So(dbMap.EditAt("computed", "$.updated", Now()), ShouldBeNil)
+
+		var value struct {
+			Visits  int64         `json:"visits"`
+			Likes   int64         `json:"likes"`
+			Half    float64       `json:"half"`
+			Label   string        `json:"label"`
+			Active  bool          `json:"active"`
+			Deleted interface{}   `json:"deleted"`
+			Tags    []interface{} `json:"tags"`
+			Updated int64         `json:"updated"`
+		}
+		So(dbMap.Get("computed", &value), ShouldBeNil)
+		So(value.Visits, ShouldEqual, 3)
+		So(value.Likes, ShouldEqual, 1)
+		So(value.Half, ShouldEqual, 1.5)
+		So(value.Label, ShouldEqual, "ADA-ada")
+		So(value.Active, ShouldBeTrue)
+		So(value.Deleted, ShouldBeNil)
+		So(value.Tags, ShouldResemble, []interface{}{"a", float64(1)})
+		So(value.Updated, ShouldBeGreaterThanOrEqualTo, before)
+
+		raw, err := dbMap.GetRaw("computed")
+		So(err, ShouldBeNil)
+		So(raw, ShouldContainSubstring, `"deleted":null`)
+
+		So(dbMap.Delete("computed"), ShouldBeNil)
+	})
+}
+
+func TestMap_Version(t *testing.T) {
+	Convey("Writes increment the entry version", t, func() {
+		initDbMap(t)
+
+		err := dbMap.SaveIfVersion("versioned", map[string]int{"count": 1}, 0)
+		So(err, ShouldBeNil)
+
+		var value map[string]int
+		version, err := dbMap.GetWithVersion("versioned", &value)
+		So(err, ShouldBeNil)
+		So(version, ShouldEqual, 1)
+		So(value["count"], ShouldEqual, 1)
+
+		err = dbMap.SaveIfVersion("versioned", map[string]int{"count": 2}, 0)
+		So(IsVersionConflict(err), ShouldBeTrue)
+
+		err = dbMap.SaveIfVersion("versioned", map[string]int{"count": 2}, version)
+		So(err, ShouldBeNil)
+
+		err = dbMap.SaveIfVersion("versioned", map[string]int{"count": 3}, version)
+		So(IsVersionConflict(err), ShouldBeTrue)
+
+		err = dbMap.EditAt("versioned", "$.count", IntExpr(10))
+		So(err, ShouldBeNil)
+
+		_, version, err = dbMap.GetRawWithVersion("versioned")
+		So(err, ShouldBeNil)
+		So(version, ShouldEqual, 3)
+	})
+
+	Convey("EditAtIfVersion and CompareAndSwap fail on conflicts", t, func() {
+		initDbMap(t)
+
+		_, version, err := dbMap.GetRawWithVersion("versioned")
+		So(err, ShouldBeNil)
+
+		err = dbMap.EditAtIfVersion("versioned", "$.count", IntExpr(11), version-1)
+		So(IsVersionConflict(err), ShouldBeTrue)
+
+		err = dbMap.EditAtIfVersion("versioned", "$.count", IntExpr(11), version)
+		So(err, ShouldBeNil)
+
+		err = dbMap.CompareAndSwap("versioned", map[string]int{"count": 10}, map[string]int{"count": 12})
+		So(IsVersionConflict(err), ShouldBeTrue)
+
+		err = dbMap.CompareAndSwap("versioned", map[string]int{"count": 11}, map[string]int{"count": 12})
+		So(err, ShouldBeNil)
+
+		var value map[string]int
+		version, err = dbMap.GetWithVersion("versioned", &value)
+		So(err, ShouldBeNil)
+		So(version, ShouldEqual, 5)
+		So(value["count"], ShouldEqual, 12)
+
+		err = dbMap.CompareAndSwapRaw("versioned", `{"count": 11}`, `{"count": 13}`)
+		So(IsVersionConflict(err), ShouldBeTrue)
+
+		err = dbMap.CompareAndSwapRaw("versioned", `{ "count": 12 }`, `{"count": 13}`)
+		So(err, ShouldBeNil)
+
+		So(dbMap.Delete("versioned"), ShouldBeNil)
+	})
+}
+
 func TestJsonMap_Clear(t *testing.T) {
 	Convey("EditAllAt item", t, func() {
 		err := dbMap.Clear()
@@ -190,6 +713,94 @@ func TestMap_Clear(t *testing.T) {
 	})
 }
 
+func TestMap_ApplyPatch(t *testing.T) {
+	Convey("Patches are applied atomically to map values", t, func() {
+		initDbMap(t)
+
+		So(dbMap.SaveRaw("patched", `{"name": "Ada", "visits": 1, "tags": ["a"], "address": {"city": "Paris"}}`, SaveOptions{}), ShouldBeNil)
+
+		err := dbMap.ApplyPatch("patched", `[
+			{"op": "test", "path": "/name", "value": "Ada"},
+			{"op": "replace", "path": "/visits", "value": 2},
+			{"op": "add", "path": "/address/zip", "value": "75001"},
+			{"op": "copy", "from": "/name", "path": "/alias"},
+			{"op": "move", "from": "/tags", "path": "/labels"}
+		]`)
+		So(err, ShouldBeNil)
+
+		raw, err := dbMap.GetRaw("patched")
+		So(err, ShouldBeNil)
+		So(sameJSON(raw, `{"name": "Ada", "alias": "Ada", "visits": 2, "labels": ["a"], "address": {"city": "Paris", "zip": "75001"}}`), ShouldBeTrue)
+
+		err = dbMap.ApplyPatch("patched", `[{"op": "add", "path": "/labels/0", "value": "first"}, {"op": "remove", "path": "/alias"}]`)
+		So(err, ShouldBeNil)
+
+		raw, err = dbMap.GetRaw("patched")
+		So(err, ShouldBeNil)
+		So(sameJSON(raw, `{"name": "Ada", "visits": 2, "labels": ["first", "a"], "address": {"city": "Paris", "zip": "75001"}}`), ShouldBeTrue)
+
+		_, version, err := dbMap.GetRawWithVersion("patched")
+		So(err, ShouldBeNil)
+
+		err = dbMap.ApplyPatch("patched", `[{"op": "replace", "path": "/visits", "value": 3}, {"op": "test", "path": "/name", "value": "Bob"}]`)
+		So(IsPatchError(err), ShouldBeTrue)
+
+		err = dbMap.ApplyPatch("patched", `[{"op": "test", "path": "/visits", "value": 2}, {"op": "remove", "path": "/missing"}]`)
+		So(IsPatchError(err), ShouldBeTrue)
+
+		unchanged, newVersion, err := dbMap.GetRawWithVersion("patched")
+		So(err, ShouldBeNil)
+		So(unchanged, ShouldEqual, raw)
+		So(newVersion, ShouldEqual, version)
+
+		err = dbMap.ApplyMergePatch("patched", `{"visits": null, "address": {"city": null, "country": "FR"}, "labels": ["b"]}`)
+		So(err, ShouldBeNil)
+
+		raw, err = dbMap.GetRaw("patched")
+		So(err, ShouldBeNil)
+		So(sameJSON(raw, `{"name": "Ada", "labels": ["b"], "address": {"zip": "75001", "country": "FR"}}`), ShouldBeTrue)
+
+		err = dbMap.ApplyMergePatch("patched", `{"name": {"first": "Ada"}}`)
+		So(err, ShouldBeNil)
+
+		raw, err = dbMap.GetRaw("patched")
+		So(err, ShouldBeNil)
+		So(sameJSON(raw, `{"name": {"first": "Ada"}, "labels": ["b"], "address": {"zip": "75001", "country": "FR"}}`), ShouldBeTrue)
+
+		err = dbMap.ApplyPatch("missing", `[{"op": "add", "path": "/a", "value": 1}]`)
+		So(err, ShouldNotBeNil)
+		So(IsPatchError(err), ShouldBeFalse)
+
+		So(dbMap.Delete("patched"), ShouldBeNil)
+	})
+}
+
+func TestMap_ArrayEdits(t *testing.T) {
+	Convey("Keys are removed and arrays are edited in place", t, func() {
+		initDbMap(t)
+
+		So(dbMap.SaveRaw("edited", `{"tags": ["b"], "items": [{"n": 1}, true], "visits": 1, "active": true, "old": 1}`, SaveOptions{}), ShouldBeNil)
+
+		So(dbMap.RemoveAt("edited", "$.old"), ShouldBeNil)
+		So(dbMap.AppendAt("edited", "$.tags", StringExpr("c")), ShouldBeNil)
+		So(dbMap.InsertAt("edited", "$.tags", 0, StringExpr("a")), ShouldBeNil)
+		So(dbMap.InsertAt("edited", "$.tags", 10, StringExpr("d")), ShouldBeNil)
+		So(dbMap.InsertAt("edited", "$.items", 1, JsonExpr(StringExpr("n"), IntExpr(2))), ShouldBeNil)
+		So(dbMap.AppendAt("edited", "$.missing", StringExpr("x")), ShouldBeNil)
+		So(dbMap.IncrementAt("edited", "$.visits", 2), ShouldBeNil)
+		So(dbMap.IncrementAt("edited", "$.likes", 1), ShouldBeNil)
+		So(dbMap.ToggleAt("edited", "$.active"), ShouldBeNil)
+		So(dbMap.ToggleAt("edited", "$.shown"), ShouldBeNil)
+
+		raw, version, err := dbMap.GetRawWithVersion("edited")
+		So(err, ShouldBeNil)
+		So(sameJSON(raw, `{"tags": ["a", "b", "c", "d"], "items": [{"n": 1}, {"n": 2}, true], "visits": 3, "likes": 1, "active": false, "shown": true}`), ShouldBeTrue)
+		So(version, ShouldEqual, 11)
+
+		So(dbMap.Delete("edited"), ShouldBeNil)
+	})
+}
+
 func TestMap_Close(t *testing.T) {
 	Convey("Close Map", t, func() {
 		initDbMap(t)
@@ -0,0 +1,398 @@
+package bome
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/omecodes/errors"
+)
+
+// MigrationsTable is the name of the table in which applied migrations are recorded. Migrations are recorded
+// in the scope of the DB that applies them, which is its table name with its prefix, so that the migrations
+// of the collections of a database have their own versions.
+const MigrationsTable = "bome_migrations"
+
+// MigrationDirection tells if a migration step applies or reverts a migration.
+type MigrationDirection string
+
+const (
+	// MigrationUp applies a migration.
+	MigrationUp MigrationDirection = "up"
+
+	// MigrationDown reverts a migration.
+	MigrationDown MigrationDirection = "down"
+)
+
+// Migration is a versioned schema change. Up and Down are SQL scripts that can contain
+// several statements separated by ';' and predefined or custom defined variables.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Checksum returns the checksum of the migration up script. It is recorded when the migration is applied
+// and verified every time migrations are run, so that an applied migration cannot be silently modified.
+func (m *Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// AppliedMigration is a migration recorded in the migrations table.
+type AppliedMigration struct {
+	Scope    string
+	Version  int64
+	Name     string
+	Checksum string
+
+	// AppliedAt is the time the migration was applied at, in milliseconds since the Unix epoch.
+	AppliedAt int64
+}
+
+// MigrationStep is a migration to apply or to revert. Statements have their variables replaced.
+type MigrationStep struct {
+	Version    int64
+	Name       string
+	Direction  MigrationDirection
+	Statements []string
+}
+
+const appliedMigrationScanner = "scanAppliedMigration"
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+?)(\.(up|down))?\.sql$`)
+
+// AddMigration registers migrations.
+func (db *DB) AddMigration(migrations ...*Migration) *DB {
+	db.migrations = append(db.migrations, migrations...)
+	return db
+}
+
+// LoadMigrations registers the migrations found in the dir directory of fsys.
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql. A file named <version>_<name>.sql is an up script.
+func (db *DB) LoadMigrations(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	migrations := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		parts := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if parts == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return err
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		m, found := migrations[version]
+		if !found {
+			m = &Migration{Version: version, Name: parts[2]}
+			migrations[version] = m
+		} else if m.Name != parts[2] {
+			return errors.New()
+		}
+
+		if parts[4] == string(MigrationDown) {
+			m.Down = string(content)
+		} else {
+			m.Up = string(content)
+		}
+	}
+
+	versions := make([]int64, 0, len(migrations))
+	for version := range migrations {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] < versions[j]
+	})
+	for _, version := range versions {
+		db.AddMigration(migrations[version])
+	}
+	return nil
+}
+
+// Migrate applies the registered migrations that are not applied yet.
+func (db *DB) Migrate() error {
+	return db.MigrateContext(context.Background())
+}
+
+// MigrateContext applies the registered migrations that are not applied yet, in version order.
+// Each migration runs in its own transaction, in which it is recorded in the migrations table.
+// MySQL commits data definition statements implicitly, so on MySQL a migration that fails after
+// such a statement is left partially applied and unrecorded, and must be repaired by hand.
+func (db *DB) MigrateContext(ctx context.Context) error {
+	steps, err := db.MigrationPlan(ctx)
+	if err != nil {
+		return err
+	}
+	return db.runMigrationSteps(ctx, steps)
+}
+
+// MigrationPlan returns the steps MigrateContext would run, without running them.
+func (db *DB) MigrationPlan(ctx context.Context) ([]*MigrationStep, error) {
+	migrations, applied, err := db.migrationsState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []*MigrationStep
+	for _, m := range migrations {
+		if _, found := applied[m.Version]; found {
+			continue
+		}
+		steps = append(steps, db.migrationStep(m, true))
+	}
+	return steps, nil
+}
+
+// RollbackMigrations reverts the applied migrations which version is greater than version, from the most recent.
+// Like MigrateContext, it is not atomic on MySQL.
+func (db *DB) RollbackMigrations(ctx context.Context, version int64) error {
+	steps, err := db.RollbackMigrationsPlan(ctx, version)
+	if err != nil {
+		return err
+	}
+	return db.runMigrationSteps(ctx, steps)
+}
+
+// RollbackMigrationsPlan returns the steps RollbackMigrations would run, without running them.
+func (db *DB) RollbackMigrationsPlan(ctx context.Context, version int64) ([]*MigrationStep, error) {
+	migrations, applied, err := db.migrationsState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []*MigrationStep
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version <= version {
+			break
+		}
+		if _, found := applied[m.Version]; !found {
+			continue
+		}
+		if m.Down == "" {
+			return nil, errors.NotSupported()
+		}
+		steps = append(steps, db.migrationStep(m, false))
+	}
+	return steps, nil
+}
+
+// AppliedMigrations returns the migrations recorded in the migrations table in the scope of db, in version order.
+func (db *DB) AppliedMigrations(ctx context.Context) ([]*AppliedMigration, error) {
+	if err := db.createMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+
+	c, err := db.QueryContext(ctx, "select scope, version, name, checksum, applied_at from "+MigrationsTable+" where scope=? order by version;", appliedMigrationScanner, db.migrationScope())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = c.Close()
+	}()
+
+	var migrations []*AppliedMigration
+	for c.HasNext() {
+		o, err := c.Entry()
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, o.(*AppliedMigration))
+	}
+	return migrations, nil
+}
+
+func (db *DB) createMigrationsTable(ctx context.Context) error {
+	db.RegisterScanner(appliedMigrationScanner, NewScannerFunc(func(row Row) (interface{}, error) {
+		m := new(AppliedMigration)
+		return m, row.Scan(&m.Scope, &m.Version, &m.Name, &m.Checksum, &m.AppliedAt)
+	}))
+	return db.ExecContext(ctx, "create table if not exists "+MigrationsTable+" ("+
+		"scope varchar(255) not null,"+
+		"version bigint not null,"+
+		"name varchar(255) not null,"+
+		"checksum varchar(64) not null,"+
+		"applied_at bigint not null,"+
+		"primary key (scope, version)"+
+		")$engine$;").Error
+}
+
+// migrationScope returns the scope in which the migrations of db are recorded.
+func (db *DB) migrationScope() string {
+	return db.vars[VarPrefix] + db.vars[VarTable]
+}
+
+// migrationsState returns the registered migrations sorted by version and the applied ones by version.
+// It fails if two migrations have the same version or if an applied migration was modified.
+func (db *DB) migrationsState(ctx context.Context) ([]*Migration, map[int64]*AppliedMigration, error) {
+	migrations := make([]*Migration, len(db.migrations))
+	copy(migrations, db.migrations)
+	sort.SliceStable(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, nil, errors.New()
+		}
+	}
+
+	appliedList, err := db.AppliedMigrations(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	applied := map[int64]*AppliedMigration{}
+	for _, am := range appliedList {
+		applied[am.Version] = am
+	}
+
+	for _, m := range migrations {
+		am, found := applied[m.Version]
+		if found && am.Checksum != m.Checksum() {
+			return nil, nil, &MigrationChecksumError{Version: m.Version, Name: m.Name}
+		}
+	}
+	return migrations, applied, nil
+}
+
+func (db *DB) migrationStep(m *Migration, up bool) *MigrationStep {
+	step := &MigrationStep{
+		Version:   m.Version,
+		Name:      m.Name,
+		Direction: MigrationUp,
+	}
+
+	script := m.Up
+	if !up {
+		step.Direction = MigrationDown
+		script = m.Down
+	}
+
+	for _, statement := range splitStatements(script) {
+		for name, value := range db.vars {
+			statement = strings.Replace(statement, name, value, -1)
+		}
+		step.Statements = append(step.Statements, statement)
+	}
+	return step
+}
+
+func (db *DB) runMigrationSteps(ctx context.Context, steps []*MigrationStep) error {
+	for _, step := range steps {
+		if err := db.runMigrationStep(ctx, step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *DB) runMigrationStep(ctx context.Context, step *MigrationStep) error {
+	var m *Migration
+	for _, registered := range db.migrations {
+		if registered.Version == step.Version {
+			m = registered
+			break
+		}
+	}
+	if m == nil {
+		return errors.NotFound()
+	}
+
+	tx, err := db.BeginTxContext(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range step.Statements {
+		if err = tx.ExecContext(ctx, statement).Error; err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if step.Direction == MigrationUp {
+		err = tx.ExecContext(ctx, "insert into "+MigrationsTable+" values (?, ?, ?, ?, ?);", db.migrationScope(), m.Version, m.Name, m.Checksum(), nowMillis()).Error
+	} else {
+		err = tx.ExecContext(ctx, "delete from "+MigrationsTable+" where scope=? and version=?;", db.migrationScope(), m.Version).Error
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// splitStatements splits script into the statements it contains. Semicolons in quoted text are ignored,
+// and "--" and "/* */" comments are left out of the statements.
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		builder    strings.Builder
+		quote      rune
+	)
+
+	add := func() {
+		statement := strings.TrimSpace(builder.String())
+		if statement != "" {
+			statements = append(statements, statement+";")
+		}
+		builder.Reset()
+	}
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			if i == len(runes) {
+				continue
+			}
+			c = '\n'
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				i++
+			}
+			i++
+			c = ' '
+		case c == ';':
+			add()
+			continue
+		}
+		builder.WriteRune(c)
+	}
+	add()
+	return statements
+}
@@ -0,0 +1,101 @@
+package bome
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDB_Migrate(t *testing.T) {
+	Convey("Migrations are applied once, verified and rolled back", t, func() {
+		conn, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+
+		for _, table := range []string{MigrationsTable, "mig_users", "mig_groups"} {
+			_, err = conn.Exec("drop table if exists " + table)
+			So(err, ShouldBeNil)
+		}
+
+		db, err := NewLite(conn)
+		So(err, ShouldBeNil)
+		db.SetTablePrefix("mig_")
+
+		err = db.LoadMigrations(fstest.MapFS{
+			"migrations/1_users.up.sql":    {Data: []byte("create table $prefix$users (name varchar(255) not null primary key)$engine$;")},
+			"migrations/1_users.down.sql":  {Data: []byte("drop table $prefix$users;")},
+			"migrations/2_groups.up.sql":   {Data: []byte("-- groups don't have an owner yet\ncreate table $prefix$groups (name varchar(255) not null); /* it's seeded; */ insert into $prefix$groups values ('a;b');")},
+			"migrations/2_groups.down.sql": {Data: []byte("drop table $prefix$groups;")},
+			"migrations/README.md":         {Data: []byte("ignored")},
+		}, "migrations")
+		So(err, ShouldBeNil)
+
+		ctx := context.Background()
+		steps, err := db.MigrationPlan(ctx)
+		So(err, ShouldBeNil)
+		So(steps, ShouldHaveLength, 2)
+		So(steps[0].Version, ShouldEqual, 1)
+		So(steps[1].Statements, ShouldHaveLength, 2)
+		So(steps[1].Statements[0], ShouldEqual, "create table mig_groups (name varchar(255) not null);")
+		So(steps[1].Statements[1], ShouldEqual, "insert into mig_groups values ('a;b');")
+
+		So(db.Migrate(), ShouldBeNil)
+		So(db.Migrate(), ShouldBeNil)
+
+		applied, err := db.AppliedMigrations(ctx)
+		So(err, ShouldBeNil)
+		So(applied, ShouldHaveLength, 2)
+
+		o, err := db.QueryFirst("select name from mig_groups;", StringScanner)
+		So(err, ShouldBeNil)
+		So(o, ShouldEqual, "a;b")
+
+		steps, err = db.RollbackMigrationsPlan(ctx, 1)
+		So(err, ShouldBeNil)
+		So(steps, ShouldHaveLength, 1)
+		So(steps[0].Direction, ShouldEqual, MigrationDown)
+
+		So(db.RollbackMigrations(ctx, 1), ShouldBeNil)
+		applied, err = db.AppliedMigrations(ctx)
+		So(err, ShouldBeNil)
+		So(applied, ShouldHaveLength, 1)
+
+		_, err = db.QueryFirst("select name from mig_groups;", StringScanner)
+		So(err, ShouldNotBeNil)
+
+		db.migrations[0].Up = "create table $prefix$users (name text);"
+		err = db.Migrate()
+		So(err, ShouldHaveSameTypeAs, &MigrationChecksumError{})
+	})
+
+	Convey("The migrations of each table are recorded in their own scope", t, func() {
+		conn, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+
+		for _, table := range []string{MigrationsTable, "mig_a", "mig_b"} {
+			_, err = conn.Exec("drop table if exists " + table)
+			So(err, ShouldBeNil)
+		}
+
+		ctx := context.Background()
+		for _, table := range []string{"mig_a", "mig_b"} {
+			db, err := NewLite(conn)
+			So(err, ShouldBeNil)
+			db.SetTableName(table)
+			db.AddMigrationScript("create table $table$ (name text);")
+			So(db.Migrate(), ShouldBeNil)
+
+			applied, err := db.AppliedMigrations(ctx)
+			So(err, ShouldBeNil)
+			So(applied, ShouldHaveLength, 1)
+			So(applied[0].Scope, ShouldEqual, table)
+			So(applied[0].Version, ShouldEqual, 1)
+			So(applied[0].AppliedAt, ShouldBeGreaterThan, 1e12)
+
+			_, err = db.QueryFirst("select count(*) from "+table+";", IntScanner)
+			So(err, ShouldBeNil)
+		}
+	})
+}
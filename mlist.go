@@ -5,14 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"reflect"
 
 	"github.com/omecodes/errors"
 )
 
 type MList struct {
 	tableName string
-	dialect   string
+	dialect   Dialect
 	*DB
 	*JsonValueHolder
 	*MList
@@ -25,7 +24,7 @@ func (l *MList) Table() string {
 
 func (l *MList) Keys() []string {
 	return []string{
-		"first_key", "second_key",
+		"name",
 	}
 }
 
@@ -37,7 +36,7 @@ func (l *MList) Transaction(ctx context.Context) (context.Context, *MList, error
 		}
 
 		var err error
-		tx, err = l.BeginTx()
+		tx, err = l.BeginTxContext(ctx, nil)
 		if err != nil {
 			return ctx, nil, err
 		}
@@ -45,9 +44,15 @@ func (l *MList) Transaction(ctx context.Context) (context.Context, *MList, error
 		newCtx := contextWithTransaction(ctx, tx)
 		return newCtx, &MList{
 			JsonValueHolder: &JsonValueHolder{
-				dialect: l.dialect,
-				tx:      tx,
+				DB:        l.DB,
+				field:     "value",
+				columns:   l.columns,
+				versioned: l.versioned,
+				keys:      l.keys,
+				dialect:   l.dialect,
+				tx:        tx,
 			},
+			DB: l.DB,
 			MList: &MList{
 				tableName: l.tableName,
 				dialect:   l.dialect,
@@ -71,10 +76,17 @@ func (l *MList) Transaction(ctx context.Context) (context.Context, *MList, error
 	newCtx := contextWithTransaction(ctx, tx)
 	return newCtx, &MList{
 		JsonValueHolder: &JsonValueHolder{
-			dialect: l.dialect,
-			tx:      tx,
+			DB:        l.DB,
+			field:     "value",
+			columns:   l.columns,
+			versioned: l.versioned,
+			keys:      l.keys,
+			dialect:   l.dialect,
+			tx:        tx,
 		},
+		DB: l.DB,
 		MList: &MList{
+			DB:        l.DB,
 			tableName: l.tableName,
 			dialect:   l.dialect,
 			tx:        tx,
@@ -106,13 +118,28 @@ func (l *MList) Client() Client {
 	return l.DB
 }
 
+// client returns the client that runs queries for ctx. When the collection is not bound to a transaction,
+// the transaction stored in ctx is used if it was started on the same database.
+func (l *MList) client(ctx context.Context) Client {
+	if l.tx == nil {
+		if tx := transaction(ctx); tx != nil && tx.db.sqlDb == l.DB.sqlDb {
+			return tx.New(l.DB)
+		}
+	}
+	return l.Client()
+}
+
 func (l *MList) Write(index int64, key string, o interface{}) error {
+	return l.WriteContext(context.Background(), index, key, o)
+}
+
+func (l *MList) WriteContext(ctx context.Context, index int64, key string, o interface{}) error {
 	data, err := json.Marshal(o)
 	if err != nil {
 		return err
 	}
 
-	return l.Save(&PairListEntry{
+	return l.SaveContext(ctx, &PairListEntry{
 		Index: index,
 		Key:   key,
 		Value: string(data),
@@ -120,10 +147,11 @@ func (l *MList) Write(index int64, key string, o interface{}) error {
 }
 
 func (l *MList) Read(key string, o interface{}) error {
-	if o == nil {
-		o = reflect.New(reflect.TypeOf(o))
-	}
-	entry, err := l.Get(key)
+	return l.ReadContext(context.Background(), key, o)
+}
+
+func (l *MList) ReadContext(ctx context.Context, key string, o interface{}) error {
+	entry, err := l.GetContext(ctx, key)
 	if err != nil {
 		return err
 	}
@@ -131,26 +159,102 @@ func (l *MList) Read(key string, o interface{}) error {
 }
 
 func (l *MList) EditAt(key string, path string, ex Expression) error {
-	ex.setDialect(l.dialect)
-	rawQuery := fmt.Sprintf("update $table$ set value=json_set(value, '%s', \"%s\") where name=?;",
-		normalizedJsonPath(path),
-		ex.eval(),
+	return l.EditAtContext(context.Background(), key, path, ex)
+}
+
+func (l *MList) EditAtContext(ctx context.Context, key string, path string, ex Expression) error {
+	value, args := jsonValueSQL(l.dialect, ex)
+	rawQuery := fmt.Sprintf("update $table$ set value=%s, version=version+1 where name=?;",
+		l.dialect.JSONSet("value", path, value),
 	)
-	return l.Client().Exec(rawQuery, key).Error
+	return l.client(ctx).ExecContext(ctx, rawQuery, append(args, key)...).Error
 }
 
-func (l *MList) ExtractAt(key string, path string) (string, error) {
-	var rawQuery string
+// EditAtIfVersion is like EditAt but fails with a VersionConflictError if the key entry version is not expectedVersion.
+func (l *MList) EditAtIfVersion(key string, path string, ex Expression, expectedVersion int64) error {
+	return l.EditAtIfVersionContext(context.Background(), key, path, ex, expectedVersion)
+}
 
-	if l.dialect == SQLite3 {
-		rawQuery = fmt.Sprintf(
-			"select json_extract(value, '%s') from $table$ where name=?;", path)
-	} else {
-		rawQuery = fmt.Sprintf(
-			"select json_unquote(json_extract(value, '%s')) from $table$ where name=?;", path)
-	}
+func (l *MList) EditAtIfVersionContext(ctx context.Context, key string, path string, ex Expression, expectedVersion int64) error {
+	value, args := jsonValueSQL(l.dialect, ex)
+	rawQuery := fmt.Sprintf("update $table$ set value=%s, version=version+1 where name=? and version=?;",
+		l.dialect.JSONSet("value", path, value),
+	)
+	result := l.client(ctx).ExecContext(ctx, rawQuery, append(args, key, expectedVersion)...)
+	return versionResultError(result, expectedVersion)
+}
+
+// ApplyPatch applies the JSON Patch document patch (RFC 6902) to the key value. Either all the operations are applied
+// or none is: an operation on a missing path or a failing test operation fails with a PatchError.
+func (l *MList) ApplyPatch(key string, patch string) error {
+	return l.ApplyPatchContext(context.Background(), key, patch)
+}
+
+func (l *MList) ApplyPatchContext(ctx context.Context, key string, patch string) error {
+	return l.JsonValueHolder.applyPatchContext(ctx, Key().Eq(StringExpr(key)), patch)
+}
+
+// ApplyMergePatch applies the JSON Merge Patch document doc (RFC 7396) to the key value.
+func (l *MList) ApplyMergePatch(key string, doc string) error {
+	return l.ApplyMergePatchContext(context.Background(), key, doc)
+}
+
+func (l *MList) ApplyMergePatchContext(ctx context.Context, key string, doc string) error {
+	return l.JsonValueHolder.applyMergePatchContext(ctx, Key().Eq(StringExpr(key)), doc)
+}
+
+// RemoveAt removes the value found at path in the key value.
+func (l *MList) RemoveAt(key string, path string) error {
+	return l.RemoveAtContext(context.Background(), key, path)
+}
+
+func (l *MList) RemoveAtContext(ctx context.Context, key string, path string) error {
+	return l.JsonValueHolder.RemoveAtContext(ctx, path, Key().Eq(StringExpr(key)))
+}
+
+// AppendAt appends the value of ex to the array found at path in the key value.
+func (l *MList) AppendAt(key string, path string, ex Expression) error {
+	return l.AppendAtContext(context.Background(), key, path, ex)
+}
+
+func (l *MList) AppendAtContext(ctx context.Context, key string, path string, ex Expression) error {
+	return l.JsonValueHolder.AppendAtContext(ctx, path, ex, Key().Eq(StringExpr(key)))
+}
+
+// InsertAt inserts the value of ex at index in the array found at path in the key value, shifting the following elements.
+func (l *MList) InsertAt(key string, path string, index int, ex Expression) error {
+	return l.InsertAtContext(context.Background(), key, path, index, ex)
+}
+
+func (l *MList) InsertAtContext(ctx context.Context, key string, path string, index int, ex Expression) error {
+	return l.JsonValueHolder.InsertAtContext(ctx, path, index, ex, Key().Eq(StringExpr(key)))
+}
+
+// IncrementAt adds delta to the number found at path in the key value. A missing number is set to delta.
+func (l *MList) IncrementAt(key string, path string, delta int64) error {
+	return l.IncrementAtContext(context.Background(), key, path, delta)
+}
 
-	o, err := l.Client().QueryFirst(rawQuery, StringScanner, key)
+func (l *MList) IncrementAtContext(ctx context.Context, key string, path string, delta int64) error {
+	return l.JsonValueHolder.IncrementAtContext(ctx, path, delta, Key().Eq(StringExpr(key)))
+}
+
+// ToggleAt negates the boolean found at path in the key value. A missing boolean is set to true.
+func (l *MList) ToggleAt(key string, path string) error {
+	return l.ToggleAtContext(context.Background(), key, path)
+}
+
+func (l *MList) ToggleAtContext(ctx context.Context, key string, path string) error {
+	return l.JsonValueHolder.ToggleAtContext(ctx, path, Key().Eq(StringExpr(key)))
+}
+
+func (l *MList) ExtractAt(key string, path string) (string, error) {
+	return l.ExtractAtContext(context.Background(), key, path)
+}
+
+func (l *MList) ExtractAtContext(ctx context.Context, key string, path string) (string, error) {
+	rawQuery := fmt.Sprintf("select %s from $table$ where name=?;", l.dialect.JSONExtract("value", path))
+	o, err := l.client(ctx).QueryFirstContext(ctx, rawQuery, StringScanner, key)
 	if err != nil {
 		return "", err
 	}
@@ -162,31 +266,181 @@ func (l *MList) Close() error {
 }
 
 func (l *MList) Save(entry *PairListEntry) error {
-	return l.Client().Exec("insert into $table$ values (?, ?, ?);", entry.Index, entry.Key, entry.Value).Error
+	return l.SaveContext(context.Background(), entry)
+}
+
+func (l *MList) SaveContext(ctx context.Context, entry *PairListEntry) error {
+	return l.client(ctx).ExecContext(ctx, "insert into $table$ (ind, name, value) values (?, ?, ?);", entry.Index, entry.Key, entry.Value).Error
 }
 
 func (l *MList) Update(key string, value string) error {
-	return l.Client().Exec("update $table$ set value=? where name=?;", value, key).Error
+	return l.UpdateContext(context.Background(), key, value)
 }
 
-func (l *MList) Upsert(entry *PairListEntry) error {
-	err := l.Save(entry)
-	if !isPrimaryKeyConstraintError(err) {
+func (l *MList) UpdateContext(ctx context.Context, key string, value string) error {
+	return l.client(ctx).ExecContext(ctx, "update $table$ set value=?, version=version+1 where name=?;", value, key).Error
+}
+
+// SaveMany saves entries by chunks in a single transaction, or in the transaction of ctx. opts applies to all entries.
+func (l *MList) SaveMany(entries []*PairListEntry, opts SaveOptions) BatchResult {
+	return l.SaveManyContext(context.Background(), entries, opts)
+}
+
+func (l *MList) SaveManyContext(ctx context.Context, entries []*PairListEntry, opts SaveOptions) BatchResult {
+	rows := make([]batchRow, 0, len(entries))
+	for _, entry := range entries {
+		rows = append(rows, batchRow{key: entry.Key, args: []interface{}{entry.Index, entry.Key, entry.Value}})
+	}
+	stmt := saveStatement(l.dialect, opts.mode(), []string{"ind", "name", "value"}, l.Keys(), []string{"value"})
+
+	owned := l.tx == nil && transaction(ctx) == nil
+	ctx, tl, err := l.Transaction(ctx)
+	if err != nil {
+		return BatchResult{Error: err}
+	}
+	return endBatch(owned, tl, writeBatch(ctx, tl.client(ctx), l.dialect, stmt, rows))
+}
+
+// DeleteMany deletes the entries of keys by chunks in a single transaction, or in the transaction of ctx.
+func (l *MList) DeleteMany(keys []string) BatchResult {
+	return l.DeleteManyContext(context.Background(), keys)
+}
+
+func (l *MList) DeleteManyContext(ctx context.Context, keys []string) BatchResult {
+	rows := make([]batchRow, 0, len(keys))
+	for _, key := range keys {
+		rows = append(rows, batchRow{key: key, args: []interface{}{key}})
+	}
+
+	owned := l.tx == nil && transaction(ctx) == nil
+	ctx, tl, err := l.Transaction(ctx)
+	if err != nil {
+		return BatchResult{Error: err}
+	}
+	return endBatch(owned, tl, writeBatch(ctx, tl.client(ctx), l.dialect, deleteStatement(l.Keys()), rows))
+}
+
+// SaveIfVersion is like Save but fails with a VersionConflictError if the entry.Key entry version is not expectedVersion.
+// An expectedVersion of 0 means that the entry.Key entry must not exist.
+func (l *MList) SaveIfVersion(entry *PairListEntry, expectedVersion int64) error {
+	return l.SaveIfVersionContext(context.Background(), entry, expectedVersion)
+}
+
+func (l *MList) SaveIfVersionContext(ctx context.Context, entry *PairListEntry, expectedVersion int64) error {
+	if expectedVersion == 0 {
+		result := l.PutContext(ctx, entry, SaveOptions{OnlyIfAbsent: true})
+		if result.Error == nil && result.Inserted == 0 {
+			return &VersionConflictError{Expected: expectedVersion}
+		}
+		return result.Error
+	}
+
+	result := l.client(ctx).ExecContext(ctx, "update $table$ set ind=?, value=?, version=version+1 where name=? and version=?;",
+		entry.Index, entry.Value, entry.Key, expectedVersion)
+	return versionResultError(result, expectedVersion)
+}
+
+// CompareAndSwap replaces the key value with newValue if it is the same JSON value as oldValue.
+// It fails with a VersionConflictError if the value is different or is modified concurrently.
+func (l *MList) CompareAndSwap(key string, oldValue, newValue string) error {
+	return l.CompareAndSwapContext(context.Background(), key, oldValue, newValue)
+}
+
+func (l *MList) CompareAndSwapContext(ctx context.Context, key string, oldValue, newValue string) error {
+	entry, version, err := l.GetWithVersionContext(ctx, key)
+	if err != nil {
 		return err
 	}
-	return l.Update(entry.Key, entry.Value)
+
+	if !sameJSON(entry.Value, oldValue) {
+		return &VersionConflictError{Expected: version}
+	}
+
+	result := l.client(ctx).ExecContext(ctx, "update $table$ set value=?, version=version+1 where name=? and version=?;", newValue, key, version)
+	return versionResultError(result, version)
+}
+
+func (l *MList) Upsert(entry *PairListEntry) error {
+	return l.UpsertContext(context.Background(), entry)
+}
+
+func (l *MList) UpsertContext(ctx context.Context, entry *PairListEntry) error {
+	return l.PutContext(ctx, entry, SaveOptions{UpdateExisting: true}).Error
+}
+
+// Put saves entry and tells if it was inserted or updated. The value of an entry with the same key is updated
+// if opts.UpdateExisting is set, and is left unchanged if opts.OnlyIfAbsent is set. Otherwise, Put fails with
+// a duplicate key error.
+func (l *MList) Put(entry *PairListEntry, opts SaveOptions) Result {
+	return l.PutContext(context.Background(), entry, opts)
+}
+
+func (l *MList) PutContext(ctx context.Context, entry *PairListEntry, opts SaveOptions) Result {
+	columns := []string{"ind", "name", "value"}
+	keys := l.Keys()
+	switch {
+	case opts.OnlyIfAbsent:
+		return insertIfAbsent(ctx, l.client(ctx), l.dialect, columns, keys, entry.Index, entry.Key, entry.Value)
+	case opts.UpdateExisting:
+		return upsert(ctx, l.client(ctx), l.dialect, columns, keys, []string{"value"}, entry.Index, entry.Key, entry.Value)
+	default:
+		return insert(ctx, l.client(ctx), l.dialect, columns, entry.Index, entry.Key, entry.Value)
+	}
 }
 
 func (l *MList) Get(key string) (*ListEntry, error) {
-	o, err := l.Client().QueryFirst("select ind, value from $table$ where name=?;", ListEntryScanner, key)
+	return l.GetContext(context.Background(), key)
+}
+
+func (l *MList) GetContext(ctx context.Context, key string) (*ListEntry, error) {
+	o, err := l.client(ctx).QueryFirstContext(ctx, "select ind, value from $table$ where name=?;", ListEntryScanner, key)
 	if err != nil {
 		return nil, err
 	}
 	return o.(*ListEntry), nil
 }
 
+// GetMany returns the entries of keys. Keys are read by chunks sized to the dialect placeholders limit.
+func (l *MList) GetMany(keys []string) (*BatchRead[string, *PairListEntry], error) {
+	return l.GetManyContext(context.Background(), keys)
+}
+
+func (l *MList) GetManyContext(ctx context.Context, keys []string) (*BatchRead[string, *PairListEntry], error) {
+	return readBatch(ctx, l.client(ctx), l.dialect, &batchQuery[string, *PairListEntry]{
+		query: func(n int) string {
+			return "select ind, name, value from $table$ where " + inKeys(l.Keys(), n) + ";"
+		},
+		args: func(key string) []interface{} {
+			return []interface{}{key}
+		},
+		scanner: PairListEntryScanner,
+		entry: func(o interface{}) (string, *PairListEntry) {
+			entry := o.(*PairListEntry)
+			return entry.Key, entry
+		},
+	}, keys)
+}
+
+// GetWithVersion returns the key entry and its version.
+func (l *MList) GetWithVersion(key string) (*ListEntry, int64, error) {
+	return l.GetWithVersionContext(context.Background(), key)
+}
+
+func (l *MList) GetWithVersionContext(ctx context.Context, key string) (*ListEntry, int64, error) {
+	o, err := l.client(ctx).QueryFirstContext(ctx, "select ind, value, version from $table$ where name=?;", versionedListEntryScanner, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	entry := o.(*versionedListEntry)
+	return &entry.ListEntry, entry.version, nil
+}
+
 func (l *MList) MinIndex() (int64, error) {
-	res, err := l.Client().QueryFirst("select min(ind) from $table$;", IntScanner)
+	return l.MinIndexContext(context.Background())
+}
+
+func (l *MList) MinIndexContext(ctx context.Context) (int64, error) {
+	res, err := l.client(ctx).QueryFirstContext(ctx, "select min(ind) from $table$;", IntScanner)
 	if err != nil {
 		return 0, err
 	}
@@ -194,7 +448,11 @@ func (l *MList) MinIndex() (int64, error) {
 }
 
 func (l *MList) MaxIndex() (int64, error) {
-	res, err := l.Client().QueryFirst("select max(ind) from $table$;", IntScanner)
+	return l.MaxIndexContext(context.Background())
+}
+
+func (l *MList) MaxIndexContext(ctx context.Context) (int64, error) {
+	res, err := l.client(ctx).QueryFirstContext(ctx, "select max(ind) from $table$;", IntScanner)
 	if err != nil {
 		return 0, err
 	}
@@ -202,7 +460,11 @@ func (l *MList) MaxIndex() (int64, error) {
 }
 
 func (l *MList) Count() (int64, error) {
-	res, err := l.Client().QueryFirst("select count(ind) from $table$;", IntScanner)
+	return l.CountContext(context.Background())
+}
+
+func (l *MList) CountContext(ctx context.Context) (int64, error) {
+	res, err := l.client(ctx).QueryFirstContext(ctx, "select count(ind) from $table$;", IntScanner)
 	if err != nil {
 		return 0, err
 	}
@@ -210,7 +472,12 @@ func (l *MList) Count() (int64, error) {
 }
 
 func (l *MList) SizeAt(index int64) (int64, error) {
-	o, err := l.Client().QueryFirst("select coalesce(length(value)), 0) from $table$ where ind=?;", IntScanner, index)
+	return l.SizeAtContext(context.Background(), index)
+}
+
+func (l *MList) SizeAtContext(ctx context.Context, index int64) (int64, error) {
+	rawQuery := fmt.Sprintf("select coalesce(%s, 0) from $table$ where ind=?;", l.dialect.Length("value"))
+	o, err := l.client(ctx).QueryFirstContext(ctx, rawQuery, IntScanner, index)
 	if err != nil {
 		return 0, err
 	}
@@ -218,7 +485,12 @@ func (l *MList) SizeAt(index int64) (int64, error) {
 }
 
 func (l *MList) TotalSize() (int64, error) {
-	o, err := l.Client().QueryFirst("select coalesce(sum(length(value)), 0) from $table$;", IntScanner)
+	return l.TotalSizeContext(context.Background())
+}
+
+func (l *MList) TotalSizeContext(ctx context.Context) (int64, error) {
+	rawQuery := fmt.Sprintf("select coalesce(sum(%s), 0) from $table$;", l.dialect.Length("value"))
+	o, err := l.client(ctx).QueryFirstContext(ctx, rawQuery, IntScanner)
 	if err != nil {
 		return 0, err
 	}
@@ -226,7 +498,11 @@ func (l *MList) TotalSize() (int64, error) {
 }
 
 func (l *MList) GetNextFromSeq(index int64) (*PairListEntry, error) {
-	o, err := l.Client().QueryFirst("select * from $table$ where ind>? order by ind;", PairListEntryScanner, index)
+	return l.GetNextFromSeqContext(context.Background(), index)
+}
+
+func (l *MList) GetNextFromSeqContext(ctx context.Context, index int64) (*PairListEntry, error) {
+	o, err := l.client(ctx).QueryFirstContext(ctx, "select ind, name, value from $table$ where ind>? order by ind;", PairListEntryScanner, index)
 	if err != nil {
 		return nil, err
 	}
@@ -234,7 +510,11 @@ func (l *MList) GetNextFromSeq(index int64) (*PairListEntry, error) {
 }
 
 func (l *MList) RangeFromIndex(index int64, offset, count int) ([]*PairListEntry, error) {
-	c, err := l.Client().Query("select * from $table$ where ind>? order by ind limit ?, ?;", PairListEntryScanner, index, offset, count)
+	return l.RangeFromIndexContext(context.Background(), index, offset, count)
+}
+
+func (l *MList) RangeFromIndexContext(ctx context.Context, index int64, offset, count int) ([]*PairListEntry, error) {
+	c, err := l.client(ctx).QueryContext(ctx, "select ind, name, value from $table$ where ind>? order by ind limit ?, ?;", PairListEntryScanner, index, offset, count)
 	if err != nil {
 		return nil, err
 	}
@@ -257,7 +537,11 @@ func (l *MList) RangeFromIndex(index int64, offset, count int) ([]*PairListEntry
 }
 
 func (l *MList) Range(offset, count int) ([]*PairListEntry, error) {
-	c, err := l.Client().Query("select * from $table$ order by ind limit ?, ?;", PairListEntryScanner, offset, count)
+	return l.RangeContext(context.Background(), offset, count)
+}
+
+func (l *MList) RangeContext(ctx context.Context, offset, count int) ([]*PairListEntry, error) {
+	c, err := l.client(ctx).QueryContext(ctx, "select ind, name, value from $table$ order by ind limit ?, ?;", PairListEntryScanner, offset, count)
 	if err != nil {
 		return nil, err
 	}
@@ -279,19 +563,37 @@ func (l *MList) Range(offset, count int) ([]*PairListEntry, error) {
 	return entries, nil
 }
 
+// Page returns the page of entries described by req, in key order.
+func (l *MList) Page(ctx context.Context, req PageRequest) (*PageResult[*PairListEntry], error) {
+	return readPage(ctx, l.client(ctx), &keyset[*PairListEntry]{
+		columns:   "ind, name, value",
+		keys:      l.Keys(),
+		scanner:   PairListEntryScanner,
+		condition: l.condition,
+		key: func(e *PairListEntry) []string {
+			return []string{e.Key}
+		},
+		args: stringKeyArgs,
+	}, req)
+}
+
 func (l *MList) IndexInRange(after, before int64) (Cursor, int64, error) {
+	return l.IndexInRangeContext(context.Background(), after, before)
+}
+
+func (l *MList) IndexInRangeContext(ctx context.Context, after, before int64) (Cursor, int64, error) {
 	var (
 		total int64
 		c     Cursor
 	)
 
-	o, err := l.Client().QueryFirst("select count(ind) from $table$ where ind > ? and ind < ?;", IntScanner, after, before)
+	o, err := l.client(ctx).QueryFirstContext(ctx, "select count(ind) from $table$ where ind > ? and ind < ?;", IntScanner, after, before)
 	if err != nil {
 		return nil, 0, err
 	}
 	total = o.(int64)
 
-	c, err = l.Client().Query("select * from $table$ where ind > ? and ind < ?;", PairListEntryScanner, after, before)
+	c, err = l.client(ctx).QueryContext(ctx, "select ind, name, value from $table$ where ind > ? and ind < ?;", PairListEntryScanner, after, before)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -300,31 +602,48 @@ func (l *MList) IndexInRange(after, before int64) (Cursor, int64, error) {
 }
 
 func (l *MList) IndexBefore(index int64) (Cursor, int64, error) {
-	o, err := l.Client().QueryFirst("select count(ind) from $table$ where ind<?;", IntScanner, index)
+	return l.IndexBeforeContext(context.Background(), index)
+}
+
+func (l *MList) IndexBeforeContext(ctx context.Context, index int64) (Cursor, int64, error) {
+	o, err := l.client(ctx).QueryFirstContext(ctx, "select count(ind) from $table$ where ind<?;", IntScanner, index)
 	if err != nil {
 		return nil, 0, err
 	}
 	total := o.(int64)
-	cursor, err := l.Client().Query("select * from $table$ where ind<? order by ind;", PairListEntryScanner, index)
+	cursor, err := l.client(ctx).QueryContext(ctx, "select ind, name, value from $table$ where ind<? order by ind;", PairListEntryScanner, index)
 	return cursor, total, err
 }
 
 func (l *MList) IndexAfter(index int64) (Cursor, int64, error) {
-	o, err := l.Client().QueryFirst("select count(ind) from $table$ where ind>?;", IntScanner, index)
+	return l.IndexAfterContext(context.Background(), index)
+}
+
+func (l *MList) IndexAfterContext(ctx context.Context, index int64) (Cursor, int64, error) {
+	o, err := l.client(ctx).QueryFirstContext(ctx, "select count(ind) from $table$ where ind>?;", IntScanner, index)
 	if err != nil {
 		return nil, 0, err
 	}
 	total := o.(int64)
-	cursor, err := l.Client().Query("select * from $table$ where ind>? order by ind;", PairListEntryScanner, index)
+	cursor, err := l.client(ctx).QueryContext(ctx, "select ind, name, value from $table$ where ind>? order by ind;", PairListEntryScanner, index)
 	return cursor, total, err
 }
 
 func (l *MList) DeleteAt(index int64) error {
-	return l.Client().Exec("delete from $table$ where ind=?;", index).Error
+	return l.DeleteAtContext(context.Background(), index)
+}
+
+func (l *MList) DeleteAtContext(ctx context.Context, index int64) error {
+	return l.client(ctx).ExecContext(ctx, "delete from $table$ where ind=?;", index).Error
 }
 
 func (l *MList) Size(key string) (int64, error) {
-	o, err := l.Client().QueryFirst("select coalesce(length(value), 0) from $table$ where name=?;", IntScanner, key)
+	return l.SizeContext(context.Background(), key)
+}
+
+func (l *MList) SizeContext(ctx context.Context, key string) (int64, error) {
+	rawQuery := fmt.Sprintf("select coalesce(%s, 0) from $table$ where name=?;", l.dialect.Length("value"))
+	o, err := l.client(ctx).QueryFirstContext(ctx, rawQuery, IntScanner, key)
 	if err != nil {
 		return 0, err
 	}
@@ -332,7 +651,11 @@ func (l *MList) Size(key string) (int64, error) {
 }
 
 func (l *MList) Contains(key string) (bool, error) {
-	res, err := l.Client().QueryFirst("select 1 from $table$ where name=?;", BoolScanner, key)
+	return l.ContainsContext(context.Background(), key)
+}
+
+func (l *MList) ContainsContext(ctx context.Context, key string) (bool, error) {
+	res, err := l.client(ctx).QueryFirstContext(ctx, "select 1 from $table$ where name=?;", BoolScanner, key)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return false, nil
@@ -343,13 +666,25 @@ func (l *MList) Contains(key string) (bool, error) {
 }
 
 func (l *MList) Delete(key string) error {
-	return l.Client().Exec("delete from $table$ where name=?;", key).Error
+	return l.DeleteContext(context.Background(), key)
+}
+
+func (l *MList) DeleteContext(ctx context.Context, key string) error {
+	return l.client(ctx).ExecContext(ctx, "delete from $table$ where name=?;", key).Error
 }
 
 func (l *MList) List() (Cursor, error) {
-	return l.Client().Query("select * from $table$;", PairListEntryScanner)
+	return l.ListContext(context.Background())
+}
+
+func (l *MList) ListContext(ctx context.Context) (Cursor, error) {
+	return l.client(ctx).QueryContext(ctx, "select ind, name, value from $table$;", PairListEntryScanner)
 }
 
 func (l *MList) Clear() error {
-	return l.Client().Exec("delete from $table$;").Error
+	return l.ClearContext(context.Background())
+}
+
+func (l *MList) ClearContext(ctx context.Context) error {
+	return l.client(ctx).ExecContext(ctx, "delete from $table$;").Error
 }
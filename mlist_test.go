@@ -0,0 +1,79 @@
+package bome
+
+import (
+	"database/sql"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var dbMList *MList
+
+func initMList(_ *testing.T) {
+	if dbMList == nil {
+		db, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+		So(db, ShouldNotBeNil)
+
+		_, err = db.Exec("drop table if exists m_list")
+		So(err, ShouldBeNil)
+
+		dbMList, err = Build().SetConn(db).SetDialect(testDialect).SetTableName("m_list").MList()
+		So(err, ShouldBeNil)
+		So(dbMList, ShouldNotBeNil)
+	}
+}
+
+func TestMList_Version(t *testing.T) {
+	Convey("Conditional writes are guarded by the entry version", t, func() {
+		initMList(t)
+
+		err := dbMList.SaveIfVersion(&PairListEntry{Index: 1, Key: "a", Value: `{"n": 1}`}, 0)
+		So(err, ShouldBeNil)
+
+		err = dbMList.SaveIfVersion(&PairListEntry{Index: 1, Key: "a", Value: `{"n": 1}`}, 0)
+		So(IsVersionConflict(err), ShouldBeTrue)
+
+		err = dbMList.Update("a", `{"n": 2}`)
+		So(err, ShouldBeNil)
+
+		entry, version, err := dbMList.GetWithVersion("a")
+		So(err, ShouldBeNil)
+		So(entry.Index, ShouldEqual, 1)
+		So(version, ShouldEqual, 2)
+
+		err = dbMList.EditAtIfVersion("a", "$.n", IntExpr(3), 1)
+		So(IsVersionConflict(err), ShouldBeTrue)
+
+		err = dbMList.EditAtIfVersion("a", "$.n", IntExpr(3), 2)
+		So(err, ShouldBeNil)
+
+		err = dbMList.CompareAndSwap("a", `{"n": 2}`, `{"n": 4}`)
+		So(IsVersionConflict(err), ShouldBeTrue)
+
+		err = dbMList.CompareAndSwap("a", `{"n": 3}`, `{"n": 4}`)
+		So(err, ShouldBeNil)
+
+		entries, err := dbMList.Range(0, 10)
+		So(err, ShouldBeNil)
+		So(entries, ShouldHaveLength, 1)
+		So(entries[0].Value, ShouldEqual, `{"n": 4}`)
+	})
+}
+
+func TestMList_GetMany(t *testing.T) {
+	Convey("GetMany reads entries by keys", t, func() {
+		initMList(t)
+
+		So(dbMList.Save(&PairListEntry{Index: 10, Key: "many-a", Value: `"a"`}), ShouldBeNil)
+		So(dbMList.Save(&PairListEntry{Index: 11, Key: "many-b", Value: `"b"`}), ShouldBeNil)
+
+		result, err := dbMList.GetMany([]string{"many-b", "many-c", "many-a"})
+		So(err, ShouldBeNil)
+		So(result.Missing, ShouldResemble, []string{"many-c"})
+		So(result.Found["many-a"].Index, ShouldEqual, 10)
+		So(result.Ordered()[0].Key, ShouldEqual, "many-b")
+
+		So(dbMList.DeleteMany([]string{"many-a", "many-b"}).Error, ShouldBeNil)
+	})
+}
@@ -1,12 +1,27 @@
 package bome
 
+import "time"
+
 type SaveOptions struct {
 	UpdateExisting bool
+
+	// OnlyIfAbsent leaves existing entries unchanged instead of failing. It takes precedence over UpdateExisting.
+	OnlyIfAbsent bool
+
+	// TTL is the time after which the saved entry expires. Entries saved without TTL nor ExpiresAt never expire.
+	TTL time.Duration
+
+	// ExpiresAt is the time at which the saved entry expires. It takes precedence over TTL.
+	ExpiresAt time.Time
 }
 
 type options struct {
-	foreignKeys []*ForeignKey
-	indexes     []*Index
+	foreignKeys    []*ForeignKey
+	indexes        []*Index
+	maxAttempts    int64
+	sweepInterval  time.Duration
+	sweepBatchSize int
+	jsonIndexes    []*jsonIndex
 }
 
 type Option func(*options)
@@ -22,3 +37,29 @@ func WithIndex(indexes ...*Index) Option {
 		o.indexes = append(o.indexes, indexes...)
 	}
 }
+
+// WithJsonIndex indexes the values found at path in the JSON values of a collection. The values are copied
+// in a generated column named name, of type t, which is indexed. The conditions on path, such as JsonAtEq or JsonAtLt,
+// are then rendered on the column. The values found at path must be of type t.
+func WithJsonIndex(name string, path string, t ValueType) Option {
+	return func(o *options) {
+		o.jsonIndexes = append(o.jsonIndexes, &jsonIndex{name: name, path: normalizedJsonPath(path), valueType: t})
+	}
+}
+
+// WithMaxAttempts sets the number of times a queue item can be dequeued before it is dead-lettered.
+// Items are never dead-lettered when n is 0.
+func WithMaxAttempts(n int64) Option {
+	return func(o *options) {
+		o.maxAttempts = n
+	}
+}
+
+// WithExpirySweeper starts a background task that deletes the expired entries of a Map or a DMap every interval,
+// by batches of batchSize entries. The task is stopped when the collection is closed.
+func WithExpirySweeper(interval time.Duration, batchSize int) Option {
+	return func(o *options) {
+		o.sweepInterval = interval
+		o.sweepBatchSize = batchSize
+	}
+}
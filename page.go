@@ -0,0 +1,168 @@
+package bome
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// PageRequest describes a page of entries read in primary key order.
+type PageRequest struct {
+	// After is the continuation token of the previous page. The first page is read when it is empty.
+	After string
+
+	// Limit is the maximum number of entries of the page.
+	Limit int
+
+	// Filter restricts the entries of the page. All entries are read when it is nil.
+	Filter BoolExpr
+
+	// Desc reads entries in descending primary key order.
+	Desc bool
+}
+
+// PageResult is a page of entries.
+type PageResult[E any] struct {
+	Entries []E
+
+	// Next is the token to pass as PageRequest.After to read the next page. It is empty on the last page.
+	Next string
+}
+
+// keyset describes how to read pages of a table ordered by its primary key columns.
+type keyset[E any] struct {
+	columns string
+	keys    []string
+	scanner string
+
+	// condition renders the filter of a page query.
+	condition func(condition BoolExpr) (string, []interface{}, error)
+
+	// visible restricts the condition of a page query to the visible entries.
+	visible func(clause string, args []interface{}) (string, []interface{})
+
+	// key returns the primary key values of an entry.
+	key func(e E) []string
+
+	// args returns the arguments bound to the primary key columns from the values of a token.
+	args func(values []string) ([]interface{}, error)
+}
+
+func stringKeyArgs(values []string) ([]interface{}, error) {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return args, nil
+}
+
+func intKeyArgs(values []string) ([]interface{}, error) {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidPageToken
+		}
+		args[i] = n
+	}
+	return args, nil
+}
+
+func encodePageToken(values []string) string {
+	data, _ := json.Marshal(values)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(token string, n int) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	var values []string
+	if err = json.Unmarshal(data, &values); err != nil || len(values) != n {
+		return nil, ErrInvalidPageToken
+	}
+	return values, nil
+}
+
+// readPage reads the page of ks entries described by req. One more entry than the limit is read to know
+// if there is a next page.
+func readPage[E any](ctx context.Context, client Client, ks *keyset[E], req PageRequest) (*PageResult[E], error) {
+	if req.Limit <= 0 {
+		return &PageResult[E]{}, nil
+	}
+
+	filter := req.Filter
+	if filter == nil {
+		filter = True()
+	}
+	clause, args, err := ks.condition(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	operator, order := ">", ""
+	if req.Desc {
+		operator, order = "<", " desc"
+	}
+
+	keys := strings.Join(ks.keys, ", ")
+	if len(ks.keys) > 1 {
+		keys = "(" + keys + ")"
+	}
+
+	if req.After != "" {
+		values, err := decodePageToken(req.After, len(ks.keys))
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := ks.args(values)
+		if err != nil {
+			return nil, err
+		}
+
+		bound := "?"
+		if len(ks.keys) > 1 {
+			bound = "(" + placeholders(len(ks.keys)) + ")"
+		}
+		clause = keys + operator + bound + " and (" + clause + ")"
+		args = append(after, args...)
+	}
+
+	if ks.visible != nil {
+		clause, args = ks.visible(clause, args)
+	}
+
+	orderBy := make([]string, len(ks.keys))
+	for i, key := range ks.keys {
+		orderBy[i] = key + order
+	}
+
+	rawQuery := "select " + ks.columns + " from $table$ where " + clause + " order by " + strings.Join(orderBy, ", ") + " limit ?;"
+	c, err := client.QueryContext(ctx, rawQuery, ks.scanner, append(args, req.Limit+1)...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = c.Close()
+	}()
+
+	page := &PageResult[E]{}
+	for c.HasNext() {
+		o, err := c.Entry()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(page.Entries) == req.Limit {
+			page.Next = encodePageToken(ks.key(page.Entries[len(page.Entries)-1]))
+			break
+		}
+		page.Entries = append(page.Entries, o.(E))
+	}
+	return page, cursorError(c)
+}
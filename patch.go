@@ -0,0 +1,679 @@
+package bome
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operations of JSON Patch documents (RFC 6902).
+const (
+	patchAdd     = "add"
+	patchRemove  = "remove"
+	patchReplace = "replace"
+	patchMove    = "move"
+	patchCopy    = "copy"
+	patchTest    = "test"
+)
+
+// patchOperation is an operation of a JSON Patch document, with its parsed pointers and value.
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+
+	path  []string
+	from  []string
+	value interface{}
+}
+
+func (op *patchOperation) error(reason string) error {
+	return &PatchError{Op: op.Op, Path: op.Path, Reason: reason}
+}
+
+// parsePatch parses and validates the JSON Patch document patch.
+func parsePatch(patch string) ([]*patchOperation, error) {
+	var ops []*patchOperation
+	if err := json.Unmarshal([]byte(patch), &ops); err != nil {
+		return nil, err
+	}
+
+	for _, op := range ops {
+		var err error
+		switch op.Op {
+		case patchAdd, patchReplace, patchTest:
+			if op.Value == nil {
+				return nil, op.error("missing value")
+			}
+			if op.value, err = decodeJSON(op.Value); err != nil {
+				return nil, op.error("invalid value")
+			}
+		case patchMove, patchCopy:
+			if op.from, err = parsePointer(op.From); err != nil {
+				return nil, op.error("invalid from pointer")
+			}
+		case patchRemove:
+		default:
+			return nil, op.error("unknown operation")
+		}
+
+		if op.path, err = parsePointer(op.Path); err != nil {
+			return nil, op.error("invalid path pointer")
+		}
+	}
+	return ops, nil
+}
+
+// parsePointer splits the JSON pointer p (RFC 6901) into its unescaped reference tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("bome: invalid JSON pointer %q", p)
+	}
+
+	tokens := strings.Split(p[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// decodeJSON decodes data keeping numbers as json.Number, so that they are written back unchanged.
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// applyPatch applies ops to doc and returns the patched document.
+func applyPatch(doc interface{}, ops []*patchOperation) (interface{}, error) {
+	var err error
+	for _, op := range ops {
+		switch op.Op {
+		case patchAdd:
+			doc, err = addAt(doc, op.path, copyJSON(op.value))
+
+		case patchRemove:
+			doc, _, err = removeAt(doc, op.path)
+
+		case patchReplace:
+			doc, err = replaceAt(doc, op.path, copyJSON(op.value))
+
+		case patchMove:
+			if isPointerPrefix(op.from, op.path) && len(op.from) < len(op.path) {
+				return nil, op.error("cannot move a value into one of its children")
+			}
+			var value interface{}
+			if doc, value, err = removeAt(doc, op.from); err == nil {
+				doc, err = addAt(doc, op.path, value)
+			}
+
+		case patchCopy:
+			value, found := valueAt(doc, op.from)
+			if !found {
+				return nil, op.error("from path not found")
+			}
+			doc, err = addAt(doc, op.path, copyJSON(value))
+
+		case patchTest:
+			value, found := valueAt(doc, op.path)
+			if !found || !equalJSON(value, op.value) {
+				return nil, op.error("test failed")
+			}
+		}
+
+		if err != nil {
+			return nil, op.error(err.Error())
+		}
+	}
+	return doc, nil
+}
+
+// mergePatch applies the JSON Merge Patch (RFC 7396) patch to doc and returns the patched document.
+func mergePatch(doc interface{}, patch interface{}) interface{} {
+	members, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	target, ok := doc.(map[string]interface{})
+	if !ok {
+		target = map[string]interface{}{}
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(target, name)
+		} else {
+			target[name] = mergePatch(target[name], value)
+		}
+	}
+	return target
+}
+
+// valueAt returns the value found at path in doc.
+func valueAt(doc interface{}, path []string) (interface{}, bool) {
+	for _, token := range path {
+		switch container := doc.(type) {
+		case map[string]interface{}:
+			value, found := container[token]
+			if !found {
+				return nil, false
+			}
+			doc = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, false
+			}
+			doc = container[index]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// editAt calls edit with the container of the value found at path, which cannot be the root, and the last token of path.
+// It returns doc with the container returned by edit.
+func editAt(doc interface{}, path []string, edit func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return edit(doc, path[0])
+	}
+
+	switch container := doc.(type) {
+	case map[string]interface{}:
+		child, found := container[path[0]]
+		if !found {
+			return nil, fmt.Errorf("path not found")
+		}
+		child, err := editAt(child, path[1:], edit)
+		if err != nil {
+			return nil, err
+		}
+		container[path[0]] = child
+		return container, nil
+
+	case []interface{}:
+		index, err := arrayIndex(path[0], len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := editAt(container[index], path[1:], edit)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = child
+		return container, nil
+
+	default:
+		return nil, fmt.Errorf("path not found")
+	}
+}
+
+func addAt(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return editAt(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			if token == "-" {
+				return append(c, value), nil
+			}
+			index, err := arrayIndex(token, len(c))
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[index+1:], c[index:])
+			c[index] = value
+			return c, nil
+		default:
+			return nil, fmt.Errorf("parent is not an object or an array")
+		}
+	})
+}
+
+func removeAt(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the document root")
+	}
+
+	var removed interface{}
+	doc, err := editAt(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			value, found := c[token]
+			if !found {
+				return nil, fmt.Errorf("path not found")
+			}
+			removed = value
+			delete(c, token)
+			return c, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = c[index]
+			return append(c[:index], c[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("path not found")
+		}
+	})
+	return doc, removed, err
+}
+
+func replaceAt(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return editAt(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			if _, found := c[token]; !found {
+				return nil, fmt.Errorf("path not found")
+			}
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			c[index] = value
+			return c, nil
+		default:
+			return nil, fmt.Errorf("path not found")
+		}
+	})
+}
+
+var arrayIndexPattern = regexp.MustCompile(`^(0|[1-9][0-9]*)$`)
+
+// arrayIndex parses the array index token, which must not be greater than max.
+func arrayIndex(token string, max int) (int, error) {
+	if !arrayIndexPattern.MatchString(token) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index > max {
+		return 0, fmt.Errorf("array index %s out of bounds", token)
+	}
+	return index, nil
+}
+
+// isPointerPrefix tells if the pointer a is a prefix of b or is equal to b.
+func isPointerPrefix(a, b []string) bool {
+	if len(a) > len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// copyJSON returns a deep copy of the decoded JSON value v.
+func copyJSON(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(value))
+		for name, member := range value {
+			c[name] = copyJSON(member)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(value))
+		for i, item := range value {
+			c[i] = copyJSON(item)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// equalJSON tells if the decoded JSON values a and b are equal. Numbers are compared by value.
+func equalJSON(a, b interface{}) bool {
+	switch va := a.(type) {
+	case map[string]interface{}:
+		vb, ok := b.(map[string]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for name, member := range va {
+			other, found := vb[name]
+			if !found || !equalJSON(member, other) {
+				return false
+			}
+		}
+		return true
+
+	case []interface{}:
+		vb, ok := b.([]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for i := range va {
+			if !equalJSON(va[i], vb[i]) {
+				return false
+			}
+		}
+		return true
+
+	case json.Number:
+		vb, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		ra, okA := new(big.Rat).SetString(va.String())
+		rb, okB := new(big.Rat).SetString(vb.String())
+		return okA && okB && ra.Cmp(rb) == 0
+
+	default:
+		return a == b
+	}
+}
+
+var simplePathToken = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// memberPath converts the pointer tokens into a JSON path if they only refer to object members
+// which names do not need to be quoted.
+func memberPath(tokens []string) (string, bool) {
+	for _, token := range tokens {
+		if !simplePathToken.MatchString(token) {
+			return "", false
+		}
+	}
+	return strings.Join(append([]string{"$"}, tokens...), "."), true
+}
+
+// patchStatement is the update of a JSON value that applies a patch in a single statement.
+// The patch applies as expected only to the values for which all the guards hold.
+type patchStatement struct {
+	value     string
+	args      []interface{}
+	guards    []string
+	guardArgs []interface{}
+}
+
+func (p *patchStatement) guard(condition string, args ...interface{}) {
+	p.guards = append(p.guards, condition)
+	p.guardArgs = append(p.guardArgs, args...)
+}
+
+// objectGuard returns the guard that tells if the value at path in field is a JSON object.
+func objectGuard(dialect Dialect, field string, path string) string {
+	return fmt.Sprintf("%s = 'object'", dialect.JSONType(field, path))
+}
+
+// existsGuard returns the guard that tells if field has a value at path.
+func existsGuard(dialect Dialect, field string, path string) string {
+	return fmt.Sprintf("%s is not null", dialect.JSONType(field, path))
+}
+
+// patchSQL translates ops into a statement that updates field. It returns false when an operation
+// has no translation: array elements, members which names need quoting, test operations that follow
+// other operations, or operations on paths that overlap.
+func patchSQL(dialect Dialect, field string, ops []*patchOperation) (*patchStatement, bool) {
+	var (
+		statement = &patchStatement{value: field}
+		touched   [][]string
+	)
+
+	overlaps := func(paths ...[]string) bool {
+		for _, path := range paths {
+			for _, other := range touched {
+				if isPointerPrefix(path, other) || isPointerPrefix(other, path) {
+					return true
+				}
+			}
+			touched = append(touched, path)
+		}
+		return false
+	}
+
+	set := func(path string, value string, args ...interface{}) {
+		statement.value = dialect.JSONSet(statement.value, path, dialect.JSONValue(value))
+		statement.args = append(statement.args, args...)
+	}
+
+	for _, op := range ops {
+		path, ok := memberPath(op.path)
+		if !ok {
+			return nil, false
+		}
+
+		if op.Op == patchTest {
+			if len(touched) > 0 {
+				return nil, false
+			}
+			statement.guard(fmt.Sprintf("%s = %s", dialect.JSONQuery(field, path), dialect.Placeholder(JsonValue)), string(op.Value))
+			continue
+		}
+
+		if len(op.path) == 0 {
+			return nil, false
+		}
+		parent, _ := memberPath(op.path[:len(op.path)-1])
+
+		switch op.Op {
+		case patchAdd:
+			if overlaps(op.path) {
+				return nil, false
+			}
+			statement.guard(objectGuard(dialect, field, parent))
+			set(path, dialect.Placeholder(JsonValue), string(op.Value))
+
+		case patchReplace:
+			if overlaps(op.path) {
+				return nil, false
+			}
+			statement.guard(existsGuard(dialect, field, path))
+			set(path, dialect.Placeholder(JsonValue), string(op.Value))
+
+		case patchRemove:
+			if overlaps(op.path) {
+				return nil, false
+			}
+			statement.guard(existsGuard(dialect, field, path))
+			statement.value = dialect.JSONRemove(statement.value, path)
+
+		case patchMove, patchCopy:
+			from, ok := memberPath(op.from)
+			if !ok || len(op.from) == 0 || overlaps(op.from, op.path) {
+				return nil, false
+			}
+			statement.guard(existsGuard(dialect, field, from))
+			statement.guard(objectGuard(dialect, field, parent))
+			if op.Op == patchMove {
+				statement.value = dialect.JSONRemove(statement.value, from)
+			}
+			set(path, dialect.JSONQuery(field, from))
+		}
+	}
+	return statement, true
+}
+
+// mergePatchSQL translates the merge patch into a statement that updates field. It returns false when patch
+// is not an object or has members which names need quoting.
+func mergePatchSQL(dialect Dialect, field string, patch interface{}) (*patchStatement, bool) {
+	if _, ok := patch.(map[string]interface{}); !ok {
+		return nil, false
+	}
+
+	statement := &patchStatement{value: field}
+
+	var merge func(tokens []string, value interface{}) bool
+	merge = func(tokens []string, value interface{}) bool {
+		path, ok := memberPath(tokens)
+		if !ok {
+			return false
+		}
+
+		members, ok := value.(map[string]interface{})
+		if !ok {
+			if value == nil {
+				statement.value = dialect.JSONRemove(statement.value, path)
+				return true
+			}
+
+			data, err := json.Marshal(value)
+			if err != nil {
+				return false
+			}
+			statement.value = dialect.JSONSet(statement.value, path, dialect.JSONValue(dialect.Placeholder(JsonValue)))
+			statement.args = append(statement.args, string(data))
+			return true
+		}
+
+		statement.guard(objectGuard(dialect, field, path))
+
+		names := make([]string, 0, len(members))
+		for name := range members {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if !merge(append(tokens[:len(tokens):len(tokens)], name), members[name]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if !merge(nil, patch) {
+		return nil, false
+	}
+	return statement, true
+}
+
+// patchContext updates the entry matched by key with statement, if it is not nil. When there is no statement or when
+// its guards do not hold, the entry is read, patched with apply and written back in a transaction. apply reports
+// the errors that made the guards fail.
+func (s *JsonValueHolder) patchContext(ctx context.Context, key BoolExpr, statement *patchStatement, apply func(doc interface{}) (interface{}, error)) error {
+	clause, args, err := s.condition(key)
+	if err != nil {
+		return err
+	}
+
+	if statement != nil {
+		where, whereArgs := s.visible(strings.Join(append([]string{"(" + clause + ")"}, statement.guards...), " and "), append(args, statement.guardArgs...))
+		rawQuery := fmt.Sprintf("update $table$ set %s=%s%s where %s;", s.field, statement.value, s.versionUpdate(), where)
+		result := s.client(ctx).ExecContext(ctx, rawQuery, append(statement.args, whereArgs...)...)
+		if result.Error != nil || result.AffectedRows > 0 {
+			return result.Error
+		}
+	}
+
+	owned := s.tx == nil && transaction(ctx) == nil
+	ctx, ts, err := s.Transaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = ts.rewrite(ctx, clause, args, apply)
+	if !owned {
+		return err
+	}
+	if err != nil {
+		_ = ts.tx.Rollback()
+		return err
+	}
+	return ts.tx.Commit()
+}
+
+// rewrite reads the value of the entry matched by clause, and writes back the value returned by apply.
+func (s *JsonValueHolder) rewrite(ctx context.Context, clause string, args []interface{}, apply func(doc interface{}) (interface{}, error)) error {
+	where, whereArgs := s.visible(clause, args)
+
+	var (
+		value   string
+		version int64
+	)
+	if s.versioned {
+		o, err := s.client(ctx).QueryFirstContext(ctx, fmt.Sprintf("select %s, version from $table$ where %s;", s.field, where), VersionedValueScanner, whereArgs...)
+		if err != nil {
+			return err
+		}
+		v := o.(*VersionedValue)
+		value, version = v.Value, v.Version
+	} else {
+		o, err := s.client(ctx).QueryFirstContext(ctx, fmt.Sprintf("select %s from $table$ where %s;", s.field, where), StringScanner, whereArgs...)
+		if err != nil {
+			return err
+		}
+		value = o.(string)
+	}
+
+	doc, err := decodeJSON([]byte(value))
+	if err != nil {
+		return err
+	}
+
+	doc, err = apply(doc)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	if !s.versioned {
+		rawQuery := fmt.Sprintf("update $table$ set %s=? where %s;", s.field, where)
+		return s.client(ctx).ExecContext(ctx, rawQuery, append([]interface{}{string(data)}, whereArgs...)...).Error
+	}
+
+	rawQuery := fmt.Sprintf("update $table$ set %s=?, version=version+1 where %s and version=?;", s.field, where)
+	result := s.client(ctx).ExecContext(ctx, rawQuery, append(append([]interface{}{string(data)}, whereArgs...), version)...)
+	return versionResultError(result, version)
+}
+
+// applyPatchContext applies the JSON Patch document patch (RFC 6902) to the value of the entry matched by key.
+func (s *JsonValueHolder) applyPatchContext(ctx context.Context, key BoolExpr, patch string) error {
+	ops, err := parsePatch(patch)
+	if err != nil {
+		return err
+	}
+
+	statement, _ := patchSQL(s.dialect, s.field, ops)
+	return s.patchContext(ctx, key, statement, func(doc interface{}) (interface{}, error) {
+		return applyPatch(doc, ops)
+	})
+}
+
+// applyMergePatchContext applies the JSON Merge Patch document doc (RFC 7396) to the value of the entry matched by key.
+func (s *JsonValueHolder) applyMergePatchContext(ctx context.Context, key BoolExpr, doc string) error {
+	patch, err := decodeJSON([]byte(doc))
+	if err != nil {
+		return err
+	}
+
+	statement, _ := mergePatchSQL(s.dialect, s.field, patch)
+	return s.patchContext(ctx, key, statement, func(value interface{}) (interface{}, error) {
+		return mergePatch(value, patch), nil
+	})
+}
@@ -0,0 +1,124 @@
+package bome
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func patchedJSON(doc string, patch string) (string, error) {
+	ops, err := parsePatch(patch)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := decodeJSON([]byte(doc))
+	if err != nil {
+		return "", err
+	}
+
+	value, err = applyPatch(value, ops)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(value)
+	return string(data), err
+}
+
+func TestApplyPatch(t *testing.T) {
+	Convey("JSON Patch operations are applied in order", t, func() {
+		doc, err := patchedJSON(`{"foo": ["bar", "baz"], "n": 1.50}`, `[
+			{"op": "add", "path": "/foo/1", "value": "qux"},
+			{"op": "add", "path": "/foo/-", "value": "end"},
+			{"op": "remove", "path": "/foo/0"},
+			{"op": "replace", "path": "/n", "value": 2},
+			{"op": "copy", "from": "/foo", "path": "/bar"},
+			{"op": "move", "from": "/bar/0", "path": "/a~1b"},
+			{"op": "test", "path": "/foo", "value": ["qux", "baz", "end"]}
+		]`)
+		So(err, ShouldBeNil)
+		So(doc, ShouldEqual, `{"a/b":"qux","bar":["baz","end"],"foo":["qux","baz","end"],"n":2}`)
+
+		doc, err = patchedJSON(`{"n": 1.50}`, `[{"op": "test", "path": "/n", "value": 1.5}, {"op": "add", "path": "", "value": [1]}]`)
+		So(err, ShouldBeNil)
+		So(doc, ShouldEqual, `[1]`)
+	})
+
+	Convey("Invalid operations fail with a PatchError", t, func() {
+		for _, patch := range []string{
+			`[{"op": "test", "path": "/a", "value": 2}]`,
+			`[{"op": "replace", "path": "/missing", "value": 2}]`,
+			`[{"op": "remove", "path": "/b/5"}]`,
+			`[{"op": "add", "path": "/b/01", "value": 2}]`,
+			`[{"op": "add", "path": "/a/x", "value": 2}]`,
+			`[{"op": "move", "from": "/b", "path": "/b/0"}]`,
+			`[{"op": "copy", "from": "/missing", "path": "/c"}]`,
+			`[{"op": "add", "path": "/c"}]`,
+			`[{"op": "increment", "path": "/a"}]`,
+			`[{"op": "add", "path": "c", "value": 2}]`,
+		} {
+			_, err := patchedJSON(`{"a": 1, "b": [1]}`, patch)
+			So(IsPatchError(err), ShouldBeTrue)
+		}
+	})
+
+	Convey("Merge patches replace, add and remove members", t, func() {
+		patch, err := decodeJSON([]byte(`{"a": "z", "c": {"f": null, "g": 1}, "d": {"e": null, "h": 2}}`))
+		So(err, ShouldBeNil)
+
+		doc, err := decodeJSON([]byte(`{"a": "b", "c": {"d": "e", "f": "g"}, "d": 1}`))
+		So(err, ShouldBeNil)
+
+		data, err := json.Marshal(mergePatch(doc, patch))
+		So(err, ShouldBeNil)
+		So(string(data), ShouldEqual, `{"a":"z","c":{"d":"e","g":1},"d":{"h":2}}`)
+	})
+}
+
+func TestPatchSQL(t *testing.T) {
+	Convey("Patches on object members are translated into a single statement", t, func() {
+		ops, err := parsePatch(`[
+			{"op": "test", "path": "/v", "value": 1},
+			{"op": "replace", "path": "/v", "value": 2},
+			{"op": "remove", "path": "/a/b"},
+			{"op": "move", "from": "/c", "path": "/d"}
+		]`)
+		So(err, ShouldBeNil)
+
+		statement, ok := patchSQL(PostgresDialect{}, "value", ops)
+		So(ok, ShouldBeTrue)
+		So(statement.value, ShouldEqual, "jsonb_set(((jsonb_set(value, '{v}', to_jsonb(cast(? as jsonb)), true) #- '{a,b}') #- '{c}'), '{d}', to_jsonb((value->'c')), true)")
+		So(statement.args, ShouldResemble, []interface{}{"2"})
+		So(statement.guards, ShouldResemble, []string{
+			"(value->'v') = cast(? as jsonb)",
+			"jsonb_typeof((value->'v')) is not null",
+			"jsonb_typeof((value->'a'->'b')) is not null",
+			"jsonb_typeof((value->'c')) is not null",
+			"jsonb_typeof(value) = 'object'",
+		})
+		So(statement.guardArgs, ShouldResemble, []interface{}{"1"})
+
+		statement, ok = mergePatchSQL(SQLiteDialect{}, "value", map[string]interface{}{"a": nil, "b": map[string]interface{}{"c": json.Number("1")}})
+		So(ok, ShouldBeTrue)
+		So(statement.value, ShouldEqual, "json_set(json_remove(value, '$.a'), '$.b.c', json(?))")
+		So(statement.guards, ShouldResemble, []string{"json_type(value, '$') = 'object'", "json_type(value, '$.b') = 'object'"})
+	})
+
+	Convey("Patches on array elements, overlapping paths or late tests are not translated", t, func() {
+		for _, patch := range []string{
+			`[{"op": "add", "path": "/a/0", "value": 1}]`,
+			`[{"op": "add", "path": "/a b", "value": 1}]`,
+			`[{"op": "replace", "path": "", "value": 1}]`,
+			`[{"op": "add", "path": "/a", "value": {}}, {"op": "add", "path": "/a/b", "value": 1}]`,
+			`[{"op": "add", "path": "/a", "value": 1}, {"op": "test", "path": "/a", "value": 1}]`,
+		} {
+			ops, err := parsePatch(patch)
+			So(err, ShouldBeNil)
+
+			_, ok := patchSQL(SQLiteDialect{}, "value", ops)
+			So(ok, ShouldBeFalse)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package bome
+
+import (
+	"strings"
+)
+
+// SortOrder is the direction of a sort key.
+type SortOrder int
+
+const (
+	// Asc sorts values in ascending order.
+	Asc SortOrder = iota
+
+	// Desc sorts values in descending order.
+	Desc
+)
+
+// SortKey sorts query results by the value found at a JSON path of entry values.
+type SortKey struct {
+	Path  string
+	Order SortOrder
+
+	// Numeric compares values as numbers. They are compared as text otherwise.
+	Numeric bool
+}
+
+// OrderBy returns the key that sorts results by the text value found at path.
+func OrderBy(path string, order SortOrder) SortKey {
+	return SortKey{Path: path, Order: order}
+}
+
+// OrderByNumber returns the key that sorts results by the numeric value found at path.
+func OrderByNumber(path string, order SortOrder) SortKey {
+	return SortKey{Path: path, Order: order, Numeric: true}
+}
+
+// QueryOptions configures the order and the projection of JSON queries results.
+type QueryOptions struct {
+	// Sort are the keys results are sorted by, in order of precedence.
+	Sort []SortKey
+
+	// Fields are the JSON paths projected into the returned values. When set, values are replaced with JSON objects
+	// mapping each path, without its leading "$.", to the value found at that path. Paths with no value are mapped to null.
+	Fields []string
+}
+
+// mergeQueryOptions merges the options passed to a query method.
+func mergeQueryOptions(opts []QueryOptions) QueryOptions {
+	var merged QueryOptions
+	for _, opt := range opts {
+		merged.Sort = append(merged.Sort, opt.Sort...)
+		merged.Fields = append(merged.Fields, opt.Fields...)
+	}
+	return merged
+}
+
+// orderBySQL returns the order by clause of the options sort keys, or an empty string if there is none.
+func (opts QueryOptions) orderBySQL(dialect Dialect, field string) string {
+	if len(opts.Sort) == 0 {
+		return ""
+	}
+
+	keys := make([]string, len(opts.Sort))
+	for i, key := range opts.Sort {
+		keys[i] = dialect.SortKey(dialect.JSONExtract(field, key.Path), key.Numeric)
+		if key.Order == Desc {
+			keys[i] += " desc"
+		}
+	}
+	return " order by " + strings.Join(keys, ", ")
+}
+
+// projectedColumns returns columns with field replaced by the projection of the options fields.
+func (opts QueryOptions) projectedColumns(dialect Dialect, columns string, field string) string {
+	if len(opts.Fields) == 0 {
+		return columns
+	}
+
+	values := make([]string, 0, 2*len(opts.Fields))
+	for _, path := range opts.Fields {
+		name := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+		values = append(values, dialect.QuoteString(name), dialect.JSONQuery(field, path))
+	}
+	projection := dialect.JSONObject(values...)
+
+	selected := strings.Split(columns, ", ")
+	for i, column := range selected {
+		if column == field {
+			selected[i] = projection + " as " + field
+		}
+	}
+	return strings.Join(selected, ", ")
+}
@@ -0,0 +1,339 @@
+package bome
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/omecodes/errors"
+)
+
+// Queue is a durable priority queue. Items with the highest priority are dequeued first, in insertion order.
+//
+// Dequeued items are leased: they are hidden from other consumers until they are acknowledged, released
+// with Nack or until the lease expires. Items that are dequeued more than the configured max attempts are
+// dead-lettered and are no longer dequeued.
+type Queue struct {
+	*DB
+	tx          *TX
+	dialect     Dialect
+	tableName   string
+	maxAttempts int64
+}
+
+// QueueStats is a snapshot of the items of a queue.
+type QueueStats struct {
+	// Ready is the number of items that can be dequeued.
+	Ready int64
+
+	// InFlight is the number of leased items.
+	InFlight int64
+
+	// Delayed is the number of items that are not visible yet.
+	Delayed int64
+
+	// Dead is the number of dead-lettered items.
+	Dead int64
+}
+
+const queueItemColumns = "id, value, priority, attempts, lease"
+
+func (q *Queue) Table() string {
+	return q.tableName
+}
+
+func (q *Queue) Keys() []string {
+	return []string{
+		"id",
+	}
+}
+
+func (q *Queue) Transaction(ctx context.Context) (context.Context, *Queue, error) {
+	tx := transaction(ctx)
+	if tx == nil {
+		if q.tx != nil {
+			return contextWithTransaction(ctx, q.tx.New(q.DB)), q, nil
+		}
+
+		var err error
+		tx, err = q.BeginTxContext(ctx, nil)
+		if err != nil {
+			return ctx, nil, err
+		}
+
+		newCtx := contextWithTransaction(ctx, tx)
+		return newCtx, &Queue{
+			DB:          q.DB,
+			tableName:   q.tableName,
+			tx:          tx,
+			dialect:     q.dialect,
+			maxAttempts: q.maxAttempts,
+		}, nil
+	}
+
+	if q.tx != nil {
+		if q.tx.db.sqlDb != tx.db.sqlDb {
+			newCtx := ContextWithCommitActions(ctx, tx.Commit)
+			newCtx = ContextWithRollbackActions(newCtx, tx.Rollback)
+			return contextWithTransaction(newCtx, q.tx.New(q.DB)), q, nil
+		}
+		return ctx, q, nil
+	}
+
+	tx = tx.New(q.DB)
+	newCtx := contextWithTransaction(ctx, tx)
+	return newCtx, &Queue{
+		DB:          q.DB,
+		tableName:   q.tableName,
+		tx:          tx,
+		dialect:     q.dialect,
+		maxAttempts: q.maxAttempts,
+	}, nil
+}
+
+func (q *Queue) Commit() error {
+	if q.tx != nil {
+		return q.tx.Commit()
+	}
+	return nil
+}
+
+func (q *Queue) Rollback() error {
+	if q.tx != nil {
+		return q.tx.Rollback()
+	}
+	return nil
+}
+
+func (q *Queue) Client() Client {
+	if q.tx != nil {
+		return q.tx
+	}
+	return q.DB
+}
+
+// client returns the client that runs queries for ctx. When the collection is not bound to a transaction,
+// the transaction stored in ctx is used if it was started on the same database.
+func (q *Queue) client(ctx context.Context) Client {
+	if q.tx == nil {
+		if tx := transaction(ctx); tx != nil && tx.db.sqlDb == q.DB.sqlDb {
+			return tx.New(q.DB)
+		}
+	}
+	return q.Client()
+}
+
+// Enqueue adds value to the queue. The item can not be dequeued before delay is elapsed.
+func (q *Queue) Enqueue(value interface{}, priority int64, delay time.Duration) error {
+	return q.EnqueueContext(context.Background(), value, priority, delay)
+}
+
+func (q *Queue) EnqueueContext(ctx context.Context, value interface{}, priority int64, delay time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	visibleAt := time.Now().Add(delay).UnixMilli()
+	return q.client(ctx).ExecContext(ctx, "insert into $table$ (value, priority, visible_at, attempts, dead) values (?, ?, ?, 0, 0);",
+		string(data), priority, visibleAt).Error
+}
+
+// Dequeue leases the next visible item for visibilityTimeout. It returns a not found error when there is no visible item.
+//
+// On engines that support returning clauses the item is leased with a single update-returning statement.
+// Other engines select the item, locking it and skipping locked items, in a transaction.
+func (q *Queue) Dequeue(ctx context.Context, visibilityTimeout time.Duration) (*QueueItem, error) {
+	now := time.Now()
+	if err := q.deadLetterExpired(ctx, now); err != nil {
+		return nil, err
+	}
+
+	lease, err := newLeaseToken()
+	if err != nil {
+		return nil, err
+	}
+	visibleAt := now.Add(visibilityTimeout).UnixMilli()
+
+	if q.dialect.SupportsReturning() {
+		rawQuery := "update $table$ set visible_at=?, attempts=attempts+1, lease=? " +
+			"where id=(select id from $table$ where dead=0 and visible_at<=? order by priority desc, id limit 1" + q.dialect.SkipLocked() + ") " +
+			"returning " + queueItemColumns + ";"
+		o, err := q.client(ctx).QueryFirstContext(ctx, rawQuery, QueueItemScanner, visibleAt, lease, now.UnixMilli())
+		if err != nil {
+			return nil, err
+		}
+		return o.(*QueueItem), nil
+	}
+
+	owned := q.tx == nil && transaction(ctx) == nil
+	ctx, queue, err := q.Transaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := queue.lockNext(ctx, now, visibleAt, lease)
+	if err != nil {
+		if owned {
+			_ = queue.Rollback()
+		}
+		return nil, err
+	}
+
+	if owned {
+		if err = queue.Commit(); err != nil {
+			return nil, err
+		}
+	}
+	return item, nil
+}
+
+func (q *Queue) lockNext(ctx context.Context, now time.Time, visibleAt int64, lease string) (*QueueItem, error) {
+	rawQuery := "select " + queueItemColumns + " from $table$ where dead=0 and visible_at<=? order by priority desc, id limit 1" + q.dialect.SkipLocked() + ";"
+	o, err := q.client(ctx).QueryFirstContext(ctx, rawQuery, QueueItemScanner, now.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+
+	item := o.(*QueueItem)
+	err = q.client(ctx).ExecContext(ctx, "update $table$ set visible_at=?, attempts=attempts+1, lease=? where id=?;", visibleAt, lease, item.ID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	item.Attempts++
+	item.Lease = lease
+	return item, nil
+}
+
+// deadLetterExpired dead-letters the items which lease expired after the last allowed attempt.
+func (q *Queue) deadLetterExpired(ctx context.Context, now time.Time) error {
+	if q.maxAttempts <= 0 {
+		return nil
+	}
+	return q.client(ctx).ExecContext(ctx, "update $table$ set dead=1, lease=null where dead=0 and attempts>=? and visible_at<=?;",
+		q.maxAttempts, now.UnixMilli()).Error
+}
+
+// Ack removes item from the queue. It returns a not found error if the item lease expired and was taken by another consumer.
+func (q *Queue) Ack(item *QueueItem) error {
+	return q.AckContext(context.Background(), item)
+}
+
+func (q *Queue) AckContext(ctx context.Context, item *QueueItem) error {
+	result := q.client(ctx).ExecContext(ctx, "delete from $table$ where id=? and lease=?;", item.ID, item.Lease)
+	return leaseResultError(result)
+}
+
+// Nack releases item so that it can be dequeued again after delay. The item is dead-lettered
+// if it was dequeued max attempts times.
+func (q *Queue) Nack(item *QueueItem, delay time.Duration) error {
+	return q.NackContext(context.Background(), item, delay)
+}
+
+func (q *Queue) NackContext(ctx context.Context, item *QueueItem, delay time.Duration) error {
+	var result Result
+	if q.maxAttempts > 0 && item.Attempts >= q.maxAttempts {
+		result = q.client(ctx).ExecContext(ctx, "update $table$ set dead=1, lease=null where id=? and lease=?;", item.ID, item.Lease)
+	} else {
+		visibleAt := time.Now().Add(delay).UnixMilli()
+		result = q.client(ctx).ExecContext(ctx, "update $table$ set visible_at=?, lease=null where id=? and lease=?;", visibleAt, item.ID, item.Lease)
+	}
+	return leaseResultError(result)
+}
+
+// Peek returns the next visible item without leasing it.
+func (q *Queue) Peek() (*QueueItem, error) {
+	return q.PeekContext(context.Background())
+}
+
+func (q *Queue) PeekContext(ctx context.Context) (*QueueItem, error) {
+	rawQuery := "select " + queueItemColumns + " from $table$ where dead=0 and visible_at<=? order by priority desc, id limit 1;"
+	o, err := q.client(ctx).QueryFirstContext(ctx, rawQuery, QueueItemScanner, time.Now().UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	return o.(*QueueItem), nil
+}
+
+// Len returns the number of items that are not dead-lettered.
+func (q *Queue) Len() (int64, error) {
+	return q.LenContext(context.Background())
+}
+
+func (q *Queue) LenContext(ctx context.Context) (int64, error) {
+	o, err := q.client(ctx).QueryFirstContext(ctx, "select count(*) from $table$ where dead=0;", IntScanner)
+	if err != nil {
+		return 0, err
+	}
+	return o.(int64), nil
+}
+
+func (q *Queue) Stats() (*QueueStats, error) {
+	return q.StatsContext(context.Background())
+}
+
+func (q *Queue) StatsContext(ctx context.Context) (*QueueStats, error) {
+	now := time.Now().UnixMilli()
+	stats := new(QueueStats)
+	counts := []struct {
+		value *int64
+		where string
+		args  []interface{}
+	}{
+		{value: &stats.Ready, where: "dead=0 and visible_at<=?", args: []interface{}{now}},
+		{value: &stats.InFlight, where: "dead=0 and visible_at>? and lease is not null", args: []interface{}{now}},
+		{value: &stats.Delayed, where: "dead=0 and visible_at>? and lease is null", args: []interface{}{now}},
+		{value: &stats.Dead, where: "dead=1"},
+	}
+
+	for _, count := range counts {
+		o, err := q.client(ctx).QueryFirstContext(ctx, "select count(*) from $table$ where "+count.where+";", IntScanner, count.args...)
+		if err != nil {
+			return nil, err
+		}
+		*count.value = o.(int64)
+	}
+	return stats, nil
+}
+
+// DeadLetters returns a cursor over the dead-lettered items. Entries are *QueueItem.
+func (q *Queue) DeadLetters() (Cursor, error) {
+	return q.DeadLettersContext(context.Background())
+}
+
+func (q *Queue) DeadLettersContext(ctx context.Context) (Cursor, error) {
+	return q.client(ctx).QueryContext(ctx, "select "+queueItemColumns+" from $table$ where dead=1 order by id;", QueueItemScanner)
+}
+
+func (q *Queue) Clear() error {
+	return q.ClearContext(context.Background())
+}
+
+func (q *Queue) ClearContext(ctx context.Context) error {
+	return q.client(ctx).ExecContext(ctx, "delete from $table$;").Error
+}
+
+func (q *Queue) Close() error {
+	return q.DB.sqlDb.Close()
+}
+
+func leaseResultError(result Result) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.AffectedRows == 0 {
+		return errors.NotFound()
+	}
+	return nil
+}
+
+func newLeaseToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -0,0 +1,106 @@
+package bome
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/omecodes/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var dbQueue *Queue
+
+func initQueue(_ *testing.T) {
+	if dbQueue == nil {
+		db, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+
+		_, err = db.Exec("drop table if exists jobs")
+		So(err, ShouldBeNil)
+
+		dbQueue, err = Build().SetConn(db).SetDialect(testDialect).SetTableName("jobs").Queue(WithMaxAttempts(2))
+		So(err, ShouldBeNil)
+		So(dbQueue, ShouldNotBeNil)
+	}
+}
+
+func TestQueue_Dequeue(t *testing.T) {
+	Convey("Items are dequeued by priority then in insertion order", t, func() {
+		initQueue(t)
+		ctx := context.Background()
+
+		So(dbQueue.Enqueue("low", 0, 0), ShouldBeNil)
+		So(dbQueue.Enqueue("high", 10, 0), ShouldBeNil)
+		So(dbQueue.Enqueue("later", 20, time.Hour), ShouldBeNil)
+		So(dbQueue.Enqueue("low-2", 0, 0), ShouldBeNil)
+
+		peeked, err := dbQueue.Peek()
+		So(err, ShouldBeNil)
+		So(peeked.Value, ShouldEqual, `"high"`)
+
+		item, err := dbQueue.Dequeue(ctx, time.Minute)
+		So(err, ShouldBeNil)
+		So(item.Value, ShouldEqual, `"high"`)
+		So(item.Attempts, ShouldEqual, 1)
+		So(item.Lease, ShouldNotBeEmpty)
+		So(dbQueue.Ack(item), ShouldBeNil)
+		So(errors.IsNotFound(dbQueue.Ack(item)), ShouldBeTrue)
+
+		item, err = dbQueue.Dequeue(ctx, time.Minute)
+		So(err, ShouldBeNil)
+		So(item.Value, ShouldEqual, `"low"`)
+
+		next, err := dbQueue.Dequeue(ctx, time.Minute)
+		So(err, ShouldBeNil)
+		So(next.Value, ShouldEqual, `"low-2"`)
+
+		_, err = dbQueue.Dequeue(ctx, time.Minute)
+		So(errors.IsNotFound(err), ShouldBeTrue)
+
+		stats, err := dbQueue.Stats()
+		So(err, ShouldBeNil)
+		So(stats.InFlight, ShouldEqual, 2)
+		So(stats.Delayed, ShouldEqual, 1)
+
+		So(dbQueue.Nack(item, 0), ShouldBeNil)
+		item, err = dbQueue.Dequeue(ctx, time.Minute)
+		So(err, ShouldBeNil)
+		So(item.Value, ShouldEqual, `"low"`)
+		So(item.Attempts, ShouldEqual, 2)
+
+		So(dbQueue.Nack(item, 0), ShouldBeNil)
+		stats, err = dbQueue.Stats()
+		So(err, ShouldBeNil)
+		So(stats.Dead, ShouldEqual, 1)
+
+		size, err := dbQueue.Len()
+		So(err, ShouldBeNil)
+		So(size, ShouldEqual, 2)
+
+		c, err := dbQueue.DeadLetters()
+		So(err, ShouldBeNil)
+		So(c.HasNext(), ShouldBeTrue)
+		dead, err := c.Entry()
+		So(err, ShouldBeNil)
+		So(dead.(*QueueItem).Value, ShouldEqual, `"low"`)
+		So(c.Close(), ShouldBeNil)
+	})
+
+	Convey("Expired leases make items visible again", t, func() {
+		initQueue(t)
+		ctx := context.Background()
+		So(dbQueue.Clear(), ShouldBeNil)
+
+		So(dbQueue.Enqueue("job", 0, 0), ShouldBeNil)
+		item, err := dbQueue.Dequeue(ctx, -time.Second)
+		So(err, ShouldBeNil)
+
+		again, err := dbQueue.Dequeue(ctx, time.Minute)
+		So(err, ShouldBeNil)
+		So(again.ID, ShouldEqual, item.ID)
+		So(errors.IsNotFound(dbQueue.Ack(item)), ShouldBeTrue)
+		So(dbQueue.Ack(again), ShouldBeNil)
+	})
+}
@@ -3,7 +3,6 @@ package bome
 import (
 	"database/sql"
 	"encoding/json"
-	"reflect"
 
 	"github.com/omecodes/errors"
 )
@@ -39,6 +38,14 @@ type cursor struct {
 	rows    *sql.Rows
 }
 
+// cursorError returns the error that ended the iteration of c, if any.
+func cursorError(c Cursor) error {
+	if rc, ok := c.(*cursor); ok {
+		return rc.rows.Err()
+	}
+	return nil
+}
+
 func (c *cursor) HasNext() bool {
 	return c.rows.Next()
 }
@@ -73,10 +80,6 @@ func (c *cursor) Read(o interface{}) error {
 	if err != nil {
 		return err
 	}
-	if o == nil {
-		o = reflect.New(reflect.TypeOf(o))
-	}
-
 	return json.Unmarshal([]byte(value), o)
 }
 
@@ -1,5 +1,14 @@
 package bome
 
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/omecodes/errors"
+)
+
 const (
 	// IntScanner is the key for integer scanner.
 	IntScanner = "scanInt"
@@ -24,6 +33,22 @@ const (
 
 	// PairListEntryScanner is the key for pairs list scanner.
 	PairListEntryScanner = "scanPairListEntry"
+
+	// QueueItemScanner is the key for queue item scanner.
+	QueueItemScanner = "scanQueueItem"
+
+	// VersionedValueScanner is the key for versioned value scanner.
+	VersionedValueScanner = "scanVersionedValue"
+
+	versionedListEntryScanner = "scanVersionedListEntry"
+
+	aggregateRowScanner = "scanAggregateRow"
+
+	indexColumnScanner = "scanIndexColumn"
+
+	columnScanner = "scanColumn"
+
+	foreignKeyColumnScanner = "scanForeignKeyColumn"
 )
 
 var defaultScanners = map[string]Scanner{
@@ -48,4 +73,153 @@ var defaultScanners = map[string]Scanner{
 	MapEntryScanner:       NewScannerFunc(scanMapEntry),
 	DoubleMapEntryScanner: NewScannerFunc(scanDoubleMapEntry),
 	PairListEntryScanner:  NewScannerFunc(scanPairListEntry),
+	QueueItemScanner:      NewScannerFunc(scanQueueItem),
+	VersionedValueScanner: NewScannerFunc(scanVersionedValue),
+
+	versionedListEntryScanner: NewScannerFunc(scanVersionedListEntry),
+	aggregateRowScanner:       NewScannerFunc(scanAggregateRow),
+	indexColumnScanner:        NewScannerFunc(scanIndexColumn),
+	columnScanner:             NewScannerFunc(scanColumn),
+	foreignKeyColumnScanner:   NewScannerFunc(scanForeignKeyColumn),
+}
+
+// structField is a struct field that receives the value of a column.
+type structField struct {
+	index []int
+	json  bool
+}
+
+// structScanner scans rows into new values of a struct type. Columns are mapped to fields with the "db" tag.
+type structScanner struct {
+	typ    reflect.Type
+	fields map[string]*structField
+	order  []string
+}
+
+// NewStructScanner creates a scanner that reads rows into new values of prototype type.
+// prototype is a struct or a pointer to a struct. Scanned entries are pointers to new structs.
+//
+// Columns are mapped to fields with the "db" tag, the lower-cased field name is used when the tag is missing
+// and "-" ignores the field. The "json" option (db:"profile,json") decodes the column JSON content into the field.
+// Fields of embedded structs are mapped as if they were declared in the outer struct.
+// Pointer fields are left nil when the column value is NULL.
+func NewStructScanner(prototype interface{}) (Scanner, error) {
+	t := reflect.TypeOf(prototype)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.NotSupported()
+	}
+
+	s := &structScanner{
+		typ:    t,
+		fields: map[string]*structField{},
+	}
+	s.addFields(t, nil)
+	return s, nil
+}
+
+func (s *structScanner) addFields(t reflect.Type, parent []int) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup("db")
+		if tag == "-" {
+			continue
+		}
+
+		index := append(append([]int{}, parent...), i)
+		if f.Anonymous && !hasTag {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.addFields(ft, index)
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		name := strings.ToLower(parts[0])
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if _, found := s.fields[name]; found {
+			continue
+		}
+
+		field := &structField{index: index}
+		for _, option := range parts[1:] {
+			if option == "json" {
+				field.json = true
+			}
+		}
+		s.fields[name] = field
+		s.order = append(s.order, name)
+	}
+}
+
+func (s *structScanner) ScanRow(row Row) (interface{}, error) {
+	columns := s.order
+	if r, ok := row.(interface{ Columns() ([]string, error) }); ok {
+		var err error
+		columns, err = r.Columns()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	o := reflect.New(s.typ)
+	var (
+		destinations = make([]interface{}, len(columns))
+		jsonValues   = map[*structField]*sql.NullString{}
+	)
+	for i, column := range columns {
+		field, found := s.fields[strings.ToLower(column)]
+		if !found {
+			destinations[i] = new(interface{})
+			continue
+		}
+
+		if field.json {
+			value := new(sql.NullString)
+			jsonValues[field] = value
+			destinations[i] = value
+			continue
+		}
+		destinations[i] = fieldByIndex(o.Elem(), field.index).Addr().Interface()
+	}
+
+	if err := row.Scan(destinations...); err != nil {
+		return nil, err
+	}
+
+	for field, value := range jsonValues {
+		if !value.Valid {
+			continue
+		}
+		if err := json.Unmarshal([]byte(value.String), fieldByIndex(o.Elem(), field.index).Addr().Interface()); err != nil {
+			return nil, err
+		}
+	}
+	return o.Interface(), nil
+}
+
+// fieldByIndex returns the nested field of v at index. Nil embedded struct pointers are allocated.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
 }
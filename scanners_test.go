@@ -0,0 +1,79 @@
+package bome
+
+import (
+	"database/sql"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type scannedAudit struct {
+	CreatedBy string  `db:"created_by"`
+	Comment   *string `db:"comment"`
+}
+
+type scannedAccount struct {
+	scannedAudit
+	ID      int64             `db:"id"`
+	Email   string            `db:"email"`
+	Profile map[string]string `db:"profile,json"`
+	Ignored string            `db:"-"`
+}
+
+func TestDB_RegisterStructScanner(t *testing.T) {
+	Convey("Rows are scanned into tagged struct fields", t, func() {
+		conn, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+
+		db, err := NewLite(conn)
+		So(err, ShouldBeNil)
+
+		err = db.AddTableDefinition("create table if not exists accounts (id integer not null primary key, email varchar(255) not null, profile text, created_by varchar(255) not null, comment text);").Init()
+		So(err, ShouldBeNil)
+
+		So(db.RegisterStructScanner("account", 1), ShouldNotBeNil)
+		So(db.RegisterStructScanner("account", &scannedAccount{}), ShouldBeNil)
+
+		So(db.Exec("delete from accounts;").Error, ShouldBeNil)
+		So(db.Exec("insert into accounts values (?, ?, ?, ?, ?);", 1, "john@doe.com", `{"lang":"fr"}`, "admin", "first").Error, ShouldBeNil)
+		So(db.Exec("insert into accounts values (?, ?, ?, ?, ?);", 2, "jane@doe.com", nil, "admin", nil).Error, ShouldBeNil)
+
+		o, err := db.QueryFirst("select * from accounts where id=?;", "account", 1)
+		So(err, ShouldBeNil)
+
+		account := o.(*scannedAccount)
+		So(account.Email, ShouldEqual, "john@doe.com")
+		So(account.Profile["lang"], ShouldEqual, "fr")
+		So(account.CreatedBy, ShouldEqual, "admin")
+		So(*account.Comment, ShouldEqual, "first")
+
+		o, err = db.QueryFirst("select id, comment, profile from accounts where id=?;", "account", 2)
+		So(err, ShouldBeNil)
+
+		account = o.(*scannedAccount)
+		So(account.ID, ShouldEqual, 2)
+		So(account.Comment, ShouldBeNil)
+		So(account.Profile, ShouldBeNil)
+	})
+
+	Convey("Tags match column names whatever their case", t, func() {
+		conn, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+
+		db, err := NewLite(conn)
+		So(err, ShouldBeNil)
+
+		type login struct {
+			UserID string `db:"userId"`
+		}
+		So(db.AddTableDefinition("create table if not exists logins (userId varchar(255) not null);").Init(), ShouldBeNil)
+		So(db.RegisterStructScanner("login", &login{}), ShouldBeNil)
+
+		So(db.Exec("delete from logins;").Error, ShouldBeNil)
+		So(db.Exec("insert into logins values (?);", "ada").Error, ShouldBeNil)
+
+		o, err := db.QueryFirst("select userId from logins;", "login")
+		So(err, ShouldBeNil)
+		So(o.(*login).UserID, ShouldEqual, "ada")
+	})
+}
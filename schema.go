@@ -0,0 +1,165 @@
+package bome
+
+import (
+	"github.com/omecodes/errors"
+)
+
+// ColumnInfo describes a table column, as returned by DB.Columns.
+type ColumnInfo struct {
+	Name string
+	Type string
+
+	// Nullable tells if the column accepts null values.
+	Nullable bool
+
+	// Default is the expression of the column default value. It is empty when the column has no default value.
+	Default string
+
+	// PrimaryKey tells if the column is part of the table primary key.
+	PrimaryKey bool
+}
+
+func scanColumn(row Row) (interface{}, error) {
+	c := new(ColumnInfo)
+	return c, row.Scan(&c.Name, &c.Type, &c.Nullable, &c.Default, &c.PrimaryKey)
+}
+
+// foreignKeyColumn is a row of Dialect.ForeignKeysQuery.
+type foreignKeyColumn struct {
+	name            string
+	column          string
+	refTable        string
+	refColumn       string
+	onDeleteCascade bool
+}
+
+func scanForeignKeyColumn(row Row) (interface{}, error) {
+	c := new(foreignKeyColumn)
+	return c, row.Scan(&c.name, &c.column, &c.refTable, &c.refColumn, &c.onDeleteCascade)
+}
+
+// Tables returns the names of the tables of the database.
+func (db *DB) Tables() ([]string, error) {
+	if !db.initDone {
+		return nil, errors.New()
+	}
+
+	c, err := db.Query(db.dialect.TablesQuery(), StringScanner)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = c.Close()
+	}()
+
+	var tables []string
+	for c.HasNext() {
+		name, err := c.Entry()
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, name.(string))
+	}
+	return tables, nil
+}
+
+// Columns returns the columns of table, in order.
+func (db *DB) Columns(table string) ([]*ColumnInfo, error) {
+	if !db.initDone {
+		return nil, errors.New()
+	}
+
+	c, err := db.Query(db.dialect.ColumnsQuery(table), columnScanner)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = c.Close()
+	}()
+
+	var columns []*ColumnInfo
+	for c.HasNext() {
+		o, err := c.Entry()
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, o.(*ColumnInfo))
+	}
+	return columns, nil
+}
+
+// Indexes returns the definitions of the indexes of table.
+func (db *DB) Indexes(table string) ([]*Index, error) {
+	if !db.initDone {
+		return nil, errors.New()
+	}
+
+	c, err := db.Query(db.dialect.IndexColumnsQuery(table), indexColumnScanner)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = c.Close()
+	}()
+
+	var indexes []*Index
+	for c.HasNext() {
+		o, err := c.Entry()
+		if err != nil {
+			return nil, err
+		}
+
+		column := o.(*indexColumn)
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != column.index {
+			indexes = append(indexes, &Index{Name: column.index, Table: table, NonUnique: !column.unique, Where: column.where})
+		}
+
+		index := indexes[len(indexes)-1]
+		order := Asc
+		if column.desc {
+			order = Desc
+		}
+		index.Fields = append(index.Fields, column.column)
+		index.Orders = append(index.Orders, order)
+		index.Lengths = append(index.Lengths, column.length)
+	}
+	return indexes, nil
+}
+
+// ForeignKeys returns the foreign keys of table.
+func (db *DB) ForeignKeys(table string) ([]*ForeignKey, error) {
+	if !db.initDone {
+		return nil, errors.New()
+	}
+
+	c, err := db.Query(db.dialect.ForeignKeysQuery(table), foreignKeyColumnScanner)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = c.Close()
+	}()
+
+	var keys []*ForeignKey
+	for c.HasNext() {
+		o, err := c.Entry()
+		if err != nil {
+			return nil, err
+		}
+
+		column := o.(*foreignKeyColumn)
+		if len(keys) == 0 || keys[len(keys)-1].Name != column.name {
+			keys = append(keys, &ForeignKey{
+				Name:            column.name,
+				Table:           &Keys{Table: table},
+				References:      &Keys{Table: column.refTable},
+				OnDeleteCascade: column.onDeleteCascade,
+			})
+		}
+
+		key := keys[len(keys)-1]
+		key.Table.Fields = append(key.Table.Fields, column.column)
+		key.References.Fields = append(key.References.Fields, column.refColumn)
+	}
+	return keys, nil
+}
@@ -0,0 +1,56 @@
+package bome
+
+import (
+	"database/sql"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDB_Schema(t *testing.T) {
+	Convey("Tables, columns, indexes and foreign keys are introspected", t, func() {
+		conn, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+
+		for _, table := range []string{"schema_members", "schema_groups"} {
+			_, err = conn.Exec("drop table if exists " + table)
+			So(err, ShouldBeNil)
+		}
+
+		db, err := NewLite(conn)
+		So(err, ShouldBeNil)
+		db.AddTableDefinition("create table if not exists schema_groups (name varchar(255) not null, region varchar(16) not null, primary key (name, region));")
+		db.AddTableDefinition("create table if not exists schema_members (id integer not null primary key, login varchar(255) default 'guest', " +
+			"group_name varchar(255), group_region varchar(16), " +
+			"foreign key (group_name, group_region) references schema_groups on delete cascade);")
+		So(db.Init(), ShouldBeNil)
+		So(db.EnsureIndex(Index{Name: "schema_members_login", Table: "schema_members", Fields: []string{"login"}}), ShouldBeNil)
+
+		tables, err := db.Tables()
+		So(err, ShouldBeNil)
+		So(tables, ShouldContain, "schema_groups")
+		So(tables, ShouldContain, "schema_members")
+
+		columns, err := db.Columns("schema_members")
+		So(err, ShouldBeNil)
+		So(columns, ShouldHaveLength, 4)
+		So(columns[0], ShouldResemble, &ColumnInfo{Name: "id", Type: "INTEGER", PrimaryKey: true})
+		So(columns[1], ShouldResemble, &ColumnInfo{Name: "login", Type: "varchar(255)", Nullable: true, Default: "'guest'"})
+
+		indexes, err := db.Indexes("schema_members")
+		So(err, ShouldBeNil)
+		So(indexes, ShouldHaveLength, 1)
+		So(indexes[0].Name, ShouldEqual, "schema_members_login")
+		So(indexes[0].NonUnique, ShouldBeFalse)
+		So(indexes[0].Fields, ShouldResemble, []string{"login"})
+
+		keys, err := db.ForeignKeys("schema_members")
+		So(err, ShouldBeNil)
+		So(keys, ShouldHaveLength, 1)
+		So(keys[0].OnDeleteCascade, ShouldBeTrue)
+		So(keys[0].Table, ShouldResemble, &Keys{Table: "schema_members", Fields: []string{"group_name", "group_region"}})
+		So(keys[0].References, ShouldResemble, &Keys{Table: "schema_groups", Fields: []string{"name", "region"}})
+
+		So(conn.Close(), ShouldBeNil)
+	})
+}
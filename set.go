@@ -0,0 +1,311 @@
+package bome
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/omecodes/errors"
+)
+
+// Set is a collection of unique string members.
+type Set struct {
+	*DB
+	tx        *TX
+	dialect   Dialect
+	tableName string
+}
+
+func (s *Set) Table() string {
+	return s.tableName
+}
+
+func (s *Set) Keys() []string {
+	return []string{
+		"name",
+	}
+}
+
+func (s *Set) Transaction(ctx context.Context) (context.Context, *Set, error) {
+	tx := transaction(ctx)
+	if tx == nil {
+		if s.tx != nil {
+			return contextWithTransaction(ctx, s.tx.New(s.DB)), s, nil
+		}
+
+		var err error
+		tx, err = s.BeginTxContext(ctx, nil)
+		if err != nil {
+			return ctx, nil, err
+		}
+
+		newCtx := contextWithTransaction(ctx, tx)
+		return newCtx, &Set{
+			DB:        s.DB,
+			tableName: s.tableName,
+			tx:        tx,
+			dialect:   s.dialect,
+		}, nil
+	}
+
+	if s.tx != nil {
+		if s.tx.db.sqlDb != tx.db.sqlDb {
+			newCtx := ContextWithCommitActions(ctx, tx.Commit)
+			newCtx = ContextWithRollbackActions(newCtx, tx.Rollback)
+			return contextWithTransaction(newCtx, s.tx.New(s.DB)), s, nil
+		}
+		return ctx, s, nil
+	}
+
+	tx = tx.New(s.DB)
+	newCtx := contextWithTransaction(ctx, tx)
+	return newCtx, &Set{
+		DB:        s.DB,
+		tableName: s.tableName,
+		tx:        tx,
+		dialect:   s.dialect,
+	}, nil
+}
+
+func (s *Set) Commit() error {
+	if s.tx != nil {
+		return s.tx.Commit()
+	}
+	return nil
+}
+
+func (s *Set) Rollback() error {
+	if s.tx != nil {
+		return s.tx.Rollback()
+	}
+	return nil
+}
+
+func (s *Set) Client() Client {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.DB
+}
+
+// client returns the client that runs queries for ctx. When the collection is not bound to a transaction,
+// the transaction stored in ctx is used if it was started on the same database.
+func (s *Set) client(ctx context.Context) Client {
+	if s.tx == nil {
+		if tx := transaction(ctx); tx != nil && tx.db.sqlDb == s.DB.sqlDb {
+			return tx.New(s.DB)
+		}
+	}
+	return s.Client()
+}
+
+// Add adds member to the set. Adding a member that is already in the set does nothing.
+func (s *Set) Add(member string) error {
+	return s.AddContext(context.Background(), member)
+}
+
+func (s *Set) AddContext(ctx context.Context, member string) error {
+	rawQuery := s.dialect.Upsert("$table$", []string{"name"}, []string{"name"}, []string{"name"})
+	return s.client(ctx).ExecContext(ctx, rawQuery, member).Error
+}
+
+// AddAll adds members to the set. It runs in the transaction of ctx or in its own.
+func (s *Set) AddAll(members ...string) error {
+	return s.AddAllContext(context.Background(), members...)
+}
+
+func (s *Set) AddAllContext(ctx context.Context, members ...string) error {
+	var rows []batchRow
+	seen := map[string]bool{}
+	for _, member := range members {
+		// A conflict update cannot affect the same row twice in one statement.
+		if !seen[member] {
+			seen[member] = true
+			rows = append(rows, batchRow{key: member, args: []interface{}{member}})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	owned := s.tx == nil && transaction(ctx) == nil
+	ctx, set, err := s.Transaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	single := s.dialect.Upsert("$table$", []string{"name"}, []string{"name"}, []string{"name"})
+	stmt := &statement{
+		width: 1,
+		query: func(n int) string {
+			return multiRow(single, 1, n) + ";"
+		},
+		exec: func(ctx context.Context, client Client, rawQuery string, _ int, args []interface{}) Result {
+			return client.ExecContext(ctx, rawQuery, args...)
+		},
+	}
+	result := writeBatch(ctx, set.client(ctx), s.dialect, stmt, rows)
+	return endBatch(owned, set, result).Error
+}
+
+func (s *Set) Remove(member string) error {
+	return s.RemoveContext(context.Background(), member)
+}
+
+func (s *Set) RemoveContext(ctx context.Context, member string) error {
+	return s.client(ctx).ExecContext(ctx, "delete from $table$ where name=?;", member).Error
+}
+
+func (s *Set) Contains(member string) (bool, error) {
+	return s.ContainsContext(context.Background(), member)
+}
+
+func (s *Set) ContainsContext(ctx context.Context, member string) (bool, error) {
+	res, err := s.client(ctx).QueryFirstContext(ctx, "select 1 from $table$ where name=?;", BoolScanner, member)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return res.(bool), nil
+}
+
+// Members returns a cursor over the set members. Entries are strings.
+func (s *Set) Members() (Cursor, error) {
+	return s.MembersContext(context.Background())
+}
+
+func (s *Set) MembersContext(ctx context.Context) (Cursor, error) {
+	return s.client(ctx).QueryContext(ctx, "select name from $table$;", StringScanner)
+}
+
+// Card returns the number of members of the set.
+func (s *Set) Card() (int64, error) {
+	return s.CardContext(context.Background())
+}
+
+func (s *Set) CardContext(ctx context.Context) (int64, error) {
+	o, err := s.client(ctx).QueryFirstContext(ctx, "select count(*) from $table$;", IntScanner)
+	if err != nil {
+		return 0, err
+	}
+	return o.(int64), nil
+}
+
+func (s *Set) Clear() error {
+	return s.ClearContext(context.Background())
+}
+
+func (s *Set) ClearContext(ctx context.Context) error {
+	return s.client(ctx).ExecContext(ctx, "delete from $table$;").Error
+}
+
+func (s *Set) Close() error {
+	return s.DB.sqlDb.Close()
+}
+
+// Union returns a cursor over the members of s or other.
+func (s *Set) Union(other *Set) (Cursor, error) {
+	return s.UnionContext(context.Background(), other)
+}
+
+func (s *Set) UnionContext(ctx context.Context, other *Set) (Cursor, error) {
+	return s.algebra(ctx, s.unionQuery, other)
+}
+
+// Intersect returns a cursor over the members of both s and other.
+func (s *Set) Intersect(other *Set) (Cursor, error) {
+	return s.IntersectContext(context.Background(), other)
+}
+
+func (s *Set) IntersectContext(ctx context.Context, other *Set) (Cursor, error) {
+	return s.algebra(ctx, s.intersectQuery, other)
+}
+
+// Diff returns a cursor over the members of s that are not in other.
+func (s *Set) Diff(other *Set) (Cursor, error) {
+	return s.DiffContext(context.Background(), other)
+}
+
+func (s *Set) DiffContext(ctx context.Context, other *Set) (Cursor, error) {
+	return s.algebra(ctx, s.diffQuery, other)
+}
+
+// UnionStore replaces the members of dest with the members of s or other.
+func (s *Set) UnionStore(other *Set, dest *Set) error {
+	return s.UnionStoreContext(context.Background(), other, dest)
+}
+
+func (s *Set) UnionStoreContext(ctx context.Context, other *Set, dest *Set) error {
+	return s.store(ctx, s.unionQuery, other, dest)
+}
+
+// IntersectStore replaces the members of dest with the members of both s and other.
+func (s *Set) IntersectStore(other *Set, dest *Set) error {
+	return s.IntersectStoreContext(context.Background(), other, dest)
+}
+
+func (s *Set) IntersectStoreContext(ctx context.Context, other *Set, dest *Set) error {
+	return s.store(ctx, s.intersectQuery, other, dest)
+}
+
+// DiffStore replaces the members of dest with the members of s that are not in other.
+func (s *Set) DiffStore(other *Set, dest *Set) error {
+	return s.DiffStoreContext(context.Background(), other, dest)
+}
+
+func (s *Set) DiffStoreContext(ctx context.Context, other *Set, dest *Set) error {
+	return s.store(ctx, s.diffQuery, other, dest)
+}
+
+func (s *Set) unionQuery(other *Set) string {
+	return fmt.Sprintf("select name from %s union select name from %s", s.tableName, other.tableName)
+}
+
+func (s *Set) intersectQuery(other *Set) string {
+	return fmt.Sprintf("select name from %s where name in (select name from %s)", s.tableName, other.tableName)
+}
+
+func (s *Set) diffQuery(other *Set) string {
+	return fmt.Sprintf("select name from %s where name not in (select name from %s)", s.tableName, other.tableName)
+}
+
+func (s *Set) algebra(ctx context.Context, query func(*Set) string, other *Set) (Cursor, error) {
+	if s.DB.sqlDb != other.DB.sqlDb {
+		return nil, errors.NotSupported()
+	}
+	return s.client(ctx).QueryContext(ctx, query(other)+";", StringScanner)
+}
+
+// store replaces the members of dest with the result of query. It runs in the transaction of ctx or in its own.
+// The result is read from a derived table so that dest can be one of the operands.
+func (s *Set) store(ctx context.Context, query func(*Set) string, other *Set, dest *Set) error {
+	if s.DB.sqlDb != other.DB.sqlDb || s.DB.sqlDb != dest.DB.sqlDb {
+		return errors.NotSupported()
+	}
+
+	owned := dest.tx == nil && transaction(ctx) == nil
+	ctx, set, err := dest.Transaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := fmt.Sprintf("select name from (%s) as result", query(other))
+	statements := []string{
+		fmt.Sprintf("delete from $table$ where name not in (%s);", result),
+		fmt.Sprintf("insert into $table$ (name) %s where name not in (select name from $table$);", result),
+	}
+	for _, statement := range statements {
+		if err = set.client(ctx).ExecContext(ctx, statement).Error; err != nil {
+			if owned {
+				_ = set.Rollback()
+			}
+			return err
+		}
+	}
+
+	if owned {
+		return set.Commit()
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package bome
+
+import (
+	"database/sql"
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var setA, setB, setC *Set
+
+func initSets(_ *testing.T) {
+	if setA == nil {
+		db, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+
+		for _, table := range []string{"set_a", "set_b", "set_c"} {
+			_, err = db.Exec("drop table if exists " + table)
+			So(err, ShouldBeNil)
+		}
+
+		setA, err = Build().SetConn(db).SetDialect(testDialect).SetTableName("set_a").Set()
+		So(err, ShouldBeNil)
+
+		setB, err = Build().SetConn(db).SetDialect(testDialect).SetTableName("set_b").Set()
+		So(err, ShouldBeNil)
+
+		setC, err = Build().SetConn(db).SetDialect(testDialect).SetTableName("set_c").Set()
+		So(err, ShouldBeNil)
+	}
+}
+
+func setMembers(c Cursor, err error) []string {
+	So(err, ShouldBeNil)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	var members []string
+	for c.HasNext() {
+		o, err := c.Entry()
+		So(err, ShouldBeNil)
+		members = append(members, o.(string))
+	}
+	return members
+}
+
+func TestSet_Add(t *testing.T) {
+	Convey("Members are added once", t, func() {
+		initSets(t)
+
+		So(setA.Add("a"), ShouldBeNil)
+		So(setA.Add("a"), ShouldBeNil)
+		So(setA.AddAll("b", "c", "b"), ShouldBeNil)
+		So(setB.AddAll("b", "c", "d"), ShouldBeNil)
+
+		card, err := setA.Card()
+		So(err, ShouldBeNil)
+		So(card, ShouldEqual, 3)
+
+		found, err := setA.Contains("c")
+		So(err, ShouldBeNil)
+		So(found, ShouldBeTrue)
+
+		So(setA.Remove("c"), ShouldBeNil)
+		found, err = setA.Contains("c")
+		So(err, ShouldBeNil)
+		So(found, ShouldBeFalse)
+
+		So(setMembers(setA.Members()), ShouldHaveLength, 2)
+	})
+
+	Convey("Members are added by chunks bounded by the placeholders limit", t, func() {
+		db, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+		_, err = db.Exec("drop table if exists set_chunks")
+		So(err, ShouldBeNil)
+
+		set, err := Build().SetConn(db).SetDialect(testDialect).SetTableName("set_chunks").Set()
+		So(err, ShouldBeNil)
+
+		var members []string
+		for i := 0; i < 2500; i++ {
+			members = append(members, strconv.Itoa(i%2000))
+		}
+		So(set.AddAll(members...), ShouldBeNil)
+
+		card, err := set.Card()
+		So(err, ShouldBeNil)
+		So(card, ShouldEqual, 2000)
+
+		So(set.Close(), ShouldBeNil)
+	})
+}
+
+func TestSet_Algebra(t *testing.T) {
+	Convey("Set algebra is executed in SQL", t, func() {
+		initSets(t)
+
+		So(setMembers(setA.Union(setB)), ShouldHaveLength, 4)
+		So(setMembers(setA.Intersect(setB)), ShouldResemble, []string{"b"})
+		So(setMembers(setA.Diff(setB)), ShouldResemble, []string{"a"})
+
+		So(setA.UnionStore(setB, setC), ShouldBeNil)
+		card, err := setC.Card()
+		So(err, ShouldBeNil)
+		So(card, ShouldEqual, 4)
+
+		So(setC.DiffStore(setB, setC), ShouldBeNil)
+		So(setMembers(setC.Members()), ShouldResemble, []string{"a"})
+
+		So(setA.IntersectStore(setB, setA), ShouldBeNil)
+		So(setMembers(setA.Members()), ShouldResemble, []string{"b"})
+	})
+}
@@ -1,6 +1,7 @@
 package bome
 
 import (
+	"context"
 	"database/sql"
 	"strings"
 
@@ -23,14 +24,20 @@ func (tx *TX) New(db *DB) *TX {
 
 // Exec executes the statement saved as name.
 func (tx *TX) Exec(query string, args ...interface{}) Result {
+	return tx.ExecContext(context.Background(), query, args...)
+}
+
+// ExecContext executes query with ctx.
+func (tx *TX) ExecContext(ctx context.Context, query string, args ...interface{}) Result {
 	for name, value := range tx.db.vars {
 		query = strings.Replace(query, name, value, -1)
 	}
+	query = tx.db.dialect.Rebind(query)
 
 	var r sql.Result
 	result := Result{}
-	r, result.Error = tx.Tx.Exec(query, args...)
-	if result.Error == nil && tx.db.dialect != SQLite3 {
+	r, result.Error = tx.Tx.ExecContext(ctx, query, args...)
+	if result.Error == nil {
 		result.LastInserted, _ = r.LastInsertId()
 		result.AffectedRows, _ = r.RowsAffected()
 	}
@@ -39,10 +46,16 @@ func (tx *TX) Exec(query string, args ...interface{}) Result {
 
 // Query executes the query statement saved as name.
 func (tx *TX) Query(query string, scannerName string, args ...interface{}) (Cursor, error) {
+	return tx.QueryContext(context.Background(), query, scannerName, args...)
+}
+
+// QueryContext executes query with ctx.
+func (tx *TX) QueryContext(ctx context.Context, query string, scannerName string, args ...interface{}) (Cursor, error) {
 	for name, value := range tx.db.vars {
 		query = strings.Replace(query, name, value, -1)
 	}
-	rows, err := tx.Tx.Query(query, args...)
+	query = tx.db.dialect.Rebind(query)
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -56,10 +69,16 @@ func (tx *TX) Query(query string, scannerName string, args ...interface{}) (Curs
 // QueryObjects executes a raw query.
 // scannerName: is one of the registered scanner name.
 func (tx *TX) QueryObjects(query string, params ...interface{}) (Cursor, error) {
+	return tx.QueryObjectsContext(context.Background(), query, params...)
+}
+
+// QueryObjectsContext executes a raw query with ctx.
+func (tx *TX) QueryObjectsContext(ctx context.Context, query string, params ...interface{}) (Cursor, error) {
 	for name, value := range tx.db.vars {
 		query = strings.Replace(query, name, value, -1)
 	}
-	rows, err := tx.db.sqlDb.Query(query, params...)
+	query = tx.db.dialect.Rebind(query)
+	rows, err := tx.Tx.QueryContext(ctx, query, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -72,11 +91,17 @@ func (tx *TX) QueryObjects(query string, params ...interface{}) (Cursor, error)
 
 // QueryFirst get the first result of the query statement saved as name.
 func (tx *TX) QueryFirst(query string, scannerName string, args ...interface{}) (interface{}, error) {
+	return tx.QueryFirstContext(context.Background(), query, scannerName, args...)
+}
+
+// QueryFirstContext get the first result of query with ctx.
+func (tx *TX) QueryFirstContext(ctx context.Context, query string, scannerName string, args ...interface{}) (interface{}, error) {
 	for name, value := range tx.db.vars {
 		query = strings.Replace(query, name, value, -1)
 	}
+	query = tx.db.dialect.Rebind(query)
 
-	rows, err := tx.Tx.Query(query, args...)
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
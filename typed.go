@@ -0,0 +1,295 @@
+package bome
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+)
+
+// TypedEntry is a typed map entry.
+type TypedEntry[T any] struct {
+	Key   string
+	Value T
+}
+
+// TypedListEntry is a typed list entry.
+type TypedListEntry[T any] struct {
+	Index int64
+	Value T
+}
+
+// TypedDoubleMapEntry is a typed double map entry.
+type TypedDoubleMapEntry[T any] struct {
+	FirstKey  string
+	SecondKey string
+	Value     T
+}
+
+func decode[T any](value string) (T, error) {
+	var o T
+	err := json.Unmarshal([]byte(value), &o)
+	return o, err
+}
+
+func encode[T any](o T) (string, error) {
+	data, err := json.Marshal(o)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// TypedCursor iterates over typed entries. Entries are decoded from the rows of the wrapped cursor.
+type TypedCursor[E any] struct {
+	cursor Cursor
+	decode func(o interface{}) (E, error)
+}
+
+func (c *TypedCursor[E]) HasNext() bool {
+	return c.cursor.HasNext()
+}
+
+// Next returns the current entry.
+func (c *TypedCursor[E]) Next() (E, error) {
+	o, err := c.cursor.Entry()
+	if err != nil {
+		var e E
+		return e, err
+	}
+	return c.decode(o)
+}
+
+// All reads all the remaining entries and closes the cursor.
+func (c *TypedCursor[E]) All() ([]E, error) {
+	defer func() {
+		if err := c.Close(); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	var entries []E
+	for c.HasNext() {
+		e, err := c.Next()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (c *TypedCursor[E]) Close() error {
+	return c.cursor.Close()
+}
+
+func typedMapEntry[T any](o interface{}) (TypedEntry[T], error) {
+	entry := o.(*MapEntry)
+	value, err := decode[T](entry.Value)
+	return TypedEntry[T]{Key: entry.Key, Value: value}, err
+}
+
+func typedListEntry[T any](o interface{}) (TypedListEntry[T], error) {
+	entry := o.(*ListEntry)
+	value, err := decode[T](entry.Value)
+	return TypedListEntry[T]{Index: entry.Index, Value: value}, err
+}
+
+func typedDoubleMapEntry[T any](o interface{}) (TypedDoubleMapEntry[T], error) {
+	entry := o.(*DoubleMapEntry)
+	value, err := decode[T](entry.Value)
+	return TypedDoubleMapEntry[T]{FirstKey: entry.FirstKey, SecondKey: entry.SecondKey, Value: value}, err
+}
+
+// TypedMap is a Map which values are JSON encoded T.
+type TypedMap[T any] struct {
+	*Map
+}
+
+// NewTypedMap wraps m into a TypedMap.
+func NewTypedMap[T any](m *Map) *TypedMap[T] {
+	return &TypedMap[T]{Map: m}
+}
+
+func (m *TypedMap[T]) Transaction(ctx context.Context) (context.Context, *TypedMap[T], error) {
+	ctx, tm, err := m.Map.Transaction(ctx)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return ctx, NewTypedMap[T](tm), nil
+}
+
+func (m *TypedMap[T]) Save(key string, value T, opts SaveOptions) error {
+	return m.Map.Save(key, value, opts)
+}
+
+func (m *TypedMap[T]) Get(key string) (T, error) {
+	value, err := m.Map.GetRaw(key)
+	if err != nil {
+		var o T
+		return o, err
+	}
+	return decode[T](value)
+}
+
+func (m *TypedMap[T]) Range(offset, count int) ([]TypedEntry[T], error) {
+	entries, err := m.Map.Range(offset, count)
+	if err != nil {
+		return nil, err
+	}
+
+	var typedEntries []TypedEntry[T]
+	for _, entry := range entries {
+		typedEntry, err := typedMapEntry[T](entry)
+		if err != nil {
+			return nil, err
+		}
+		typedEntries = append(typedEntries, typedEntry)
+	}
+	return typedEntries, nil
+}
+
+func (m *TypedMap[T]) List() (*TypedCursor[TypedEntry[T]], error) {
+	c, err := m.Map.List()
+	if err != nil {
+		return nil, err
+	}
+	return &TypedCursor[TypedEntry[T]]{cursor: c, decode: typedMapEntry[T]}, nil
+}
+
+// TypedList is a List which values are JSON encoded T.
+type TypedList[T any] struct {
+	*List
+}
+
+// NewTypedList wraps l into a TypedList.
+func NewTypedList[T any](l *List) *TypedList[T] {
+	return &TypedList[T]{List: l}
+}
+
+func (l *TypedList[T]) Transaction(ctx context.Context) (context.Context, *TypedList[T], error) {
+	ctx, tl, err := l.List.Transaction(ctx)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return ctx, NewTypedList[T](tl), nil
+}
+
+// Append adds value at the end of the list.
+func (l *TypedList[T]) Append(value T) error {
+	encoded, err := encode(value)
+	if err != nil {
+		return err
+	}
+	return l.List.Save(encoded)
+}
+
+func (l *TypedList[T]) SaveAt(index int64, value T, opts SaveOptions) error {
+	return l.List.SaveAt(index, value, opts)
+}
+
+func (l *TypedList[T]) Get(index int64) (T, error) {
+	var o T
+	err := l.List.Read(index, &o)
+	return o, err
+}
+
+func (l *TypedList[T]) Range(offset, count int) (*TypedCursor[TypedListEntry[T]], error) {
+	c, err := l.List.Range(offset, count)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedCursor[TypedListEntry[T]]{cursor: c, decode: typedListEntry[T]}, nil
+}
+
+func (l *TypedList[T]) After(index int64) (*TypedCursor[TypedListEntry[T]], int64, error) {
+	c, total, err := l.List.IndexAfter(index)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &TypedCursor[TypedListEntry[T]]{cursor: c, decode: typedListEntry[T]}, total, nil
+}
+
+// TypedDMap is a DMap which values are JSON encoded T.
+type TypedDMap[T any] struct {
+	*DMap
+}
+
+// NewTypedDMap wraps m into a TypedDMap.
+func NewTypedDMap[T any](m *DMap) *TypedDMap[T] {
+	return &TypedDMap[T]{DMap: m}
+}
+
+func (m *TypedDMap[T]) Transaction(ctx context.Context) (context.Context, *TypedDMap[T], error) {
+	ctx, tm, err := m.DMap.Transaction(ctx)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return ctx, NewTypedDMap[T](tm), nil
+}
+
+func (m *TypedDMap[T]) Save(key1, key2 string, value T, opts SaveOptions) error {
+	encoded, err := encode(value)
+	if err != nil {
+		return err
+	}
+	return m.DMap.Save(key1, key2, encoded, opts)
+}
+
+func (m *TypedDMap[T]) Get(key1, key2 string) (T, error) {
+	value, err := m.DMap.ReadRaw(key1, key2)
+	if err != nil {
+		var o T
+		return o, err
+	}
+	return decode[T](value)
+}
+
+func (m *TypedDMap[T]) Range(offset, count int) ([]TypedDoubleMapEntry[T], error) {
+	entries, err := m.DMap.Range(offset, count)
+	if err != nil {
+		return nil, err
+	}
+
+	var typedEntries []TypedDoubleMapEntry[T]
+	for _, entry := range entries {
+		typedEntry, err := typedDoubleMapEntry[T](entry)
+		if err != nil {
+			return nil, err
+		}
+		typedEntries = append(typedEntries, typedEntry)
+	}
+	return typedEntries, nil
+}
+
+func (m *TypedDMap[T]) RangeByFirstKey(key string, offset, count int) ([]TypedEntry[T], error) {
+	entries, err := m.DMap.RangeByFirstKey(key, offset, count)
+	if err != nil {
+		return nil, err
+	}
+
+	var typedEntries []TypedEntry[T]
+	for _, entry := range entries {
+		typedEntry, err := typedMapEntry[T](entry)
+		if err != nil {
+			return nil, err
+		}
+		typedEntries = append(typedEntries, typedEntry)
+	}
+	return typedEntries, nil
+}
+
+func (m *TypedDMap[T]) GetForFirst(key1 string) (*TypedCursor[TypedEntry[T]], error) {
+	c, err := m.DMap.GetForFirst(key1)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedCursor[TypedEntry[T]]{cursor: c, decode: typedMapEntry[T]}, nil
+}
+
+func (m *TypedDMap[T]) GetAll() (*TypedCursor[TypedDoubleMapEntry[T]], error) {
+	c, err := m.DMap.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return &TypedCursor[TypedDoubleMapEntry[T]]{cursor: c, decode: typedDoubleMapEntry[T]}, nil
+}
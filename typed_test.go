@@ -0,0 +1,115 @@
+package bome
+
+import (
+	"database/sql"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type typedUser struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+var (
+	typedUsers     *TypedMap[typedUser]
+	typedUsersList *TypedList[typedUser]
+	typedUsersDMap *TypedDMap[typedUser]
+)
+
+func initTypedCollections(_ *testing.T) {
+	if typedUsers == nil {
+		db, err := sql.Open(testDialect, testDBPath)
+		So(err, ShouldBeNil)
+
+		for _, table := range []string{"typed_map", "typed_list", "typed_dmap"} {
+			_, err = db.Exec("drop table if exists " + table)
+			So(err, ShouldBeNil)
+		}
+
+		m, err := Build().SetConn(db).SetDialect(testDialect).SetTableName("typed_map").Map()
+		So(err, ShouldBeNil)
+		typedUsers = NewTypedMap[typedUser](m)
+
+		l, err := Build().SetConn(db).SetDialect(testDialect).SetTableName("typed_list").List()
+		So(err, ShouldBeNil)
+		typedUsersList = NewTypedList[typedUser](l)
+
+		dm, err := Build().SetConn(db).SetDialect(testDialect).SetTableName("typed_dmap").DMap()
+		So(err, ShouldBeNil)
+		typedUsersDMap = NewTypedDMap[typedUser](dm)
+	}
+}
+
+func TestTypedMap(t *testing.T) {
+	Convey("Typed map values are decoded into T", t, func() {
+		initTypedCollections(t)
+
+		So(typedUsers.Save("u1", typedUser{Name: "john", Age: 21}, SaveOptions{}), ShouldBeNil)
+		So(typedUsers.Save("u2", typedUser{Name: "jane", Age: 34}, SaveOptions{}), ShouldBeNil)
+
+		user, err := typedUsers.Get("u1")
+		So(err, ShouldBeNil)
+		So(user, ShouldResemble, typedUser{Name: "john", Age: 21})
+
+		_, err = typedUsers.Get("u3")
+		So(err, ShouldNotBeNil)
+
+		entries, err := typedUsers.Range(0, 10)
+		So(err, ShouldBeNil)
+		So(entries, ShouldHaveLength, 2)
+
+		c, err := typedUsers.List()
+		So(err, ShouldBeNil)
+		all, err := c.All()
+		So(err, ShouldBeNil)
+		So(all, ShouldHaveLength, 2)
+		So(all[1].Value.Name, ShouldEqual, "jane")
+	})
+}
+
+func TestTypedList(t *testing.T) {
+	Convey("Typed list values are decoded into T", t, func() {
+		initTypedCollections(t)
+
+		So(typedUsersList.Append(typedUser{Name: "john", Age: 21}), ShouldBeNil)
+		So(typedUsersList.Append(typedUser{Name: "jane", Age: 34}), ShouldBeNil)
+
+		user, err := typedUsersList.Get(2)
+		So(err, ShouldBeNil)
+		So(user.Name, ShouldEqual, "jane")
+
+		c, total, err := typedUsersList.After(0)
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 2)
+
+		all, err := c.All()
+		So(err, ShouldBeNil)
+		So(all, ShouldHaveLength, 2)
+		So(all[0].Index, ShouldEqual, 1)
+		So(all[0].Value.Age, ShouldEqual, 21)
+	})
+}
+
+func TestTypedDMap(t *testing.T) {
+	Convey("Typed double map values are decoded into T", t, func() {
+		initTypedCollections(t)
+
+		So(typedUsersDMap.Save("g1", "u1", typedUser{Name: "john", Age: 21}, SaveOptions{}), ShouldBeNil)
+		So(typedUsersDMap.Save("g1", "u2", typedUser{Name: "jane", Age: 34}, SaveOptions{}), ShouldBeNil)
+
+		user, err := typedUsersDMap.Get("g1", "u2")
+		So(err, ShouldBeNil)
+		So(user.Age, ShouldEqual, 34)
+
+		entries, err := typedUsersDMap.RangeByFirstKey("g1", 0, 10)
+		So(err, ShouldBeNil)
+		So(entries, ShouldHaveLength, 2)
+
+		doubleEntries, err := typedUsersDMap.Range(0, 10)
+		So(err, ShouldBeNil)
+		So(doubleEntries, ShouldHaveLength, 2)
+		So(doubleEntries[0].FirstKey, ShouldEqual, "g1")
+	})
+}
@@ -0,0 +1,158 @@
+package bome
+
+import (
+	"context"
+	"strings"
+)
+
+// saveMode tells how a save statement handles rows which keys already exist.
+type saveMode int
+
+const (
+	// saveInsert fails on existing keys.
+	saveInsert saveMode = iota
+
+	// saveIfAbsent leaves existing rows unchanged.
+	saveIfAbsent
+
+	// saveUpsert updates existing rows.
+	saveUpsert
+)
+
+func (opts SaveOptions) mode() saveMode {
+	switch {
+	case opts.OnlyIfAbsent:
+		return saveIfAbsent
+	case opts.UpdateExisting:
+		return saveUpsert
+	default:
+		return saveInsert
+	}
+}
+
+// statement is a write statement of a variable number of rows.
+type statement struct {
+	// width is the number of arguments of a row.
+	width int
+
+	// query returns the statement that writes n rows.
+	query func(n int) string
+
+	// exec runs rawQuery with the arguments of n rows.
+	exec func(ctx context.Context, client Client, rawQuery string, n int, args []interface{}) Result
+}
+
+func (s *statement) run(ctx context.Context, client Client, n int, args []interface{}) Result {
+	return s.exec(ctx, client, s.query(n), n, args)
+}
+
+// saveStatement returns the statement that saves rows of columns in a versioned table. With saveUpsert,
+// the updates columns of existing rows are updated and their version is incremented.
+// Results tell how many rows were inserted and updated:
+//
+// SQLite and PostgreSQL return the version of the written rows, which is 1 only for inserted rows.
+// MySQL reports 1 affected row for each inserted row and 2 for each updated row.
+func saveStatement(dialect Dialect, mode saveMode, columns, keys, updates []string) *statement {
+	s := &statement{width: len(columns)}
+
+	switch mode {
+	case saveInsert:
+		single := "insert into $table$ (" + strings.Join(columns, ", ") + ") values (" + placeholders(len(columns)) + ")"
+		s.query = func(n int) string {
+			return multiRow(single, len(columns), n) + ";"
+		}
+		s.exec = execInserts
+
+	case saveIfAbsent:
+		single := dialect.Upsert("$table$", columns, keys, nil)
+		s.query = func(n int) string {
+			return multiRow(single, len(columns), n) + ";"
+		}
+		s.exec = execInserts
+
+	case saveUpsert:
+		single := dialect.Upsert("$table$", columns, keys, append(append([]string{}, updates...), "version=version+1"))
+		if dialect.SupportsReturning() {
+			s.query = func(n int) string {
+				return multiRow(single, len(columns), n) + " returning version;"
+			}
+			s.exec = execReturningVersions
+		} else {
+			s.query = func(n int) string {
+				return multiRow(single, len(columns), n) + ";"
+			}
+			s.exec = execUpserts
+		}
+	}
+	return s
+}
+
+// multiRow turns rawQuery, which inserts one row of width values, into the insert of n rows.
+func multiRow(rawQuery string, width int, n int) string {
+	if n == 1 {
+		return rawQuery
+	}
+	row := "(" + placeholders(width) + ")"
+	rows := strings.TrimSuffix(strings.Repeat(row+",", n), ",")
+	return strings.Replace(rawQuery, "values "+row, "values "+rows, 1)
+}
+
+func execInserts(ctx context.Context, client Client, rawQuery string, _ int, args []interface{}) Result {
+	result := client.ExecContext(ctx, rawQuery, args...)
+	if result.Error == nil {
+		result.Inserted = result.AffectedRows
+	}
+	return result
+}
+
+func execUpserts(ctx context.Context, client Client, rawQuery string, n int, args []interface{}) Result {
+	result := client.ExecContext(ctx, rawQuery, args...)
+	if result.Error != nil {
+		return result
+	}
+	result.Updated = result.AffectedRows - int64(n)
+	result.Inserted = int64(n) - result.Updated
+	result.AffectedRows = int64(n)
+	return result
+}
+
+func execReturningVersions(ctx context.Context, client Client, rawQuery string, _ int, args []interface{}) Result {
+	c, err := client.QueryContext(ctx, rawQuery, IntScanner, args...)
+	if err != nil {
+		return Result{Error: err}
+	}
+	defer func() {
+		_ = c.Close()
+	}()
+
+	var result Result
+	for c.HasNext() {
+		o, err := c.Entry()
+		if err != nil {
+			return Result{Error: err}
+		}
+		if o.(int64) == 1 {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+		result.AffectedRows++
+	}
+	return result
+}
+
+// upsert inserts a row of columns in a versioned table or updates the updates columns and increments the version
+// of the row with the same keys. The result tells if the row was inserted or updated.
+func upsert(ctx context.Context, client Client, dialect Dialect, columns, keys, updates []string, args ...interface{}) Result {
+	return saveStatement(dialect, saveUpsert, columns, keys, updates).run(ctx, client, 1, args)
+}
+
+// insertIfAbsent inserts a row of columns unless a row with the same keys exists.
+func insertIfAbsent(ctx context.Context, client Client, dialect Dialect, columns, keys []string, args ...interface{}) Result {
+	return saveStatement(dialect, saveIfAbsent, columns, keys, nil).run(ctx, client, 1, args)
+}
+
+// insert inserts a row of columns. It fails if a row with the same keys exists.
+func insert(ctx context.Context, client Client, dialect Dialect, columns []string, args ...interface{}) Result {
+	return saveStatement(dialect, saveInsert, columns, nil, nil).run(ctx, client, 1, args)
+}
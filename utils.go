@@ -2,17 +2,17 @@ package bome
 
 import "strings"
 
+// mysqlEscaper escapes the characters that are special in MySQL string literals.
+// It replaces in a single pass so that inserted backslashes are never escaped twice.
+var mysqlEscaper = strings.NewReplacer("\\", "\\\\", "'", `\'`, "\x00", "\\0", "\n", "\\n", "\r", "\\r", `"`, `\"`, "\x1a", "\\Z")
+
 func escaped(value string) string {
-	replace := map[string]string{"\\": "\\\\", "'": `\'`, "\\0": "\\\\0", "\n": "\\n", "\r": "\\r", `"`: `\"`, "\x1a": "\\Z"}
-	for b, a := range replace {
-		value = strings.Replace(value, b, a, -1)
-	}
-	return value
+	return mysqlEscaper.Replace(value)
 }
 
 func normalizedJsonPath(jp string) string {
 	jp = strings.Replace(jp, "/", ".", -1)
-	if strings.HasPrefix(jp, "$.") {
+	if jp == "$" || strings.HasPrefix(jp, "$.") {
 		return jp
 	}
 	if strings.HasPrefix(jp, ".") {
@@ -20,3 +20,11 @@ func normalizedJsonPath(jp string) string {
 	}
 	return "$." + jp
 }
+
+// placeholders returns n comma separated '?' placeholders.
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat("?,", n-1) + "?"
+}
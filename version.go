@@ -0,0 +1,26 @@
+package bome
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// versionResultError returns a VersionConflictError if the write guarded by the expected version changed no entry.
+func versionResultError(result Result, expected int64) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.AffectedRows == 0 {
+		return &VersionConflictError{Expected: expected}
+	}
+	return nil
+}
+
+// sameJSON tells if a and b are encodings of the same JSON value.
+func sameJSON(a, b string) bool {
+	var va, vb interface{}
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return a == b
+	}
+	return reflect.DeepEqual(va, vb)
+}